@@ -2,35 +2,65 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strconv"
+	"strings"
 	"time"
 
 	"textlib-rl-system/internal/analyzer"
 	"textlib-rl-system/internal/logging"
 	"textlib-rl-system/internal/rl"
+	"textlib-rl-system/internal/rl/dataset"
+	"textlib-rl-system/internal/rl/model"
 	"textlib-rl-system/internal/telemetry"
 )
 
 func main() {
 	// Parse command line flags
 	var (
-		mode          = flag.String("mode", "train", "Mode: train, generate-report, health-check, or cleanup-logs")
+		mode          = flag.String("mode", "train", "Mode: train, bandit, generate-report, convert-model, health-check, or cleanup-logs")
 		maxEpisodes   = flag.Int("episodes", 10000, "Maximum training episodes")
 		logLevel      = flag.String("log-level", "info", "Logging level")
 		checkpointDir = flag.String("checkpoint-dir", "./models", "Checkpoint directory")
+		keepLast      = flag.Int("keep-last", 0, "Number of checkpoints to keep (0 uses the config, which defaults to 5)")
 		enableProfile = flag.Bool("profile", false, "Enable CPU/memory profiling")
 		configFile    = flag.String("config", "", "Configuration file path")
 		inputFile     = flag.String("input", "", "Input file for report generation")
 		outputFile    = flag.String("output", "", "Output file for report generation")
 		modelFile     = flag.String("model", "", "Model file for report generation")
+		modelFormat   = flag.String("model-format", "", "Encoding of saved and converted models: json or binary (default json; --mode=convert-model infers it from --output)")
+		compress      = flag.Bool("compress", false, "Gzip saved and converted models")
+		agentType     = flag.String("agent", rl.AgentKindQLearning, fmt.Sprintf("Learning agent: one of %v", rl.AgentKinds()))
+		exploration   = flag.String("exploration", "", "Exploration strategy: epsilon_greedy, boltzmann or ucb1 (default from config)")
+		tuneInterval  = flag.Int("tune-interval", 0, "Tune function parameters with the GA every N episodes (0 uses the config, which defaults to off)")
+		bandit        = flag.String("bandit", "", "Bandit algorithm for --mode=bandit: linucb or thompson (default from config)")
+		dataPath      = flag.String("data", "", "Training data: a .jsonl file, a .csv file or a directory of .txt files (default: built-in examples)")
+		dataColumns   = flag.String("data-columns", "", "CSV column mapping, e.g. text=body,task_type=category (default: columns named after the fields)")
+		split         = flag.String("split", "", "Held-out split strategy: random or stratified (default from config)")
+		valFraction   = flag.Float64("val-fraction", 0, "Fraction of the data held out for validation every logging interval (0 uses the config)")
+		testFraction  = flag.Float64("test-fraction", 0, "Fraction of the data held out for the final test report (0 uses the config)")
+		curriculum    = flag.String("curriculum", "", "Curriculum by example difficulty: uniform, easy_to_hard, competence or self_paced (default from config)")
+		executorName  = flag.String("executor", rl.ExecutorSimulator, fmt.Sprintf("Action executor: one of %v", rl.ExecutorNames()))
+		seed          = flag.Int64("seed", 0, "Random seed; the same seed replays the same run on the simulator (0 uses the config, or picks one from the clock)")
+		resume        = flag.String("resume", "", "Continue training from a checkpoint file, or the newest checkpoint in a directory, with its saved configuration")
 	)
 	flag.Parse()
 
+	// A resumed run keeps its saved episode budget unless --episodes is given
+	if *resume != "" && !flagPassed("episodes") {
+		*maxEpisodes = 0
+	}
+	// The agent named in --config wins over the flag's default
+	if !flagPassed("agent") {
+		*agentType = ""
+	}
+
 	// Set resource limits as specified in the design
 	runtime.GOMAXPROCS(2)
 
@@ -42,11 +72,25 @@ func main() {
 	// Configure logging level
 	configureLogging(*logLevel)
 
+	trainingData := func() []rl.TrainingExample {
+		return loadTrainingData(*dataPath, *dataColumns)
+	}
+
+	encoding := model.Encoding{Format: *modelFormat, Compress: *compress}
+	if err := encoding.Validate(); err != nil {
+		log.Fatalf("Invalid --model-format: %v", err)
+	}
+
 	switch *mode {
 	case "train":
-		runTraining(*maxEpisodes, *checkpointDir, *enableProfile, *configFile)
+		runTraining(*maxEpisodes, *checkpointDir, *keepLast, *enableProfile, *configFile, *agentType, *exploration, *tuneInterval, *executorName, trainingData(),
+			rl.SplitConfig{Strategy: *split, ValidationFraction: *valFraction, TestFraction: *testFraction}, *curriculum, *seed, *resume, encoding)
+	case "bandit":
+		runBandit(*maxEpisodes, *configFile, *bandit, *outputFile, *executorName, trainingData(), *seed)
 	case "generate-report":
 		generateReport(*inputFile, *outputFile, *modelFile)
+	case "convert-model":
+		convertModel(*inputFile, *outputFile, encoding)
 	case "health-check":
 		healthCheck()
 	case "cleanup-logs":
@@ -56,7 +100,15 @@ func main() {
 	}
 }
 
-func runTraining(maxEpisodes int, checkpointDir string, enableProfiling bool, configFile string) {
+// flagPassed reports whether the named flag was given on the command line,
+// as opposed to holding its default.
+func flagPassed(name string) bool {
+	passed := false
+	flag.Visit(func(f *flag.Flag) { passed = passed || f.Name == name })
+	return passed
+}
+
+func runTraining(maxEpisodes int, checkpointDir string, keepLast int, enableProfiling bool, configFile string, agentType string, exploration string, tuneInterval int, executorName string, trainingData []rl.TrainingExample, split rl.SplitConfig, curriculum string, seed int64, resume string, encoding model.Encoding) {
 	log.Println("Starting RL training with comprehensive logging...")
 
 	// Perform automatic log cleanup before training
@@ -82,23 +134,113 @@ func runTraining(maxEpisodes int, checkpointDir string, enableProfiling bool, co
 
 	// Load configuration
 	config := loadConfiguration(configFile, maxEpisodes, enableProfiling)
+	if agentType != "" {
+		config.AgentType = agentType
+	}
+	if exploration != "" {
+		config.Exploration.Strategy = exploration
+	}
+	if tuneInterval > 0 {
+		config.ParameterTuningInterval = tuneInterval
+	}
+	if split.Strategy != "" {
+		config.SplitStrategy = split.Strategy
+	}
+	if split.ValidationFraction > 0 {
+		config.ValidationFraction = split.ValidationFraction
+	}
+	if split.TestFraction > 0 {
+		config.TestFraction = split.TestFraction
+	}
+
+	if curriculum != "" {
+		config.Curriculum = curriculum
+	}
+	if err := rl.ValidateCurriculum(config); err != nil {
+		log.Fatalf("Invalid curriculum: %v", err)
+	}
+
+	var checkpoint rl.Checkpoint
+	if resume != "" {
+		var err error
+		checkpoint, err = rl.LoadCheckpoint(resume)
+		if err != nil {
+			log.Fatalf("Failed to load checkpoint: %v", err)
+		}
+		// The saved configuration replaces the flags so the run continues as
+		// it started; only the episode budget may be extended
+		config = checkpoint.Config
+		if maxEpisodes > 0 {
+			config.MaxEpisodes = maxEpisodes
+		}
+		log.Printf("Resuming from episode %d of %d with seed %d", checkpoint.Episode, config.MaxEpisodes, config.Seed)
+	} else {
+		applySeed(&config, seed)
+	}
+	config.CheckpointDir = checkpointDir
+	if keepLast > 0 {
+		config.CheckpointKeepLast = keepLast
+	}
+
+	splits, err := rl.SplitExamples(trainingData, rl.SplitConfigFrom(config))
+	if err != nil {
+		log.Fatalf("Failed to split training data: %v", err)
+	}
+	if rl.SplitConfigFrom(config).Enabled() {
+		log.Printf("Split %d examples into %d train, %d validation and %d test",
+			len(trainingData), len(splits.Train), len(splits.Validation), len(splits.Test))
+	}
+
+	agent, err := rl.NewAgent(config)
+	if err != nil {
+		log.Fatalf("Failed to create agent: %v", err)
+	}
+	log.Printf("Using %s agent", config.AgentType)
+
+	executor, err := rl.NewExecutor(executorName, config)
+	if err != nil {
+		log.Fatalf("Failed to create executor: %v", err)
+	}
+	log.Printf("Using %s executor", executor.Name())
 
 	// Initialize RL system
 	system := rl.NewEnhancedRLSystem(config)
+	system.SetAgent(agent)
+	system.SetExecutor(executor)
 	system.SetLogger(logger)
 	system.SetTelemetry(telemetry)
 
-	// Load training data
-	trainingData := loadTrainingData()
-	system.LoadTrainingData(trainingData)
+	system.LoadTrainingData(splits.Train)
+	system.LoadValidationData(splits.Validation)
+	if resume != "" {
+		if err := system.Resume(checkpoint); err != nil {
+			log.Fatalf("Failed to resume: %v", err)
+		}
+	}
 
 	// Start training
-	log.Printf("Starting training with %d episodes...", maxEpisodes)
+	log.Printf("Starting training with %d episodes...", config.MaxEpisodes)
 	system.TrainWithLogging()
 
+	// Score the held-out splits with the final policy
+	var validationHistory []analyzer.SplitEvaluation
+	for _, result := range system.ValidationHistory() {
+		validationHistory = append(validationHistory, analyzer.SplitEvaluation(result))
+	}
+	var testEvaluation *analyzer.SplitEvaluation
+	if len(splits.Test) > 0 {
+		result := system.Evaluate("test", splits.Test)
+		result.Episode = config.MaxEpisodes
+		testEvaluation = (*analyzer.SplitEvaluation)(&result)
+		log.Printf("Test: reward %.3f, quality %.3f, success %.1f%% over %d examples",
+			result.AverageReward, result.AverageQuality, result.SuccessRate*100, result.Examples)
+	}
+
 	// Generate final insights
-	analyzer := analyzer.NewInsightAnalyzer(logger, logger.MetricsDB, maxEpisodes)
+	analyzer := analyzer.NewInsightAnalyzer(logger, logger.MetricsDB, config.MaxEpisodes)
 	insights := analyzer.GenerateInsights()
+	insights.ValidationHistory = validationHistory
+	insights.TestEvaluation = testEvaluation
 
 	// Save insights
 	if err := saveInsights(insights, "./logs/insights.json"); err != nil {
@@ -106,13 +248,69 @@ func runTraining(maxEpisodes int, checkpointDir string, enableProfiling bool, co
 	}
 
 	// Save final model
-	if err := saveFinalModel(system, checkpointDir); err != nil {
+	if err := saveFinalModel(system, checkpointDir, encoding); err != nil {
 		log.Printf("Failed to save final model: %v", err)
 	}
 
 	log.Println("Training completed successfully.")
 }
 
+// runBandit learns the best first call per kind of text with a contextual
+// bandit, one executed call per round, and writes the recommendations as an
+// API feedback report.
+func runBandit(rounds int, configFile string, algorithm string, outputFile string, executorName string, trainingData []rl.TrainingExample, seed int64) {
+	log.Println("Starting contextual bandit training...")
+
+	if outputFile == "" {
+		outputFile = "./logs/bandit_report.json"
+	}
+
+	config := loadConfiguration(configFile, rounds, false)
+	if algorithm != "" {
+		config.BanditAlgorithm = algorithm
+	}
+	applySeed(&config, seed)
+
+	trainer, err := rl.NewBanditTrainer(config, trainingData)
+	if err != nil {
+		log.Fatalf("Failed to create bandit: %v", err)
+	}
+	executor, err := rl.NewExecutor(executorName, config)
+	if err != nil {
+		log.Fatalf("Failed to create executor: %v", err)
+	}
+	trainer.SetExecutor(executor)
+	log.Printf("Using %s bandit over %d arms for %d rounds on the %s executor", trainer.Policy.Name(), len(trainer.Arms), config.MaxEpisodes, executor.Name())
+
+	totalReward := 0.0
+	for round := 1; round <= config.MaxEpisodes; round++ {
+		_, reward := trainer.Step(trainer.SampleExample())
+		totalReward += reward
+		if config.LoggingInterval > 0 && round%config.LoggingInterval == 0 {
+			log.Printf("Round %d: average reward %.3f", round, totalReward/float64(round))
+		}
+	}
+
+	report := analyzer.APIFeedbackReport{
+		Timestamp:      time.Now(),
+		AnalysisPeriod: config.MaxEpisodes,
+	}
+	for _, recommendation := range trainer.Recommendations() {
+		report.FirstCallRecommendations = append(report.FirstCallRecommendations, analyzer.FirstCallRecommendation(recommendation))
+		report.Recommendations = append(report.Recommendations, fmt.Sprintf(
+			"For %s texts, call %s first (expected reward %.2f over %d rounds)",
+			recommendation.Cluster, recommendation.Function, recommendation.ExpectedReward, recommendation.Rounds))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputFile), 0755); err != nil {
+		log.Fatalf("Failed to create report directory: %v", err)
+	}
+	if err := saveInsights(report, outputFile); err != nil {
+		log.Fatalf("Failed to save bandit report: %v", err)
+	}
+	log.Printf("Bandit report saved: %s", outputFile)
+}
+
 func generateReport(inputFile, outputFile, modelFile string) {
 	log.Println("Generating API usage guide...")
 
@@ -130,16 +328,18 @@ func generateReport(inputFile, outputFile, modelFile string) {
 	}
 
 	// Load model if specified
-	var model interface{}
+	var trained *model.Model
 	if modelFile != "" {
-		model, err = loadModel(modelFile)
+		loaded, err := model.Load(modelFile)
 		if err != nil {
 			log.Printf("Warning: Failed to load model: %v", err)
+		} else {
+			trained = &loaded
 		}
 	}
 
 	// Generate report
-	report := generateAPIUsageGuide(insights, model)
+	report := generateAPIUsageGuide(insights, trained)
 
 	// Save report
 	if err := os.WriteFile(outputFile, []byte(report), 0644); err != nil {
@@ -239,6 +439,18 @@ func loadConfiguration(configFile string, maxEpisodes int, enableProfiling bool)
 	return config
 }
 
+// applySeed sets the run's seed from --seed, else keeps the configured one,
+// else picks one from the clock, and logs it so any run can be replayed.
+func applySeed(config *rl.SystemConfig, seed int64) {
+	if seed != 0 {
+		config.Seed = seed
+	}
+	if config.Seed == 0 {
+		config.Seed = time.Now().UnixNano()
+	}
+	log.Printf("Using seed %d", config.Seed)
+}
+
 func loadConfigFromFile(filename string, config *rl.SystemConfig) error {
 	data, err := os.ReadFile(filename)
 	if err != nil {
@@ -248,9 +460,32 @@ func loadConfigFromFile(filename string, config *rl.SystemConfig) error {
 	return json.Unmarshal(data, config)
 }
 
-func loadTrainingData() []rl.TrainingExample {
-	// Load comprehensive realistic training data
-	return rl.GetRealisticTrainingData()
+// loadTrainingData reads the corpus at path, or returns the built-in
+// realistic examples when no path is given. Invalid records are reported one
+// per line and skipped; a corpus with no valid records is fatal.
+func loadTrainingData(path string, columns string) []rl.TrainingExample {
+	if path == "" {
+		return rl.GetRealisticTrainingData()
+	}
+
+	mapping, err := dataset.ParseColumnMapping(columns)
+	if err != nil {
+		log.Fatalf("Invalid --data-columns: %v", err)
+	}
+	examples, err := dataset.Load(path, dataset.Options{Columns: mapping})
+	var loadErr *dataset.LoadError
+	if errors.As(err, &loadErr) {
+		for _, record := range loadErr.Records {
+			log.Printf("Skipping invalid record: %v", record)
+		}
+	} else if err != nil {
+		log.Fatalf("Failed to load training data: %v", err)
+	}
+	if len(examples) == 0 {
+		log.Fatalf("No valid training examples in %s", path)
+	}
+	log.Printf("Loaded %d training examples from %s", len(examples), path)
+	return examples
 }
 
 func saveInsights(insights analyzer.APIFeedbackReport, filename string) error {
@@ -262,27 +497,58 @@ func saveInsights(insights analyzer.APIFeedbackReport, filename string) error {
 	return os.WriteFile(filename, data, 0644)
 }
 
-func saveFinalModel(system *rl.EnhancedRLSystem, checkpointDir string) error {
+func saveFinalModel(system *rl.EnhancedRLSystem, checkpointDir string, encoding model.Encoding) error {
 	// Create checkpoint directory if it doesn't exist
 	if err := os.MkdirAll(checkpointDir, 0755); err != nil {
 		return err
 	}
 
-	// Save Q-table and configuration
-	modelData := map[string]interface{}{
-		"q_table":     system.Agent.QTable,
-		"config":      system.Config,
-		"timestamp":   time.Now(),
-		"version":     "1.0",
-	}
+	filename := fmt.Sprintf("%s/final_model_%d%s", checkpointDir, time.Now().Unix(), encoding.Extension())
+	return model.Save(filename, model.New(system), encoding)
+}
 
-	data, err := json.MarshalIndent(modelData, "", "  ")
+// convertModel rewrites a model file in another encoding. Without --output
+// the result goes next to the input, in the other format unless
+// --model-format names one.
+func convertModel(inputFile, outputFile string, encoding model.Encoding) {
+	if inputFile == "" {
+		log.Fatal("--mode=convert-model needs --input")
+	}
+	trained, err := model.Load(inputFile)
 	if err != nil {
-		return err
+		log.Fatalf("Failed to load model: %v", err)
 	}
 
-	filename := fmt.Sprintf("%s/final_model_%d.json", checkpointDir, time.Now().Unix())
-	return os.WriteFile(filename, data, 0644)
+	if outputFile == "" {
+		if encoding.Format == "" {
+			encoding.Format = model.FormatBinary
+			if model.EncodingFor(inputFile).Format == model.FormatBinary {
+				encoding.Format = model.FormatJSON
+			}
+		}
+		base := strings.TrimSuffix(strings.TrimSuffix(inputFile, ".gz"), filepath.Ext(strings.TrimSuffix(inputFile, ".gz")))
+		outputFile = base + encoding.Extension()
+	} else if encoding.Format == "" {
+		inferred := model.EncodingFor(outputFile)
+		encoding.Format = inferred.Format
+		encoding.Compress = encoding.Compress || inferred.Compress
+	}
+	if outputFile == inputFile {
+		log.Fatalf("Converting %s would overwrite it; pass a different --output", inputFile)
+	}
+
+	if err := model.Save(outputFile, trained, encoding); err != nil {
+		log.Fatalf("Failed to save model: %v", err)
+	}
+	log.Printf("Converted %s (%s) to %s (%s)", inputFile, fileSize(inputFile), outputFile, fileSize(outputFile))
+}
+
+func fileSize(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "unknown size"
+	}
+	return fmt.Sprintf("%d bytes", info.Size())
 }
 
 func loadInsights(filename string) (analyzer.APIFeedbackReport, error) {
@@ -297,18 +563,7 @@ func loadInsights(filename string) (analyzer.APIFeedbackReport, error) {
 	return insights, err
 }
 
-func loadModel(filename string) (interface{}, error) {
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		return nil, err
-	}
-
-	var model map[string]interface{}
-	err = json.Unmarshal(data, &model)
-	return model, err
-}
-
-func generateAPIUsageGuide(insights analyzer.APIFeedbackReport, model interface{}) string {
+func generateAPIUsageGuide(insights analyzer.APIFeedbackReport, trained *model.Model) string {
 	report := fmt.Sprintf(`# TextLib API Usage Guide
 
 Generated: %s
@@ -352,6 +607,41 @@ This report provides comprehensive insights into the optimal usage patterns for
 		report += fmt.Sprintf("Sequence: %s\n\n", fmt.Sprintf("%v", sequence))
 	}
 
+	// Add first-call recommendations from bandit runs
+	if len(insights.FirstCallRecommendations) > 0 {
+		report += "## Recommended First Call\n\n"
+		report += "| Context cluster | First call | Expected reward | Runner-up | Rounds |\n"
+		report += "|---|---|---|---|---|\n"
+		for _, recommendation := range insights.FirstCallRecommendations {
+			report += fmt.Sprintf("| %s | %s | %.2f | %s (-%.2f) | %d |\n",
+				strings.ReplaceAll(recommendation.Cluster, "|", "\\|"), recommendation.Function, recommendation.ExpectedReward,
+				recommendation.RunnerUp, recommendation.Margin, recommendation.Rounds)
+		}
+		report += "\n"
+	}
+
+	// Add held-out scores
+	if insights.TestEvaluation != nil || len(insights.ValidationHistory) > 0 {
+		report += "## Held-out Evaluation\n\n"
+		report += "| Split | Episode | Examples | Avg reward | Avg quality | Success rate |\n"
+		report += "|---|---|---|---|---|---|\n"
+		evaluations := insights.ValidationHistory
+		if insights.TestEvaluation != nil {
+			evaluations = append(evaluations, *insights.TestEvaluation)
+		}
+		for _, evaluation := range evaluations {
+			report += fmt.Sprintf("| %s | %d | %d | %.3f | %.3f | %.1f%% |\n",
+				evaluation.Split, evaluation.Episode, evaluation.Examples,
+				evaluation.AverageReward, evaluation.AverageQuality, evaluation.SuccessRate*100)
+		}
+		report += "\n"
+	}
+
+	// Add the trained model's learned policy
+	if trained != nil {
+		report += describeModel(*trained)
+	}
+
 	// Add recommendations
 	report += "## Recommendations\n\n"
 	for i, recommendation := range insights.Recommendations {
@@ -376,6 +666,47 @@ This report provides comprehensive insights into the optimal usage patterns for
 	return report
 }
 
+// describeModel summarizes a trained model and the call sequence its agent
+// picks for one built-in example of each task type.
+func describeModel(trained model.Model) string {
+	section := "## Trained Model\n\n"
+	section += fmt.Sprintf("- **Agent**: %s\n", trained.Agent.Kind)
+	if trained.Training.MigratedFrom != "" {
+		section += fmt.Sprintf("- **Schema**: %s (migrated from %s)\n", trained.Version, trained.Training.MigratedFrom)
+	} else {
+		section += fmt.Sprintf("- **Schema**: %s\n", trained.Version)
+	}
+	section += fmt.Sprintf("- **Episodes**: %d\n", trained.Training.Episodes)
+	section += fmt.Sprintf("- **Seed**: %d\n", trained.Training.Seed)
+	section += fmt.Sprintf("- **State Keys**: %s\n", trained.Agent.StateKeys)
+	section += fmt.Sprintf("- **Actions**: %d\n", len(trained.Agent.Actions))
+	if table, exists := trained.Agent.Tables["q"]; exists {
+		section += fmt.Sprintf("- **States Learned**: %d\n", len(table))
+	}
+	section += "\n"
+
+	system, err := trained.NewSystem()
+	if err != nil {
+		return section + fmt.Sprintf("The agent could not be rebuilt: %v\n\n", err)
+	}
+	section += "### Learned Call Sequences\n\n"
+	section += "| Task type | Example | Sequence |\n"
+	section += "|---|---|---|\n"
+	seen := make(map[string]bool)
+	for _, example := range rl.GetRealisticTrainingData() {
+		if seen[example.TaskType] {
+			continue
+		}
+		seen[example.TaskType] = true
+		var calls []string
+		for _, action := range system.GreedySequence(example) {
+			calls = append(calls, action.FunctionName)
+		}
+		section += fmt.Sprintf("| %s | %s | %s |\n", example.TaskType, example.ID, strings.Join(calls, " → "))
+	}
+	return section + "\n"
+}
+
 // Health check functions
 func checkMemory() error {
 	var m runtime.MemStats
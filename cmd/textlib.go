@@ -0,0 +1,7 @@
+//go:build textlib
+
+package main
+
+// The textlib executor calls the real library, so it is only linked into
+// builds with -tags textlib.
+import _ "textlib-rl-system/internal/textlibexec"
@@ -520,6 +520,10 @@ func (analyzer *InsightAnalyzer) calculateConvergenceRate(variances []float64) f
 	// Calculate how quickly variance decreases
 	firstVariance := variances[0]
 	lastVariance := variances[len(variances)-1]
+	if firstVariance == 0 {
+		// No initial spread to shrink; dividing would put NaN or Inf in the report
+		return 0
+	}
 	
 	return (firstVariance - lastVariance) / firstVariance
 }
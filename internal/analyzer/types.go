@@ -22,6 +22,38 @@ type APIFeedbackReport struct {
 	Recommendations    []string                      `json:"recommendations"`
 	FailureAnalysis    FailureAnalysis               `json:"failure_analysis"`
 	UsageInsights      UsageInsights                 `json:"usage_insights"`
+
+	FirstCallRecommendations []FirstCallRecommendation `json:"first_call_recommendations,omitempty"`
+
+	ValidationHistory []SplitEvaluation `json:"validation_history,omitempty"`
+	TestEvaluation    *SplitEvaluation  `json:"test_evaluation,omitempty"`
+}
+
+// SplitEvaluation is a greedy evaluation of the learned policy on a held-out
+// split: the validation split during training, the test split at the end.
+type SplitEvaluation struct {
+	Split          string  `json:"split"`
+	Episode        int     `json:"episode"`
+	Examples       int     `json:"examples"`
+	AverageReward  float64 `json:"average_reward"`
+	AverageQuality float64 `json:"average_quality"`
+	ScoredOutputs  int     `json:"scored_outputs"`
+	SuccessRate    float64 `json:"success_rate"`
+	AverageSteps   float64 `json:"average_steps"`
+}
+
+// FirstCallRecommendation is the call a contextual bandit learned to make
+// first for one cluster of similar texts.
+type FirstCallRecommendation struct {
+	Cluster        string  `json:"cluster"`
+	TaskType       string  `json:"task_type"`
+	Function       string  `json:"function"`
+	ExpectedReward float64 `json:"expected_reward"`
+	RunnerUp       string  `json:"runner_up,omitempty"`
+	Margin         float64 `json:"margin"`
+	Rounds         int     `json:"rounds"`
+	Pulls          int     `json:"pulls"`
+	ObservedReward float64 `json:"observed_reward"`
 }
 
 type FunctionStats struct {
@@ -36,6 +36,8 @@ type ActionMetrics struct {
 	ExpectedOutput  string  `json:"expected_output"`
 	QValue          float64 `json:"q_value"`
 	ExplorationFlag bool    `json:"exploration_flag"`
+
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
 }
 
 type ResultMetrics struct {
@@ -61,6 +63,10 @@ type LearningMetrics struct {
 	PolicyStability   float64 `json:"policy_stability"`
 	ActionDiversity   float64 `json:"action_diversity"`
 	LearningProgress  float64 `json:"learning_progress"`
+	Loss              float64 `json:"loss"`
+	TDError           float64 `json:"td_error"`
+	RealUpdates       int     `json:"real_updates"`
+	PlanningUpdates   int     `json:"planning_updates"`
 }
 
 type EpisodeMetrics struct {
@@ -71,6 +77,10 @@ type EpisodeMetrics struct {
 	Rewards     []float64       `json:"rewards"`
 	States      []StateMetrics  `json:"states"`
 	TotalReward float64         `json:"total_reward"`
+
+	ExampleID         string  `json:"example_id,omitempty"`
+	Difficulty        float64 `json:"difficulty"`
+	DifficultyCeiling float64 `json:"difficulty_ceiling"`
 }
 
 type InsightLogger struct {
@@ -0,0 +1,109 @@
+package rl
+
+import (
+	"fmt"
+)
+
+// ActionPreconditions declares when an action may be taken in a state. The
+// zero value imposes no constraint beyond the budget check every action gets.
+type ActionPreconditions struct {
+	// RequiresResultFrom masks the action until at least one of the named
+	// functions has produced a result.
+	RequiresResultFrom []string
+	// MaxUses caps how many times the action can be called per episode;
+	// zero means unlimited.
+	MaxUses int
+	// NotAfter masks the action once any of the named functions has been
+	// called.
+	NotAfter []string
+}
+
+// ActionSpace is the set of actions together with the preconditions that
+// mask them. Agents enumerate it through getAvailableActions, so greedy and
+// random selection as well as bootstrapped targets only see legal actions.
+type ActionSpace struct {
+	Actions       []Action
+	Preconditions map[string]ActionPreconditions // keyed by function name
+}
+
+// DefaultActionSpace returns the textlib actions with preconditions that rule
+// out calls that cannot help: repeating a deterministic analysis, validating
+// before anything exists, or reformatting output that was already validated.
+func DefaultActionSpace() *ActionSpace {
+	producers := []string{
+		"extract_entities", "analyze_readability", "detect_code",
+		"extract_keywords", "sentiment_analysis", "summarize_text", "format_text",
+	}
+	return &ActionSpace{
+		Actions: getDefaultActions(),
+		Preconditions: map[string]ActionPreconditions{
+			"extract_entities":    {MaxUses: 1},
+			"analyze_readability": {MaxUses: 1},
+			"detect_code":         {MaxUses: 1},
+			"extract_keywords":    {MaxUses: 1},
+			"sentiment_analysis":  {MaxUses: 1},
+			"summarize_text":      {MaxUses: 1},
+			"format_text":         {MaxUses: 1, NotAfter: []string{"validate_output"}},
+			"validate_output":     {MaxUses: 1, RequiresResultFrom: producers},
+		},
+	}
+}
+
+// Available returns the actions whose preconditions hold in state, in the
+// order they were declared.
+func (space *ActionSpace) Available(state State) []Action {
+	available := make([]Action, 0, len(space.Actions))
+	for _, action := range space.Actions {
+		if space.Allowed(state, action) == nil {
+			available = append(available, action)
+		}
+	}
+	return available
+}
+
+// Keys returns the table key of every action in the space, in the order they
+// were declared.
+func (space *ActionSpace) Keys() []string {
+	keys := make([]string, len(space.Actions))
+	for i, action := range space.Actions {
+		keys[i] = actionKey(action)
+	}
+	return keys
+}
+
+// Allowed reports why action is masked in state, or nil if it may be taken.
+func (space *ActionSpace) Allowed(state State, action Action) error {
+	if action.Cost > state.RemainingBudget {
+		return fmt.Errorf("%s costs %d, only %d budget left", action.FunctionName, action.Cost, state.RemainingBudget)
+	}
+
+	preconditions := space.Preconditions[action.FunctionName]
+	if len(preconditions.RequiresResultFrom) > 0 {
+		satisfied := false
+		for _, name := range preconditions.RequiresResultFrom {
+			if _, exists := state.CurrentResults[name]; exists {
+				satisfied = true
+				break
+			}
+		}
+		if !satisfied {
+			return fmt.Errorf("%s requires a result from one of %v", action.FunctionName, preconditions.RequiresResultFrom)
+		}
+	}
+
+	uses := 0
+	for _, used := range state.ActionsUsed {
+		if used == action.FunctionName {
+			uses++
+		}
+		for _, name := range preconditions.NotAfter {
+			if used == name {
+				return fmt.Errorf("%s is not allowed after %s", action.FunctionName, name)
+			}
+		}
+	}
+	if preconditions.MaxUses > 0 && uses >= preconditions.MaxUses {
+		return fmt.Errorf("%s already used %d of %d times", action.FunctionName, uses, preconditions.MaxUses)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package rl
+
+import (
+	"testing"
+)
+
+func availableNames(actions []Action) map[string]bool {
+	names := make(map[string]bool, len(actions))
+	for _, action := range actions {
+		names[action.FunctionName] = true
+	}
+	return names
+}
+
+func TestActionSpace_DefaultPreconditions(t *testing.T) {
+	space := DefaultActionSpace()
+	state := testState("Precondition text.")
+
+	initial := availableNames(space.Available(state))
+	if initial["validate_output"] || len(initial) != len(getDefaultActions())-1 {
+		t.Errorf("Expected everything but validate_output initially, got %v", initial)
+	}
+
+	state.ActionsUsed = []string{"detect_code"}
+	state.CurrentResults = map[string]interface{}{"detect_code": true}
+	available := availableNames(space.Available(state))
+	if available["detect_code"] || !available["validate_output"] {
+		t.Errorf("Expected detect_code used up and validate_output unlocked, got %v", available)
+	}
+
+	state.ActionsUsed = append(state.ActionsUsed, "validate_output")
+	if err := space.Allowed(state, Action{FunctionName: "format_text", Cost: 2}); err == nil {
+		t.Error("Expected format_text to be masked after validate_output")
+	}
+
+	state.RemainingBudget = 4
+	for _, action := range space.Available(state) {
+		if action.Cost > 4 {
+			t.Errorf("Expected %s (cost %d) masked with budget 4", action.FunctionName, action.Cost)
+		}
+	}
+}
+
+func TestActionSpace_CustomPreconditions(t *testing.T) {
+	space := &ActionSpace{
+		Actions: []Action{{FunctionName: "a", Cost: 1}, {FunctionName: "b", Cost: 1}},
+		Preconditions: map[string]ActionPreconditions{
+			"a": {MaxUses: 2},
+			"b": {RequiresResultFrom: []string{"a"}},
+		},
+	}
+	state := State{RemainingBudget: 10, ActionsUsed: []string{"a", "a"}, CurrentResults: map[string]interface{}{}}
+	if got := space.Available(state); len(got) != 0 {
+		t.Errorf("Expected a exhausted and b locked without a result, got %v", got)
+	}
+	state.CurrentResults["a"] = "ok"
+	if got := space.Available(state); len(got) != 1 || got[0].FunctionName != "b" {
+		t.Errorf("Expected only b available, got %v", got)
+	}
+}
+
+func TestQLearningAgent_MasksUnavailableActions(t *testing.T) {
+	agent := NewQLearningAgent(0.1, 0.9, 0.0, 0.0, 1.0)
+	state := testState("Masked selection text.")
+	state.ActionsUsed = []string{"summarize_text"}
+	state.CurrentResults = map[string]interface{}{"summarize_text": "summary"}
+
+	// The masked action has by far the highest value
+	masked := Action{FunctionName: "summarize_text", Category: "generation", Cost: 8}
+	legal := Action{FunctionName: "extract_keywords", Category: "analysis", Cost: 4}
+	agent.QTable[agent.getStateKey(state)] = map[string]float64{
+		agent.getActionKey(masked): 100.0,
+		agent.getActionKey(legal):  1.0,
+	}
+
+	if got := agent.selectBestAction(state); got.FunctionName != legal.FunctionName {
+		t.Errorf("Expected greedy choice %s, got %s", legal.FunctionName, got.FunctionName)
+	}
+	if got := agent.getMaxQValue(state); got != 1.0 {
+		t.Errorf("Expected max Q over available actions 1.0, got %f", got)
+	}
+
+	agent.Exploration = &EpsilonGreedy{decaySchedule: decaySchedule{Current: 1.0, Initial: 1.0, Min: 1.0, DecayRate: 1.0}}
+	for i := 0; i < 200; i++ {
+		if action, _ := agent.SelectActionWithMetrics(state); action.FunctionName == masked.FunctionName {
+			t.Fatal("Random selection returned a masked action")
+		}
+	}
+}
+
+func TestEnhancedRLSystem_CompletesWhenNothingIsAvailable(t *testing.T) {
+	system := NewEnhancedRLSystem(SystemConfig{})
+	state := testState("Completion text.")
+	state.RemainingBudget = 1
+	if !system.isTaskComplete(state) {
+		t.Error("Expected completion when only a locked action fits the budget")
+	}
+}
+
+func TestMaxMasked(t *testing.T) {
+	if got := maxMasked([]float64{5, -2, 3}, []bool{false, true, true}); got != 3 {
+		t.Errorf("Expected 3, got %f", got)
+	}
+	if got := maxMasked([]float64{5, -2}, []bool{false, false}); got != 0 {
+		t.Errorf("Expected 0 with nothing available, got %f", got)
+	}
+}
@@ -4,56 +4,94 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
-	"math"
-	"math/rand"
+	"strings"
 	"textlib-rl-system/internal/logging"
 )
 
+// StateKeysTextHash is the state-key scheme of version 1.0 models: a hash of
+// the task type, the first 100 characters of text, the step count and the
+// remaining budget. Agents restored from those models keep using it so their
+// Q-tables still match.
+const StateKeysTextHash = "text_hash"
+
+// NewQLearningAgent creates an agent with per-step exponentially decaying
+// epsilon-greedy exploration.
 func NewQLearningAgent(learningRate, discountFactor, explorationRate, minExploration, decayRate float64) *QLearningAgent {
 	return &QLearningAgent{
-		QTable:          make(map[string]map[string]float64),
-		LearningRate:    learningRate,
-		DiscountFactor:  discountFactor,
-		ExplorationRate: explorationRate,
-		MinExploration:  minExploration,
-		DecayRate:       decayRate,
+		QTable:         make(map[string]map[string]float64),
+		LearningRate:   learningRate,
+		DiscountFactor: discountFactor,
+		Exploration: &EpsilonGreedy{decaySchedule: decaySchedule{
+			Current:   explorationRate,
+			Initial:   explorationRate,
+			Min:       minExploration,
+			DecayRate: decayRate,
+			Schedule:  ScheduleExponential,
+			DecayPer:  DecayPerStep,
+		}},
+		Featurizer:  NewTextFeaturizer(),
+		ActionSpace: DefaultActionSpace(),
 	}
 }
 
+// actionValueFunc scores an action in a state; agents built on QLearningAgent
+// pass their own estimate so selection stays greedy with respect to it.
+type actionValueFunc func(state State, action Action) float64
+
 func (agent *QLearningAgent) SelectActionWithMetrics(state State) (Action, logging.ActionMetrics) {
-	
-	var selectedAction Action
-	var qValue float64
-	var isExploration bool
-	
-	if rand.Float64() < agent.ExplorationRate {
-		selectedAction = agent.selectRandomAction(state)
-		qValue = agent.GetQValue(state, selectedAction)
-		isExploration = true
-	} else {
-		selectedAction = agent.selectBestAction(state)
-		qValue = agent.GetQValue(state, selectedAction)
-		isExploration = false
+	selectedAction, isExploration := agent.chooseAction(state)
+	return selectedAction, agent.actionMetrics(state, selectedAction, isExploration, agent.GetQValue(state, selectedAction))
+}
+
+func (agent *QLearningAgent) chooseAction(state State) (Action, bool) {
+	return agent.chooseActionBy(state, agent.GetQValue)
+}
+
+// chooseActionBy lets the exploration strategy pick among the available
+// actions scored by value and reports whether the choice was exploratory.
+func (agent *QLearningAgent) chooseActionBy(state State, value actionValueFunc) (Action, bool) {
+	availableActions := agent.getAvailableActions(state)
+	if len(availableActions) == 0 {
+		return Action{FunctionName: "no_op", Category: "utility", Cost: 0}, false
 	}
 	
-	metrics := logging.ActionMetrics{
-		FunctionName:    selectedAction.FunctionName,
-		Category:        selectedAction.Category,
-		ComputeCost:     selectedAction.Cost,
+	actionKeys, values := agent.scoreActions(state, availableActions, value)
+	idx, isExploration := agent.Exploration.Choose(agent.getStateKey(state), actionKeys, values)
+	return availableActions[idx], isExploration
+}
+
+func (agent *QLearningAgent) scoreActions(state State, actions []Action, value actionValueFunc) ([]string, []float64) {
+	actionKeys := make([]string, len(actions))
+	values := make([]float64, len(actions))
+	for i, action := range actions {
+		actionKeys[i] = agent.getActionKey(action)
+		values[i] = value(state, action)
+	}
+	return actionKeys, values
+}
+
+func (agent *QLearningAgent) actionMetrics(state State, action Action, isExploration bool, qValue float64) logging.ActionMetrics {
+	return logging.ActionMetrics{
+		FunctionName:    action.FunctionName,
+		Category:        action.Category,
+		ComputeCost:     action.Cost,
 		InputSize:       len(state.Text),
 		ExpectedOutput:  "simulated_output",
 		QValue:          qValue,
 		ExplorationFlag: isExploration,
+		Parameters:      action.Parameters,
 	}
-	
-	return selectedAction, metrics
 }
 
 func (agent *QLearningAgent) GetQValue(state State, action Action) float64 {
+	return agent.tableValue(agent.QTable, state, action)
+}
+
+func (agent *QLearningAgent) tableValue(table map[string]map[string]float64, state State, action Action) float64 {
 	stateKey := agent.getStateKey(state)
 	actionKey := agent.getActionKey(action)
 	
-	if stateActions, exists := agent.QTable[stateKey]; exists {
+	if stateActions, exists := table[stateKey]; exists {
 		if qValue, exists := stateActions[actionKey]; exists {
 			return qValue
 		}
@@ -63,23 +101,147 @@ func (agent *QLearningAgent) GetQValue(state State, action Action) float64 {
 }
 
 func (agent *QLearningAgent) UpdateQValue(state State, action Action, reward float64, nextState State) {
-	stateKey := agent.getStateKey(state)
-	actionKey := agent.getActionKey(action)
+	agent.Update(state, action, reward, nextState, false)
+}
+
+// Update applies a one-step Q-learning backup. Terminal transitions do not
+// bootstrap from the next state.
+func (agent *QLearningAgent) Update(state State, action Action, reward float64, nextState State, done bool) logging.LearningMetrics {
+	maxNextQ := 0.0
+	if !done {
+		maxNextQ = agent.getMaxQValue(nextState)
+	}
+	
+	agent.moveQValue(state, action, reward+agent.DiscountFactor*maxNextQ)
+	agent.Exploration.EndStep()
 	
-	if agent.QTable[stateKey] == nil {
-		agent.QTable[stateKey] = make(map[string]float64)
+	return agent.learningMetrics()
+}
+
+func (agent *QLearningAgent) learningMetrics() logging.LearningMetrics {
+	return logging.LearningMetrics{
+		ExplorationRate: agent.Exploration.Rate(),
+	}
+}
+
+// moveQValue moves Q(state, action) towards target by the learning rate.
+func (agent *QLearningAgent) moveQValue(state State, action Action, target float64) {
+	agent.moveTableValue(agent.QTable, state, action, target)
+}
+
+func (agent *QLearningAgent) moveTableValue(table map[string]map[string]float64, state State, action Action, target float64) {
+	agent.moveKeyValue(table, agent.getStateKey(state), agent.getActionKey(action), target)
+}
+
+func (agent *QLearningAgent) moveKeyValue(table map[string]map[string]float64, stateKey, actionKey string, target float64) {
+	if table[stateKey] == nil {
+		table[stateKey] = make(map[string]float64)
+	}
+	
+	currentQ := table[stateKey][actionKey]
+	table[stateKey][actionKey] = currentQ + agent.LearningRate*(target-currentQ)
+}
+
+func (agent *QLearningAgent) EndEpisode(episode logging.EpisodeMetrics) {
+	agent.Exploration.EndEpisode()
+}
+
+func (agent *QLearningAgent) Snapshot() AgentSnapshot {
+	return agent.snapshotAs(AgentKindQLearning)
+}
+
+func (agent *QLearningAgent) Restore(snapshot AgentSnapshot) error {
+	return agent.restoreAs(AgentKindQLearning, snapshot)
+}
+
+// snapshotAs captures the shared tabular state under the given agent kind so
+// variants built on QLearningAgent serialize the same way.
+func (agent *QLearningAgent) snapshotAs(kind string) AgentSnapshot {
+	snapshot := snapshotLearner(kind, agent.LearningRate, agent.DiscountFactor, agent.Exploration,
+		map[string]map[string]map[string]float64{
+			"q": copyQTable(agent.QTable),
+		})
+	snapshot.Options["state_featurizer"] = agent.featurizer().Name()
+	if agent.StateKeys != "" {
+		snapshot.Options["state_keys"] = agent.StateKeys
+	}
+	return snapshot
+}
+
+// restoreAs rejects snapshots keyed by a different featurizer, since their
+// state keys would never match the states this agent sees. Snapshots with a
+// state_keys option switch the agent to that key scheme instead.
+func (agent *QLearningAgent) restoreAs(kind string, snapshot AgentSnapshot) error {
+	switch scheme := snapshot.Options["state_keys"]; scheme {
+	case "", StateKeysTextHash:
+		agent.StateKeys = scheme
+	default:
+		return fmt.Errorf("unknown state-key scheme %q", scheme)
+	}
+	if name, exists := snapshot.Options["state_featurizer"]; exists && agent.StateKeys == "" && name != agent.featurizer().Name() {
+		return fmt.Errorf("snapshot state keys come from featurizer %s, agent uses %s", name, agent.featurizer().Name())
+	}
+	exploration, err := restoreLearner(kind, snapshot, &agent.LearningRate, &agent.DiscountFactor, agent.Exploration)
+	if err != nil {
+		return err
 	}
+	agent.Exploration = exploration
+	agent.QTable = copyQTable(snapshot.Tables["q"])
+	return nil
+}
+
+// snapshotLearner builds the parts of a snapshot every value-based agent
+// shares: kind, learning rate, discount and exploration state.
+func snapshotLearner(kind string, learningRate, discountFactor float64, exploration ExplorationStrategy,
+	tables map[string]map[string]map[string]float64) AgentSnapshot {
 	
-	currentQ := agent.GetQValue(state, action)
-	maxNextQ := agent.getMaxQValue(nextState)
+	snapshot := AgentSnapshot{
+		Kind: kind,
+		Hyperparameters: map[string]float64{
+			"learning_rate":   learningRate,
+			"discount_factor": discountFactor,
+		},
+		Options: map[string]string{
+			"exploration": exploration.Name(),
+		},
+		Tables: tables,
+	}
+	exploration.SaveState(&snapshot)
+	return snapshot
+}
+
+// restoreLearner is the inverse of snapshotLearner. It returns the exploration
+// strategy to use, replacing current when the snapshot used a different one.
+func restoreLearner(kind string, snapshot AgentSnapshot, learningRate, discountFactor *float64,
+	current ExplorationStrategy) (ExplorationStrategy, error) {
 	
-	newQ := currentQ + agent.LearningRate*(reward+agent.DiscountFactor*maxNextQ-currentQ)
-	agent.QTable[stateKey][actionKey] = newQ
+	if snapshot.Kind != kind {
+		return nil, fmt.Errorf("cannot restore %s snapshot into %s agent", snapshot.Kind, kind)
+	}
+	if value, exists := snapshot.Hyperparameters["learning_rate"]; exists {
+		*learningRate = value
+	}
+	if value, exists := snapshot.Hyperparameters["discount_factor"]; exists {
+		*discountFactor = value
+	}
 	
-	agent.ExplorationRate = math.Max(agent.MinExploration, agent.ExplorationRate*agent.DecayRate)
+	exploration := current
+	if name, exists := snapshot.Options["exploration"]; exists && name != current.Name() {
+		strategy, err := NewExplorationStrategy(SystemConfig{Exploration: ExplorationConfig{Strategy: name}})
+		if err != nil {
+			return nil, err
+		}
+		exploration = strategy
+	}
+	exploration.LoadState(snapshot)
+	return exploration, nil
 }
 
 func (agent *QLearningAgent) selectBestAction(state State) Action {
+	return agent.selectBestActionBy(state, agent.GetQValue)
+}
+
+func (agent *QLearningAgent) selectBestActionBy(state State, value actionValueFunc) Action {
 	availableActions := agent.getAvailableActions(state)
 	
 	if len(availableActions) == 0 {
@@ -87,10 +249,10 @@ func (agent *QLearningAgent) selectBestAction(state State) Action {
 	}
 	
 	bestAction := availableActions[0]
-	bestQValue := agent.GetQValue(state, bestAction)
+	bestQValue := value(state, bestAction)
 	
 	for _, action := range availableActions[1:] {
-		qValue := agent.GetQValue(state, action)
+		qValue := value(state, action)
 		if qValue > bestQValue {
 			bestQValue = qValue
 			bestAction = action
@@ -100,15 +262,6 @@ func (agent *QLearningAgent) selectBestAction(state State) Action {
 	return bestAction
 }
 
-func (agent *QLearningAgent) selectRandomAction(state State) Action {
-	availableActions := agent.getAvailableActions(state)
-	if len(availableActions) == 0 {
-		return Action{FunctionName: "no_op", Category: "utility", Cost: 0}
-	}
-	
-	return availableActions[rand.Intn(len(availableActions))]
-}
-
 func (agent *QLearningAgent) getMaxQValue(state State) float64 {
 	availableActions := agent.getAvailableActions(state)
 	if len(availableActions) == 0 {
@@ -126,33 +279,113 @@ func (agent *QLearningAgent) getMaxQValue(state State) float64 {
 	return maxQ
 }
 
+// policyProbabilities returns the exploration strategy's action distribution
+// for state, aligned with getAvailableActions.
+func (agent *QLearningAgent) policyProbabilities(state State) ([]Action, []float64) {
+	availableActions := agent.getAvailableActions(state)
+	actionKeys, values := agent.scoreActions(state, availableActions, agent.GetQValue)
+	return availableActions, agent.Exploration.Probabilities(agent.getStateKey(state), actionKeys, values)
+}
+
+// getAvailableActions returns the actions whose preconditions hold in state.
 func (agent *QLearningAgent) getAvailableActions(state State) []Action {
-	return []Action{
-		{FunctionName: "extract_entities", Category: "analysis", Cost: 5},
-		{FunctionName: "analyze_readability", Category: "analysis", Cost: 3},
-		{FunctionName: "detect_code", Category: "analysis", Cost: 2},
-		{FunctionName: "extract_keywords", Category: "analysis", Cost: 4},
-		{FunctionName: "sentiment_analysis", Category: "analysis", Cost: 3},
-		{FunctionName: "summarize_text", Category: "generation", Cost: 8},
-		{FunctionName: "format_text", Category: "formatting", Cost: 2},
-		{FunctionName: "validate_output", Category: "utility", Cost: 1},
-	}
+	return agent.actionSpace().Available(state)
 }
 
+// getStateKey discretizes the featurizer's output into a readable table key,
+// e.g. "technical_analysis|len=medium|code|ent=low|used=detect_code|last=detect_code|res=detect_code|budget=4".
+// Continuous features are bucketed so similar documents share entries.
 func (agent *QLearningAgent) getStateKey(state State) string {
-	data := fmt.Sprintf("%s|%s|%d|%d", state.TaskType, state.Text[:min(100, len(state.Text))], 
-		state.StepCount, state.RemainingBudget)
+	if agent.StateKeys == StateKeysTextHash {
+		return textHashStateKey(state)
+	}
+	features := agent.featurizer().Featurize(state)
+
+	parts := []string{features.TaskType, "len=" + features.LengthBucket}
+	if features.CodePresence {
+		parts = append(parts, "code")
+	}
+	if features.MathPresence {
+		parts = append(parts, "math")
+	}
+	parts = append(parts,
+		"ent="+entityDensityBucket(features.EntityDensity),
+		"used="+strings.Join(features.ActionsUsed, ","),
+		"last="+features.LastAction,
+		"res="+strings.Join(features.ResultsPresent, ","),
+		fmt.Sprintf("budget=%d", budgetBucket(features.RemainingBudget)),
+	)
+	return strings.Join(parts, "|")
+}
+
+func textHashStateKey(state State) string {
+	text := state.Text
+	if len(text) > 100 {
+		text = text[:100]
+	}
+	data := fmt.Sprintf("%s|%s|%d|%d", state.TaskType, text, state.StepCount, state.RemainingBudget)
 	hash := sha256.Sum256([]byte(data))
 	return hex.EncodeToString(hash[:])[:16]
 }
 
+func (agent *QLearningAgent) featurizer() StateFeaturizer {
+	if agent.Featurizer == nil {
+		agent.Featurizer = NewTextFeaturizer()
+	}
+	return agent.Featurizer
+}
+
+func (agent *QLearningAgent) actionSpace() *ActionSpace {
+	if agent.ActionSpace == nil {
+		agent.ActionSpace = DefaultActionSpace()
+	}
+	return agent.ActionSpace
+}
+
+func entityDensityBucket(density float64) string {
+	switch {
+	case density == 0:
+		return "none"
+	case density < 0.05:
+		return "low"
+	case density < 0.15:
+		return "medium"
+	default:
+		return "high"
+	}
+}
+
+// budgetBucket groups the remaining budget in steps of ten, which is about
+// two calls of the most common action cost.
+func budgetBucket(budget int) int {
+	if budget <= 0 {
+		return 0
+	}
+	return (budget + 9) / 10
+}
+
+// getActionKey names an action in tables and logs. Parameterized variants
+// append their discretized values, e.g.
+// "extract_entities_analysis[confidence_threshold=0.5;max_entities=510]".
 func (agent *QLearningAgent) getActionKey(action Action) string {
-	return fmt.Sprintf("%s_%s", action.FunctionName, action.Category)
+	return actionKey(action)
+}
+
+func actionKey(action Action) string {
+	key := fmt.Sprintf("%s_%s", action.FunctionName, action.Category)
+	if len(action.Parameters) > 0 {
+		key += "[" + parameterKey(action.Parameters) + "]"
+	}
+	return key
 }
 
-func min(a, b int) int {
-	if a < b {
-		return a
+func copyQTable(table map[string]map[string]float64) map[string]map[string]float64 {
+	copied := make(map[string]map[string]float64, len(table))
+	for stateKey, actions := range table {
+		copied[stateKey] = make(map[string]float64, len(actions))
+		for actionKey, value := range actions {
+			copied[stateKey][actionKey] = value
+		}
 	}
-	return b
-}
\ No newline at end of file
+	return copied
+}
@@ -0,0 +1,226 @@
+package rl
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"textlib-rl-system/internal/rl/replay"
+)
+
+const (
+	AgentKindQLearning = "qlearning"
+)
+
+// AgentFactory builds an agent from the system configuration.
+type AgentFactory func(config SystemConfig) Agent
+
+var (
+	agentFactoriesMu sync.RWMutex
+	agentFactories   = map[string]AgentFactory{
+		AgentKindQLearning: func(config SystemConfig) Agent {
+			return newQLearningAgentFromConfig(config)
+		},
+	}
+)
+
+// RegisterAgent makes an agent kind available to NewAgent and the --agent flag.
+// Registering an existing kind replaces its factory.
+func RegisterAgent(kind string, factory AgentFactory) {
+	agentFactoriesMu.Lock()
+	defer agentFactoriesMu.Unlock()
+
+	agentFactories[kind] = factory
+}
+
+// NewAgent builds the agent named by config.AgentType, defaulting to Q-learning.
+func NewAgent(config SystemConfig) (Agent, error) {
+	kind := config.AgentType
+	if kind == "" {
+		kind = AgentKindQLearning
+	}
+
+	agentFactoriesMu.RLock()
+	factory, exists := agentFactories[kind]
+	agentFactoriesMu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("unknown agent type %q (available: %v)", kind, AgentKinds())
+	}
+	if _, err := NewExplorationStrategy(config); err != nil {
+		return nil, err
+	}
+	if err := validateLearningRateSchedule(config.LearningRateSchedule); err != nil {
+		return nil, err
+	}
+	if err := replay.Validate(dqnConfigFrom(config).replayConfig()); err != nil {
+		return nil, err
+	}
+	if err := validateAgentConfig(withAgentDefaults(config)); err != nil {
+		return nil, err
+	}
+
+	return factory(withAgentDefaults(config)), nil
+}
+
+// AgentKinds lists the registered agent kinds in sorted order.
+func AgentKinds() []string {
+	agentFactoriesMu.RLock()
+	defer agentFactoriesMu.RUnlock()
+
+	kinds := make([]string, 0, len(agentFactories))
+	for kind := range agentFactories {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+func newQLearningAgentFromConfig(config SystemConfig) *QLearningAgent {
+	config = withAgentDefaults(config)
+	agent := NewQLearningAgent(config.LearningRate, *config.DiscountFactor,
+		*config.ExplorationRate, *config.MinExploration, config.DecayRate)
+	agent.Exploration = newExplorationFromConfig(config)
+	agent.ActionSpace = NewActionSpace(config)
+	return agent
+}
+
+// newExplorationFromConfig builds the configured exploration strategy. NewAgent
+// validates the configuration first, so the epsilon-greedy fallback only
+// applies to callers that bypass it.
+func newExplorationFromConfig(config SystemConfig) ExplorationStrategy {
+	if strategy, err := NewExplorationStrategy(config); err == nil {
+		return strategy
+	}
+	config = withAgentDefaults(config)
+	config.Exploration = ExplorationConfig{}
+	strategy, _ := NewExplorationStrategy(config)
+	return strategy
+}
+
+// withAgentDefaults fills unset learning hyperparameters with the values the
+// system has always trained with. Optional fields are only filled when nil,
+// so explicit zeros survive.
+func withAgentDefaults(config SystemConfig) SystemConfig {
+	if config.LearningRate == 0 {
+		config.LearningRate = 0.1
+	}
+	if config.DiscountFactor == nil {
+		config.DiscountFactor = Float64(0.95)
+	}
+	if config.ExplorationRate == nil {
+		config.ExplorationRate = Float64(1.0)
+	}
+	if config.MinExploration == nil {
+		config.MinExploration = Float64(0.01)
+	}
+	if config.DecayRate == 0 {
+		config.DecayRate = 0.995
+	}
+	if config.NSteps == 0 {
+		config.NSteps = 3
+	}
+	if config.TraceLambda == nil {
+		config.TraceLambda = Float64(0.8)
+	}
+	if config.PolicyLearningRate == 0 {
+		config.PolicyLearningRate = 0.05
+	}
+	if config.CriticLearningRate == 0 {
+		config.CriticLearningRate = 0.1
+	}
+	if config.EntropyCoefficient == nil {
+		config.EntropyCoefficient = Float64(0.01)
+	}
+	if config.PlanningSteps == nil {
+		config.PlanningSteps = Int(10)
+	}
+	if config.L2Regularization == nil {
+		config.L2Regularization = Float64(1e-4)
+	}
+	if config.LearningRateSchedule == "" {
+		config.LearningRateSchedule = LearningRateConstant
+	}
+	if len(config.HiddenSizes) == 0 {
+		config.HiddenSizes = []int{32, 32}
+	}
+	if config.NetworkLearningRate == 0 {
+		config.NetworkLearningRate = 0.01
+	}
+	if config.ReplayCapacity == 0 {
+		config.ReplayCapacity = 10000
+	}
+	if config.BatchSize == 0 {
+		config.BatchSize = 32
+	}
+	if config.TargetSyncInterval == 0 {
+		config.TargetSyncInterval = 200
+	}
+	if config.LearningRateDecay == 0 {
+		switch config.LearningRateSchedule {
+		case LearningRateInverseTime:
+			config.LearningRateDecay = 1e-3
+		case LearningRateExponential:
+			config.LearningRateDecay = 0.9999
+		}
+	}
+
+	exploration := &config.Exploration
+	if exploration.Strategy == "" {
+		exploration.Strategy = ExplorationEpsilonGreedy
+	}
+	if exploration.Schedule == "" {
+		exploration.Schedule = ScheduleExponential
+	}
+	if exploration.DecayPer == "" {
+		exploration.DecayPer = DecayPerStep
+	}
+	if exploration.DecaySteps == 0 {
+		exploration.DecaySteps = 1000
+	}
+	if exploration.Temperature == 0 {
+		exploration.Temperature = 1.0
+	}
+	if exploration.MinTemperature == 0 {
+		exploration.MinTemperature = 0.05
+	}
+	if exploration.TemperatureDecay == 0 {
+		exploration.TemperatureDecay = 0.999
+	}
+	if exploration.UCBConstant == 0 {
+		exploration.UCBConstant = 1.0
+	}
+	return config
+}
+
+// validateAgentConfig rejects hyperparameters outside their meaningful range.
+// It expects a config that has been through withAgentDefaults.
+func validateAgentConfig(config SystemConfig) error {
+	checks := []struct {
+		name     string
+		value    float64
+		min, max float64
+	}{
+		{"learning rate", config.LearningRate, 0, 1},
+		{"discount factor", *config.DiscountFactor, 0, 1},
+		{"exploration rate", *config.ExplorationRate, 0, 1},
+		{"minimum exploration", *config.MinExploration, 0, 1},
+		{"exploration decay rate", config.DecayRate, 0, 1},
+		{"trace lambda", *config.TraceLambda, 0, 1},
+		{"L2 regularization", *config.L2Regularization, 0, math.Inf(1)},
+		{"policy learning rate", config.PolicyLearningRate, 0, math.Inf(1)},
+		{"critic learning rate", config.CriticLearningRate, 0, math.Inf(1)},
+		{"entropy coefficient", *config.EntropyCoefficient, 0, math.Inf(1)},
+		{"planning steps", float64(*config.PlanningSteps), 0, math.Inf(1)},
+	}
+	for _, check := range checks {
+		if check.value < check.min || check.value > check.max || math.IsNaN(check.value) {
+			return fmt.Errorf("%s must be in [%g, %g], got %g", check.name, check.min, check.max, check.value)
+		}
+	}
+	if config.NSteps < 1 {
+		return fmt.Errorf("n-step agents need at least 1 step, got %d", config.NSteps)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package rl
+
+import (
+	"testing"
+	"time"
+
+	"textlib-rl-system/internal/logging"
+)
+
+func testState(text string) State {
+	return State{
+		Text:            text,
+		TaskType:        "technical_analysis",
+		ActionsUsed:     []string{},
+		CurrentResults:  make(map[string]interface{}),
+		StepCount:       0,
+		RemainingBudget: 50,
+	}
+}
+
+// trainingFixture builds the system tests train: config's agent on an
+// instant simulator, logging to a temporary directory and loaded with the
+// built-in training examples.
+func trainingFixture(t *testing.T, config SystemConfig) *EnhancedRLSystem {
+	t.Helper()
+	agent, err := NewAgent(config)
+	if err != nil {
+		t.Fatalf("NewAgent(%s) returned error: %v", config.AgentType, err)
+	}
+	system := NewEnhancedRLSystem(config)
+	system.SetAgent(agent)
+	system.SetExecutor(NewInstantSimulator(config))
+	system.SetLogger(logging.NewInsightLogger(t.TempDir(), 10, time.Second))
+	system.LoadTrainingData(GetRealisticTrainingData())
+	return system
+}
+
+func TestNewAgent_DefaultsToQLearning(t *testing.T) {
+	agent, err := NewAgent(SystemConfig{})
+	if err != nil {
+		t.Fatalf("NewAgent returned error: %v", err)
+	}
+
+	qAgent, ok := agent.(*QLearningAgent)
+	if !ok {
+		t.Fatalf("Expected *QLearningAgent, got %T", agent)
+	}
+
+	if qAgent.LearningRate != 0.1 || qAgent.DiscountFactor != 0.95 {
+		t.Errorf("Expected default hyperparameters, got lr=%f gamma=%f", qAgent.LearningRate, qAgent.DiscountFactor)
+	}
+}
+
+func TestNewAgent_UnknownKind(t *testing.T) {
+	if _, err := NewAgent(SystemConfig{AgentType: "does_not_exist"}); err == nil {
+		t.Error("Expected error for unknown agent type")
+	}
+}
+
+func TestNewAgent_HonorsZeroSettings(t *testing.T) {
+	zero := Float64(0)
+	config := SystemConfig{DiscountFactor: zero, ExplorationRate: zero, MinExploration: zero, TraceLambda: zero,
+		EntropyCoefficient: zero, L2Regularization: zero, PlanningSteps: Int(0), PriorityAlpha: zero}
+	build := func(kind string) Agent {
+		config.AgentType = kind
+		agent, err := NewAgent(config)
+		if err != nil {
+			t.Fatalf("NewAgent(%s) returned error: %v", kind, err)
+		}
+		return agent
+	}
+
+	if q := build(AgentKindQLearning).(*QLearningAgent); q.Exploration.Rate() != 0 || q.DiscountFactor != 0 {
+		t.Errorf("Expected a greedy, myopic agent, got exploration rate %f and discount %f", q.Exploration.Rate(), q.DiscountFactor)
+	}
+	if lambda := build(AgentKindQLambda).(*TraceAgent).Lambda; lambda != 0 {
+		t.Errorf("Expected lambda 0, got %f", lambda)
+	}
+	if entropy := build(AgentKindREINFORCE).(*PolicyGradientAgent).Config.EntropyCoefficient; entropy != 0 {
+		t.Errorf("Expected no entropy bonus, got %f", entropy)
+	}
+	if l2 := build(AgentKindLinearQ).(*LinearQAgent).L2; l2 != 0 {
+		t.Errorf("Expected no weight decay, got %f", l2)
+	}
+	if steps := build(AgentKindDynaQ).(*DynaQAgent).PlanningSteps; steps != 0 {
+		t.Errorf("Expected no planning steps, got %d", steps)
+	}
+	if alpha := build(AgentKindDQN).(*DQNAgent).Config.replayConfig().Alpha; alpha != 0 {
+		t.Errorf("Expected priority alpha 0, got %f", alpha)
+	}
+}
+
+func TestNewAgent_RejectsOutOfRangeSettings(t *testing.T) {
+	for name, config := range map[string]SystemConfig{
+		"exploration above 1":   {ExplorationRate: Float64(1.5)},
+		"negative exploration":  {MinExploration: Float64(-0.1)},
+		"discount above 1":      {DiscountFactor: Float64(1.2)},
+		"learning rate above 1": {LearningRate: 2},
+		"lambda above 1":        {AgentType: AgentKindSARSALambda, TraceLambda: Float64(1.1)},
+		"negative entropy":      {AgentType: AgentKindActorCritic, EntropyCoefficient: Float64(-1)},
+		"negative planning":     {AgentType: AgentKindDynaQ, PlanningSteps: Int(-3)},
+		"negative L2":           {AgentType: AgentKindLinearQ, L2Regularization: Float64(-1e-3)},
+		"negative alpha":        {AgentType: AgentKindDQN, PriorityAlpha: Float64(-1)},
+	} {
+		if _, err := NewAgent(config); err == nil {
+			t.Errorf("Expected %s to be rejected", name)
+		}
+	}
+}
+
+type stubAgent struct {
+	*QLearningAgent
+	episodes int
+}
+
+func (s *stubAgent) EndEpisode(episode logging.EpisodeMetrics) {
+	s.episodes++
+}
+
+func TestRegisterAgent(t *testing.T) {
+	RegisterAgent("stub_test", func(config SystemConfig) Agent {
+		return &stubAgent{QLearningAgent: newQLearningAgentFromConfig(config)}
+	})
+	defer func() {
+		agentFactoriesMu.Lock()
+		delete(agentFactories, "stub_test")
+		agentFactoriesMu.Unlock()
+	}()
+
+	agent, err := NewAgent(SystemConfig{AgentType: "stub_test"})
+	if err != nil {
+		t.Fatalf("NewAgent returned error: %v", err)
+	}
+	if _, ok := agent.(*stubAgent); !ok {
+		t.Errorf("Expected *stubAgent, got %T", agent)
+	}
+
+	found := false
+	for _, kind := range AgentKinds() {
+		if kind == "stub_test" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Registered kind missing from AgentKinds")
+	}
+}
+
+func TestQLearningAgent_UpdateTerminal(t *testing.T) {
+	agent := NewQLearningAgent(0.5, 0.9, 0.0, 0.0, 1.0)
+	state := testState("Terminal transition test.")
+	action := getDefaultActions()[0]
+
+	// Seed a large value in the next state so bootstrapping would be visible
+	nextState := state
+	nextState.StepCount = 1
+	nextState.ActionsUsed = []string{"extract_entities"}
+	agent.QTable[agent.getStateKey(nextState)] = map[string]float64{agent.getActionKey(action): 10.0}
+
+	agent.Update(state, action, 1.0, nextState, true)
+
+	if got := agent.GetQValue(state, action); got != 0.5 {
+		t.Errorf("Expected terminal update to ignore next state, got Q=%f", got)
+	}
+}
+
+func TestQLearningAgent_SnapshotRestore(t *testing.T) {
+	agent := NewQLearningAgent(0.1, 0.95, 0.5, 0.01, 0.995)
+	state := testState("Snapshot round trip text.")
+	action := getDefaultActions()[1]
+	agent.Update(state, action, 2.0, state, false)
+
+	snapshot := agent.Snapshot()
+	if snapshot.Kind != AgentKindQLearning {
+		t.Errorf("Expected kind %s, got %s", AgentKindQLearning, snapshot.Kind)
+	}
+
+	restored := NewQLearningAgent(0.2, 0.5, 1.0, 0.1, 0.9)
+	if err := restored.Restore(snapshot); err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+
+	if restored.GetQValue(state, action) != agent.GetQValue(state, action) {
+		t.Error("Restored Q-value does not match original")
+	}
+	if restored.Exploration.Rate() != agent.Exploration.Rate() || restored.LearningRate != 0.1 {
+		t.Error("Restored hyperparameters do not match original")
+	}
+
+	// Snapshot tables must not alias the live agent
+	snapshot.Tables["q"][agent.getStateKey(state)][agent.getActionKey(action)] = 99
+	if agent.GetQValue(state, action) == 99 {
+		t.Error("Snapshot aliases the agent's Q-table")
+	}
+
+	if err := restored.Restore(AgentSnapshot{Kind: "other"}); err == nil {
+		t.Error("Expected error restoring snapshot of a different kind")
+	}
+}
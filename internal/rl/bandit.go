@@ -0,0 +1,457 @@
+package rl
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	BanditLinUCB   = "linucb"
+	BanditThompson = "thompson"
+)
+
+// BanditPolicy chooses one arm per round from a context vector and learns
+// from the reward of that arm alone. Arms are indices into the trainer's
+// action set.
+type BanditPolicy interface {
+	Name() string
+	Select(context []float64) int
+	Update(context []float64, arm int, reward float64)
+	// Estimate is the policy's expected reward for arm in context, without
+	// any exploration bonus or sampling noise.
+	Estimate(context []float64, arm int) float64
+}
+
+// NewBanditPolicy builds a disjoint linear bandit: each arm has its own ridge
+// regression of reward on the context. alpha is the LinUCB confidence width
+// or the Thompson posterior scale.
+func NewBanditPolicy(algorithm string, dimension, arms int, alpha float64) (BanditPolicy, error) {
+	switch algorithm {
+	case BanditLinUCB, "":
+		return NewLinUCB(dimension, arms, alpha), nil
+	case BanditThompson:
+		return NewLinearThompson(dimension, arms, alpha), nil
+	default:
+		return nil, fmt.Errorf("unknown bandit algorithm %q (available: %s, %s)", algorithm, BanditLinUCB, BanditThompson)
+	}
+}
+
+// linearArm is a ridge regression with A = I + Σ x xᵀ and b = Σ r x. The
+// inverse of A is maintained directly with Sherman-Morrison updates, so
+// neither policy ever solves a linear system.
+type linearArm struct {
+	inverse [][]float64
+	b       []float64
+	theta   []float64
+	pulls   int
+}
+
+func newLinearArm(dimension int) *linearArm {
+	inverse := make([][]float64, dimension)
+	for i := range inverse {
+		inverse[i] = make([]float64, dimension)
+		inverse[i][i] = 1.0
+	}
+	return &linearArm{
+		inverse: inverse,
+		b:       make([]float64, dimension),
+		theta:   make([]float64, dimension),
+	}
+}
+
+func (arm *linearArm) update(context []float64, reward float64) {
+	projected := matrixVector(arm.inverse, context)
+	denominator := 1.0 + dot(context, projected)
+	for i := range arm.inverse {
+		for j := range arm.inverse[i] {
+			arm.inverse[i][j] -= projected[i] * projected[j] / denominator
+		}
+	}
+	for i, x := range context {
+		arm.b[i] += reward * x
+	}
+	arm.theta = matrixVector(arm.inverse, arm.b)
+	arm.pulls++
+}
+
+// width is √(xᵀA⁻¹x), the size of the confidence ellipsoid along x.
+func (arm *linearArm) width(context []float64) float64 {
+	return math.Sqrt(math.Max(0.0, dot(context, matrixVector(arm.inverse, context))))
+}
+
+// LinUCB plays the arm with the highest upper confidence bound θ·x + α√(xᵀA⁻¹x).
+type LinUCB struct {
+	Alpha float64
+	arms  []*linearArm
+}
+
+func NewLinUCB(dimension, arms int, alpha float64) *LinUCB {
+	policy := &LinUCB{Alpha: alpha, arms: make([]*linearArm, arms)}
+	for i := range policy.arms {
+		policy.arms[i] = newLinearArm(dimension)
+	}
+	return policy
+}
+
+func (policy *LinUCB) Name() string { return BanditLinUCB }
+
+func (policy *LinUCB) Select(context []float64) int {
+	scores := make([]float64, len(policy.arms))
+	for i, arm := range policy.arms {
+		scores[i] = dot(arm.theta, context) + policy.Alpha*arm.width(context)
+	}
+	return argmax(scores)
+}
+
+func (policy *LinUCB) Update(context []float64, arm int, reward float64) {
+	policy.arms[arm].update(context, reward)
+}
+
+func (policy *LinUCB) Estimate(context []float64, arm int) float64 {
+	return dot(policy.arms[arm].theta, context)
+}
+
+// LinearThompson samples θ̃ ~ N(θ, v²A⁻¹) for every arm and plays the arm
+// whose sample scores the context highest.
+type LinearThompson struct {
+	Scale float64
+	// Rand draws the posterior samples; nil uses math/rand
+	Rand *Random
+	arms []*linearArm
+}
+
+func NewLinearThompson(dimension, arms int, scale float64) *LinearThompson {
+	policy := &LinearThompson{Scale: scale, arms: make([]*linearArm, arms)}
+	for i := range policy.arms {
+		policy.arms[i] = newLinearArm(dimension)
+	}
+	return policy
+}
+
+func (policy *LinearThompson) Name() string { return BanditThompson }
+
+func (policy *LinearThompson) Select(context []float64) int {
+	scores := make([]float64, len(policy.arms))
+	for i, arm := range policy.arms {
+		sample := sampleGaussian(policy.Rand, arm.theta, cholesky(arm.inverse), policy.Scale)
+		scores[i] = dot(sample, context)
+	}
+	return argmax(scores)
+}
+
+func (policy *LinearThompson) Update(context []float64, arm int, reward float64) {
+	policy.arms[arm].update(context, reward)
+}
+
+func (policy *LinearThompson) Estimate(context []float64, arm int) float64 {
+	return dot(policy.arms[arm].theta, context)
+}
+
+// sampleGaussian draws mean + scale·L·z with z standard normal, where L is the
+// lower Cholesky factor of the covariance.
+func sampleGaussian(rng *Random, mean []float64, lower [][]float64, scale float64) []float64 {
+	z := make([]float64, len(mean))
+	for i := range z {
+		z[i] = randNormFloat64(rng)
+	}
+	sample := append([]float64(nil), mean...)
+	for i := range lower {
+		for j := 0; j <= i; j++ {
+			sample[i] += scale * lower[i][j] * z[j]
+		}
+	}
+	return sample
+}
+
+// cholesky factors a symmetric positive-definite matrix as L·Lᵀ. Pivots are
+// floored at a small positive value because Sherman-Morrison updates can
+// drift a hair below zero on long runs.
+func cholesky(matrix [][]float64) [][]float64 {
+	n := len(matrix)
+	lower := make([][]float64, n)
+	for i := range lower {
+		lower[i] = make([]float64, n)
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i; j++ {
+			total := matrix[i][j]
+			for k := 0; k < j; k++ {
+				total -= lower[i][k] * lower[j][k]
+			}
+			if i == j {
+				lower[i][i] = math.Sqrt(math.Max(total, 1e-12))
+			} else {
+				lower[i][j] = total / lower[j][j]
+			}
+		}
+	}
+	return lower
+}
+
+func matrixVector(matrix [][]float64, vector []float64) []float64 {
+	result := make([]float64, len(matrix))
+	for i, row := range matrix {
+		result[i] = dot(row, vector)
+	}
+	return result
+}
+
+func dot(a, b []float64) float64 {
+	total := 0.0
+	for i := range a {
+		total += a[i] * b[i]
+	}
+	return total
+}
+
+// banditContext turns the text features of a fresh state into a fixed-width
+// context: bias, length bucket, code, math, entity density and a one-hot task
+// type. Task types are taken from the training data up front; anything else
+// shares a final "other" slot.
+type banditContext struct {
+	featurizer StateFeaturizer
+	tasks      map[string]int
+}
+
+func newBanditContext(featurizer StateFeaturizer, examples []TrainingExample) *banditContext {
+	names := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, example := range examples {
+		if !seen[example.TaskType] {
+			seen[example.TaskType] = true
+			names = append(names, example.TaskType)
+		}
+	}
+	sort.Strings(names)
+
+	tasks := make(map[string]int, len(names))
+	for i, name := range names {
+		tasks[name] = i
+	}
+	return &banditContext{featurizer: featurizer, tasks: tasks}
+}
+
+func (context *banditContext) dimension() int {
+	return 8 + len(context.tasks) + 1
+}
+
+func (context *banditContext) vector(features StateFeatures) []float64 {
+	vector := make([]float64, context.dimension())
+	vector[0] = 1.0
+	switch features.LengthBucket {
+	case LengthShort:
+		vector[1] = 1
+	case LengthMedium:
+		vector[2] = 1
+	case LengthLong:
+		vector[3] = 1
+	default:
+		vector[4] = 1
+	}
+	if features.CodePresence {
+		vector[5] = 1
+	}
+	if features.MathPresence {
+		vector[6] = 1
+	}
+	vector[7] = math.Min(1.0, features.EntityDensity)
+
+	if i, exists := context.tasks[features.TaskType]; exists {
+		vector[8+i] = 1
+	} else {
+		vector[8+len(context.tasks)] = 1
+	}
+	return vector
+}
+
+// banditClusterKey groups contexts that get the same first-call
+// recommendation: task type, length bucket and code/math presence.
+func banditClusterKey(features StateFeatures) string {
+	parts := []string{features.TaskType, features.LengthBucket}
+	if features.CodePresence {
+		parts = append(parts, "code")
+	}
+	if features.MathPresence {
+		parts = append(parts, "math")
+	}
+	return strings.Join(parts, "|")
+}
+
+type banditCluster struct {
+	rounds     int
+	contextSum []float64
+	pulls      []int
+	rewardSum  []float64
+}
+
+// banditBudget is the budget of a fresh episode, matching createInitialState.
+const banditBudget = 50
+
+// BanditRecommendation is the best first call for one context cluster.
+type BanditRecommendation struct {
+	Cluster        string  `json:"cluster"`
+	TaskType       string  `json:"task_type"`
+	Function       string  `json:"function"`
+	ExpectedReward float64 `json:"expected_reward"`
+	RunnerUp       string  `json:"runner_up,omitempty"`
+	Margin         float64 `json:"margin"`
+	Rounds         int     `json:"rounds"`
+	Pulls          int     `json:"pulls"`
+	ObservedReward float64 `json:"observed_reward"`
+}
+
+// BanditTrainer learns which textlib call to make first for a text. Every
+// round is one example: its initial state is featurized into a context, the
+// policy picks one of the actions allowed as a first call, the executor runs it and the
+// enhanced reward for that single call is fed back.
+type BanditTrainer struct {
+	Policy       BanditPolicy
+	Arms         []Action
+	TrainingData []TrainingExample
+	// Rand samples the examples; nil uses math/rand
+	Rand *Random
+
+	context    *banditContext
+	clusters   map[string]*banditCluster
+	executor   Executor
+	rewardCalc *EnhancedRewardCalculator
+}
+
+// NewBanditTrainer builds a trainer from config.BanditAlgorithm and
+// config.BanditAlpha, defaulting to LinUCB with α = 1.
+func NewBanditTrainer(config SystemConfig, examples []TrainingExample) (*BanditTrainer, error) {
+	alpha := config.BanditAlpha
+	if alpha <= 0 {
+		alpha = 1.0
+	}
+
+	// Arms are the actions whose preconditions allow them as a first call
+	arms := DefaultActionSpace().Available(State{RemainingBudget: banditBudget})
+	context := newBanditContext(NewTextFeaturizer(), examples)
+	policy, err := NewBanditPolicy(config.BanditAlgorithm, context.dimension(), len(arms), alpha)
+	if err != nil {
+		return nil, err
+	}
+	if thompson, ok := policy.(*LinearThompson); ok {
+		thompson.Rand = newRand(config.Seed, "bandit")
+	}
+
+	return &BanditTrainer{
+		Policy:       policy,
+		Arms:         arms,
+		TrainingData: examples,
+		Rand:         newRand(config.Seed, "sampler"),
+		context:      context,
+		clusters:     make(map[string]*banditCluster),
+		executor:     newSimulatorFromConfig(config),
+		rewardCalc:   NewEnhancedRewardCalculator(),
+	}, nil
+}
+
+// SetExecutor replaces the simulator that runs the chosen arms.
+func (trainer *BanditTrainer) SetExecutor(executor Executor) {
+	trainer.executor = executor
+}
+
+// SampleExample draws a training example uniformly at random.
+func (trainer *BanditTrainer) SampleExample() TrainingExample {
+	if len(trainer.TrainingData) == 0 {
+		return TrainingExample{ID: "default", Text: "Sample text for analysis and processing.", TaskType: "comprehensive"}
+	}
+	return trainer.TrainingData[randIntn(trainer.Rand, len(trainer.TrainingData))]
+}
+
+func (trainer *BanditTrainer) initialState(example TrainingExample) State {
+	return State{
+		Text:            example.Text,
+		TaskType:        example.TaskType,
+		ActionsUsed:     []string{},
+		CurrentResults:  make(map[string]interface{}),
+		RemainingBudget: banditBudget,
+	}
+}
+
+// Step plays one round on example and returns the chosen action and its reward.
+func (trainer *BanditTrainer) Step(example TrainingExample) (Action, float64) {
+	state := trainer.initialState(example)
+	features := trainer.context.featurizer.Featurize(state)
+	context := trainer.context.vector(features)
+
+	arm := trainer.Policy.Select(context)
+	action := trainer.Arms[arm]
+	result := trainer.executor.ExecuteAction(action, state.Text, action.Parameters)
+	reward := trainer.rewardCalc.CalculateReward(state, action, result, example)
+
+	trainer.Policy.Update(context, arm, reward)
+	trainer.observe(banditClusterKey(features), context, arm, reward)
+	return action, reward
+}
+
+func (trainer *BanditTrainer) observe(key string, context []float64, arm int, reward float64) {
+	cluster, exists := trainer.clusters[key]
+	if !exists {
+		cluster = &banditCluster{
+			contextSum: make([]float64, len(context)),
+			pulls:      make([]int, len(trainer.Arms)),
+			rewardSum:  make([]float64, len(trainer.Arms)),
+		}
+		trainer.clusters[key] = cluster
+	}
+	cluster.rounds++
+	for i, x := range context {
+		cluster.contextSum[i] += x
+	}
+	cluster.pulls[arm]++
+	cluster.rewardSum[arm] += reward
+}
+
+// Recommendations ranks the arms at each cluster's mean context and returns
+// the best one per cluster, sorted by cluster key.
+func (trainer *BanditTrainer) Recommendations() []BanditRecommendation {
+	keys := make([]string, 0, len(trainer.clusters))
+	for key := range trainer.clusters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	recommendations := make([]BanditRecommendation, 0, len(keys))
+	for _, key := range keys {
+		cluster := trainer.clusters[key]
+		mean := make([]float64, len(cluster.contextSum))
+		for i, total := range cluster.contextSum {
+			mean[i] = total / float64(cluster.rounds)
+		}
+
+		estimates := make([]float64, len(trainer.Arms))
+		for arm := range trainer.Arms {
+			estimates[arm] = trainer.Policy.Estimate(mean, arm)
+		}
+		best := argmax(estimates)
+		recommendation := BanditRecommendation{
+			Cluster:        key,
+			TaskType:       strings.SplitN(key, "|", 2)[0],
+			Function:       trainer.Arms[best].FunctionName,
+			ExpectedReward: estimates[best],
+			Rounds:         cluster.rounds,
+			Pulls:          cluster.pulls[best],
+		}
+		if cluster.pulls[best] > 0 {
+			recommendation.ObservedReward = cluster.rewardSum[best] / float64(cluster.pulls[best])
+		}
+
+		runnerUp := -1
+		for arm, estimate := range estimates {
+			if arm != best && (runnerUp < 0 || estimate > estimates[runnerUp]) {
+				runnerUp = arm
+			}
+		}
+		if runnerUp >= 0 {
+			recommendation.RunnerUp = trainer.Arms[runnerUp].FunctionName
+			recommendation.Margin = estimates[best] - estimates[runnerUp]
+		}
+		recommendations = append(recommendations, recommendation)
+	}
+	return recommendations
+}
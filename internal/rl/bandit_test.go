@@ -0,0 +1,120 @@
+package rl
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestBanditPolicies_LearnBestArmPerContext(t *testing.T) {
+	contexts := [][]float64{{1, 1, 0}, {1, 0, 1}}
+	best := []int{0, 2}
+
+	for _, algorithm := range []string{BanditLinUCB, BanditThompson} {
+		policy, err := NewBanditPolicy(algorithm, 3, 4, 0.5)
+		if err != nil {
+			t.Fatalf("NewBanditPolicy(%s) returned error: %v", algorithm, err)
+		}
+		for round := 0; round < 600; round++ {
+			c := round % len(contexts)
+			arm := policy.Select(contexts[c])
+			reward := 0.2 + 0.1*rand.NormFloat64()
+			if arm == best[c] {
+				reward = 1.0 + 0.1*rand.NormFloat64()
+			}
+			policy.Update(contexts[c], arm, reward)
+		}
+
+		for c, context := range contexts {
+			estimates := make([]float64, 4)
+			for arm := range estimates {
+				estimates[arm] = policy.Estimate(context, arm)
+			}
+			if got := argmax(estimates); got != best[c] {
+				t.Errorf("%s: expected arm %d for context %d, got %d (%v)", algorithm, best[c], c, got, estimates)
+			}
+		}
+	}
+
+	if _, err := NewBanditPolicy("exp3", 3, 4, 1.0); err == nil {
+		t.Error("Expected error for unknown bandit algorithm")
+	}
+}
+
+func TestLinUCB_ConfidenceShrinksWithPulls(t *testing.T) {
+	policy := NewLinUCB(2, 1, 1.0)
+	context := []float64{1, 1}
+	before := policy.arms[0].width(context)
+	for i := 0; i < 10; i++ {
+		policy.Update(context, 0, 1.0)
+	}
+	if after := policy.arms[0].width(context); after >= before/2 {
+		t.Errorf("Expected confidence width to shrink, %f -> %f", before, after)
+	}
+}
+
+func TestCholesky_ReconstructsMatrix(t *testing.T) {
+	matrix := [][]float64{{4, 2, 0.4}, {2, 5, 1}, {0.4, 1, 3}}
+	lower := cholesky(matrix)
+	for i := range matrix {
+		for j := range matrix {
+			total := 0.0
+			for k := range matrix {
+				total += lower[i][k] * lower[j][k]
+			}
+			if math.Abs(total-matrix[i][j]) > 1e-9 {
+				t.Errorf("L·Lᵀ[%d][%d] = %f, expected %f", i, j, total, matrix[i][j])
+			}
+		}
+	}
+}
+
+func TestBanditTrainer_RecommendsFirstCallPerCluster(t *testing.T) {
+	examples := []TrainingExample{
+		{ID: "a", Text: "The quick report.", TaskType: "news_analysis"},
+		{ID: "b", Text: "Another quick memo.", TaskType: "business_communication"},
+	}
+	trainer, err := NewBanditTrainer(SystemConfig{BanditAlgorithm: BanditThompson}, examples)
+	if err != nil {
+		t.Fatalf("NewBanditTrainer returned error: %v", err)
+	}
+	if trainer.Policy.Name() != BanditThompson {
+		t.Errorf("Expected thompson policy, got %s", trainer.Policy.Name())
+	}
+
+	// One real round per example exercises the simulator and reward path
+	for _, example := range examples {
+		if action, _ := trainer.Step(example); action.FunctionName == "" {
+			t.Error("Expected an action from Step")
+		}
+	}
+
+	// Then teach the policy directly that format_text wins for news
+	features := trainer.context.featurizer.Featurize(trainer.initialState(examples[0]))
+	context := trainer.context.vector(features)
+	for round := 0; round < 200; round++ {
+		arm := trainer.Policy.Select(context)
+		reward := 0.0
+		if trainer.Arms[arm].FunctionName == "format_text" {
+			reward = 2.0
+		}
+		trainer.Policy.Update(context, arm, reward)
+		trainer.observe(banditClusterKey(features), context, arm, reward)
+	}
+
+	recommendations := trainer.Recommendations()
+	if len(recommendations) != 2 {
+		t.Fatalf("Expected one recommendation per cluster, got %+v", recommendations)
+	}
+	if recommendations[0].TaskType != "business_communication" || recommendations[1].Cluster != "news_analysis|short" {
+		t.Errorf("Expected clusters sorted by key, got %s and %s", recommendations[0].Cluster, recommendations[1].Cluster)
+	}
+	news := recommendations[1]
+	if news.Function != "format_text" || news.Rounds != 201 || news.Margin <= 0 {
+		t.Errorf("Expected format_text recommended for news, got %+v", news)
+	}
+
+	if _, err := NewBanditTrainer(SystemConfig{BanditAlgorithm: "greedy"}, examples); err == nil {
+		t.Error("Expected error for unknown bandit algorithm")
+	}
+}
@@ -0,0 +1,264 @@
+package rl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// defaultCheckpointKeepLast is how many checkpoints are kept when
+// CheckpointKeepLast is unset.
+const defaultCheckpointKeepLast = 5
+
+// checkpointPattern matches the files SaveCheckpoint writes. Names carry the
+// zero-padded episode count, so lexical order is training order.
+const checkpointPattern = "checkpoint_*.json"
+
+// Checkpoint is everything a training run needs to continue exactly where it
+// stopped: the agent with its exploration state, how many episodes have run,
+// the position of every seeded stream the system owns and the curriculum and
+// tuner state. Streams owned by the agent are part of its snapshot, and the
+// experience of an ExperienceAgent is saved next to it.
+type Checkpoint struct {
+	Episode           int                   `json:"episode"` // episodes completed
+	Seed              int64                 `json:"seed"`
+	Config            SystemConfig          `json:"config"`
+	Executor          string                `json:"executor"`
+	TrainingData      string                `json:"training_data"` // fingerprint of the examples
+	Agent             AgentSnapshot         `json:"agent"`
+	Experience        json.RawMessage       `json:"experience,omitempty"`
+	ExplorationRate   float64               `json:"exploration_rate"`
+	Random            map[string]uint64     `json:"random"`
+	Curriculum        *CurriculumState      `json:"curriculum,omitempty"`
+	ParameterTuning   *ParameterTuningState `json:"parameter_tuning,omitempty"`
+	ValidationHistory []EvaluationResult    `json:"validation_history,omitempty"`
+	Timestamp         time.Time             `json:"timestamp"`
+}
+
+// Checkpoint captures the training state after the episodes run so far.
+func (system *EnhancedRLSystem) Checkpoint() Checkpoint {
+	checkpoint := Checkpoint{
+		Episode:           system.episode,
+		Seed:              system.Config.Seed,
+		Config:            system.Config,
+		Executor:          system.executor.Name(),
+		TrainingData:      fingerprintExamples(system.TrainingData),
+		Agent:             system.Agent.Snapshot(),
+		ExplorationRate:   system.explorationRate,
+		Random:            make(map[string]uint64),
+		ParameterTuning:   system.ParameterTuning(),
+		ValidationHistory: system.ValidationHistory(),
+		Timestamp:         time.Now(),
+	}
+	for name, rng := range system.randomStreams() {
+		checkpoint.Random[name] = rng.State()
+	}
+	if agent, ok := system.Agent.(ExperienceAgent); ok {
+		experience, err := agent.Experience()
+		if err != nil {
+			log.Printf("Checkpoint after %d episodes leaves out the agent's experience: %v", system.episode, err)
+		}
+		checkpoint.Experience = experience
+	}
+	if system.curriculum != nil {
+		state := system.curriculum.State()
+		checkpoint.Curriculum = &state
+	}
+	return checkpoint
+}
+
+// Resume restores a checkpoint into a system built from checkpoint.Config
+// with the same executor and training data, after LoadTrainingData.
+// TrainWithLogging then continues from the checkpoint's episode.
+func (system *EnhancedRLSystem) Resume(checkpoint Checkpoint) error {
+	if checkpoint.Seed != system.Config.Seed {
+		return fmt.Errorf("checkpoint was trained with seed %d, system has seed %d", checkpoint.Seed, system.Config.Seed)
+	}
+	if checkpoint.Executor != system.executor.Name() {
+		return fmt.Errorf("checkpoint was trained on the %s executor, system uses %s", checkpoint.Executor, system.executor.Name())
+	}
+	if fingerprint := fingerprintExamples(system.TrainingData); checkpoint.TrainingData != fingerprint {
+		return fmt.Errorf("checkpoint was trained on different data (%s, loaded %s)", checkpoint.TrainingData, fingerprint)
+	}
+	if err := system.Agent.Restore(checkpoint.Agent); err != nil {
+		return fmt.Errorf("restoring agent: %w", err)
+	}
+	if agent, ok := system.Agent.(ExperienceAgent); ok && checkpoint.Experience != nil {
+		if err := agent.RestoreExperience(checkpoint.Experience); err != nil {
+			return fmt.Errorf("restoring agent experience: %w", err)
+		}
+	}
+
+	streams := system.randomStreams()
+	for name, state := range checkpoint.Random {
+		if rng, exists := streams[name]; exists {
+			rng.SetState(state)
+		}
+	}
+	if checkpoint.Curriculum != nil {
+		if system.curriculum == nil {
+			system.curriculum = newCurriculumFromConfig(system.Config, system.TrainingData)
+		}
+		system.curriculum.Restore(*checkpoint.Curriculum)
+	}
+	if checkpoint.ParameterTuning != nil {
+		system.RestoreParameterTuning(*checkpoint.ParameterTuning)
+	}
+	system.validationHistory = append([]EvaluationResult(nil), checkpoint.ValidationHistory...)
+	system.explorationRate = checkpoint.ExplorationRate
+	system.episode = checkpoint.Episode
+	system.lastCheckpoint = checkpoint.Episode
+	return nil
+}
+
+// randomStreams lists the seeded streams the system owns, by checkpoint key.
+// The curriculum's sampler is saved with the curriculum and the agent's
+// streams with its snapshot.
+func (system *EnhancedRLSystem) randomStreams() map[string]*Random {
+	streams := make(map[string]*Random)
+	if simulator, ok := system.executor.(*ActionSimulator); ok && simulator.Rand != nil {
+		streams["simulator"] = simulator.Rand
+	}
+	if system.evaluator != nil && system.evaluator.Rand != nil {
+		streams["evaluation"] = system.evaluator.Rand
+	}
+	if system.tuner != nil {
+		if system.tuner.Optimizer.Rand != nil {
+			streams["tuning"] = system.tuner.Optimizer.Rand
+		}
+		if system.tuner.simulator.Rand != nil {
+			streams["tuning_simulator"] = system.tuner.simulator.Rand
+		}
+	}
+	return streams
+}
+
+// SaveCheckpoint writes a checkpoint to Config.CheckpointDir and removes all
+// but the newest CheckpointKeepLast. The file is written under a temporary
+// name and renamed into place, so an interrupted write never replaces a good
+// checkpoint.
+func (system *EnhancedRLSystem) SaveCheckpoint() (string, error) {
+	dir := system.Config.CheckpointDir
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(system.Checkpoint(), "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.CreateTemp(dir, ".checkpoint-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	path := filepath.Join(dir, fmt.Sprintf("checkpoint_%08d.json", system.episode))
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	system.lastCheckpoint = system.episode
+
+	keep := system.Config.CheckpointKeepLast
+	if keep <= 0 {
+		keep = defaultCheckpointKeepLast
+	}
+	return path, pruneCheckpoints(dir, keep)
+}
+
+// LoadCheckpoint reads the checkpoint at path, or the newest checkpoint in it
+// when path is a directory.
+func LoadCheckpoint(path string) (Checkpoint, error) {
+	var checkpoint Checkpoint
+	if info, err := os.Stat(path); err != nil {
+		return checkpoint, err
+	} else if info.IsDir() {
+		latest, err := LatestCheckpoint(path)
+		if err != nil {
+			return checkpoint, err
+		}
+		path = latest
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return checkpoint, err
+	}
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return checkpoint, fmt.Errorf("invalid checkpoint %s: %w", path, err)
+	}
+	return checkpoint, nil
+}
+
+// LatestCheckpoint returns the path of the newest checkpoint in dir.
+func LatestCheckpoint(dir string) (string, error) {
+	paths, err := listCheckpoints(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(paths) == 0 {
+		return "", fmt.Errorf("no checkpoints in %s", dir)
+	}
+	return paths[len(paths)-1], nil
+}
+
+func listCheckpoints(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, checkpointPattern))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func pruneCheckpoints(dir string, keep int) error {
+	paths, err := listCheckpoints(dir)
+	if err != nil {
+		return err
+	}
+	for len(paths) > keep {
+		if err := os.Remove(paths[0]); err != nil {
+			return err
+		}
+		paths = paths[1:]
+	}
+	return nil
+}
+
+// checkpointModel saves a checkpoint when a directory is configured.
+func (system *EnhancedRLSystem) checkpointModel() {
+	if system.Config.CheckpointDir == "" {
+		return
+	}
+	path, err := system.SaveCheckpoint()
+	if err != nil {
+		log.Printf("Failed to checkpoint after %d episodes: %v", system.episode, err)
+		return
+	}
+	log.Printf("Checkpointed %d episodes to %s", system.episode, path)
+}
+
+// fingerprintExamples identifies a training set by its IDs and texts, in
+// order, so a run is not resumed on different data.
+func fingerprintExamples(examples []TrainingExample) string {
+	hash := sha256.New()
+	for _, example := range examples {
+		hash.Write([]byte(example.ID))
+		hash.Write([]byte{0})
+		hash.Write([]byte(example.Text))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
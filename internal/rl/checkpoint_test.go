@@ -0,0 +1,120 @@
+package rl
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+func snapshotJSON(t *testing.T, system *EnhancedRLSystem) []byte {
+	t.Helper()
+	data, err := json.Marshal(system.Agent.Snapshot())
+	if err != nil {
+		t.Fatalf("Failed to encode snapshot: %v", err)
+	}
+	return data
+}
+
+func TestCheckpoint_ResumeMatchesUninterruptedRun(t *testing.T) {
+	for _, strategy := range []string{ExplorationEpsilonGreedy, ExplorationBoltzmann, ExplorationUCB1} {
+		for _, kind := range AgentKinds() {
+			name := kind + "/" + strategy
+			config := SystemConfig{
+				AgentType:                 kind,
+				Exploration:               ExplorationConfig{Strategy: strategy},
+				MaxEpisodes:               20,
+				MaxStepsPerEpisode:        5,
+				LoggingInterval:           4,
+				CheckpointInterval:        5,
+				CheckpointKeepLast:        10,
+				CheckpointDir:             t.TempDir(),
+				ParameterTuningInterval:   6,
+				TuningGenerations:         2,
+				TuningPopulation:          4,
+				TuningRollouts:            2,
+				Curriculum:                CurriculumSelfPaced,
+				CurriculumRewardThreshold: Float64(1),
+				Seed:                      3,
+			}
+			uninterrupted := trainingFixture(t, config)
+			uninterrupted.LoadValidationData(GetRealisticTrainingData()[:2])
+			uninterrupted.TrainWithLogging()
+
+			checkpoint, err := LoadCheckpoint(filepath.Join(config.CheckpointDir, "checkpoint_00000010.json"))
+			if err != nil {
+				t.Fatalf("%s: LoadCheckpoint returned error: %v", name, err)
+			}
+			if checkpoint.Episode != 10 || checkpoint.Seed != 3 || checkpoint.Curriculum == nil || checkpoint.ParameterTuning == nil {
+				t.Fatalf("%s: incomplete checkpoint %+v", name, checkpoint)
+			}
+
+			checkpoint.Config.CheckpointDir = t.TempDir()
+			resumed := trainingFixture(t, checkpoint.Config)
+			resumed.LoadValidationData(GetRealisticTrainingData()[:2])
+			if err := resumed.Resume(checkpoint); err != nil {
+				t.Fatalf("%s: Resume returned error: %v", name, err)
+			}
+			resumed.TrainWithLogging()
+
+			if !bytes.Equal(snapshotJSON(t, uninterrupted), snapshotJSON(t, resumed)) {
+				t.Errorf("%s: expected the resumed run to end with the uninterrupted run's agent", name)
+			}
+			if len(resumed.ValidationHistory()) != len(uninterrupted.ValidationHistory()) {
+				t.Errorf("%s: expected validation history to carry over, got %d of %d", name,
+					len(resumed.ValidationHistory()), len(uninterrupted.ValidationHistory()))
+			}
+		}
+	}
+}
+
+func TestCheckpoint_KeepsLastN(t *testing.T) {
+	dir := t.TempDir()
+	system := trainingFixture(t, SystemConfig{
+		MaxEpisodes: 12, MaxStepsPerEpisode: 2, LoggingInterval: 100,
+		CheckpointInterval: 2, CheckpointKeepLast: 3, CheckpointDir: dir,
+	})
+	system.TrainWithLogging()
+
+	paths, err := listCheckpoints(dir)
+	if err != nil {
+		t.Fatalf("listCheckpoints returned error: %v", err)
+	}
+	var names []string
+	for _, path := range paths {
+		names = append(names, filepath.Base(path))
+	}
+	want := []string{"checkpoint_00000008.json", "checkpoint_00000010.json", "checkpoint_00000012.json"}
+	if len(names) != len(want) || names[0] != want[0] || names[2] != want[2] {
+		t.Errorf("Expected %v, got %v", want, names)
+	}
+
+	latest, err := LoadCheckpoint(dir)
+	if err != nil || latest.Episode != 12 {
+		t.Errorf("Expected the directory to load its newest checkpoint, got episode %d (%v)", latest.Episode, err)
+	}
+}
+
+func TestCheckpoint_ResumeRejectsMismatches(t *testing.T) {
+	config := SystemConfig{MaxEpisodes: 2, MaxStepsPerEpisode: 2, LoggingInterval: 100, Seed: 1}
+	system := trainingFixture(t, config)
+	system.TrainWithLogging()
+	checkpoint := system.Checkpoint()
+
+	other := trainingFixture(t, SystemConfig{Seed: 2})
+	if err := other.Resume(checkpoint); err == nil {
+		t.Error("Expected a different seed to be rejected")
+	}
+
+	other = trainingFixture(t, config)
+	other.LoadTrainingData(GetRealisticTrainingData()[:1])
+	if err := other.Resume(checkpoint); err == nil {
+		t.Error("Expected different training data to be rejected")
+	}
+
+	other = trainingFixture(t, config)
+	other.SetExecutor(&recordingExecutor{})
+	if err := other.Resume(checkpoint); err == nil {
+		t.Error("Expected a different executor to be rejected")
+	}
+}
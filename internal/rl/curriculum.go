@@ -0,0 +1,256 @@
+package rl
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"textlib-rl-system/internal/logging"
+)
+
+const (
+	CurriculumUniform    = "uniform"
+	CurriculumEasyToHard = "easy_to_hard"
+	CurriculumCompetence = "competence"
+	CurriculumSelfPaced  = "self_paced"
+)
+
+// curriculumRewardSmoothing is the weight of the newest episode in the
+// per-example reward average the self-paced curriculum reads.
+const curriculumRewardSmoothing = 0.3
+
+// Curriculum samples training examples no harder than a difficulty ceiling
+// that rises over training:
+//
+//   - uniform: no ceiling, every example is equally likely
+//   - easy_to_hard: the ceiling rises linearly across the difficulty range,
+//     from Start of the way up at episode 0 to the hardest example after
+//     Episodes episodes
+//   - competence: competence-based pacing (Platanios et al., 2019); the
+//     easiest c(t) = min(1, sqrt(t(1-c0²)/T + c0²)) fraction of examples is
+//     available, with c0 = Start and T = Episodes
+//   - self_paced: the ceiling starts Start of the way up and rises by Step
+//     of the range once every available example has been played and their
+//     recent mean per-step reward reaches RewardThreshold
+//
+// Examples at or under the ceiling are sampled uniformly; the easiest example
+// is always available.
+type Curriculum struct {
+	Strategy        string
+	Start           float64
+	Episodes        int
+	RewardThreshold float64
+	Step            float64
+	// Rand draws the examples; nil uses math/rand
+	Rand *Random
+
+	examples  []TrainingExample // sorted by difficulty
+	ceiling   float64
+	available int
+	recent    map[string]float64
+}
+
+// CurriculumState is what a checkpoint keeps of a curriculum: the ceiling,
+// the self-paced reward averages and the sampler's position.
+type CurriculumState struct {
+	Ceiling float64            `json:"ceiling"`
+	Recent  map[string]float64 `json:"recent,omitempty"`
+	Random  uint64             `json:"random"`
+}
+
+// ValidateCurriculum checks config.Curriculum and its settings.
+func ValidateCurriculum(config SystemConfig) error {
+	switch config.Curriculum {
+	case "", CurriculumUniform, CurriculumEasyToHard, CurriculumCompetence, CurriculumSelfPaced:
+	default:
+		return fmt.Errorf("unknown curriculum %q (want %s, %s, %s or %s)", config.Curriculum,
+			CurriculumUniform, CurriculumEasyToHard, CurriculumCompetence, CurriculumSelfPaced)
+	}
+	if start := config.CurriculumStart; start != nil && (*start < 0 || *start > 1) {
+		return fmt.Errorf("curriculum start must be between 0 and 1, got %g", *start)
+	}
+	if config.CurriculumStep < 0 || config.CurriculumStep > 1 {
+		return fmt.Errorf("curriculum step must be between 0 and 1, got %g", config.CurriculumStep)
+	}
+	return nil
+}
+
+// NewCurriculum builds the configured curriculum over examples. Unset
+// settings default to starting 20% of the way up, reaching the full set
+// after half of MaxEpisodes, a self-paced threshold of 5 (half the largest
+// per-step reward) and steps of 10% of the range.
+func NewCurriculum(config SystemConfig, examples []TrainingExample) (*Curriculum, error) {
+	if err := ValidateCurriculum(config); err != nil {
+		return nil, err
+	}
+
+	curriculum := &Curriculum{
+		Strategy:        config.Curriculum,
+		Start:           0.2,
+		Episodes:        config.CurriculumEpisodes,
+		RewardThreshold: 5.0,
+		Step:            config.CurriculumStep,
+		Rand:            newRand(config.Seed, "sampler"),
+		examples:        append([]TrainingExample(nil), examples...),
+		recent:          make(map[string]float64),
+	}
+	if curriculum.Strategy == "" {
+		curriculum.Strategy = CurriculumUniform
+	}
+	if config.CurriculumStart != nil {
+		curriculum.Start = *config.CurriculumStart
+	}
+	if curriculum.Episodes <= 0 {
+		curriculum.Episodes = config.MaxEpisodes / 2
+		if curriculum.Episodes <= 0 {
+			curriculum.Episodes = 1000
+		}
+	}
+	if config.CurriculumRewardThreshold != nil {
+		curriculum.RewardThreshold = *config.CurriculumRewardThreshold
+	}
+	if curriculum.Step == 0 {
+		curriculum.Step = 0.1
+	}
+
+	if len(curriculum.examples) == 0 {
+		return curriculum, nil
+	}
+	sort.SliceStable(curriculum.examples, func(i, j int) bool {
+		return curriculum.examples[i].Difficulty < curriculum.examples[j].Difficulty
+	})
+	if curriculum.Strategy == CurriculumSelfPaced {
+		curriculum.setCeiling(curriculum.fromRange(curriculum.Start))
+	} else {
+		curriculum.advance(0)
+	}
+	return curriculum, nil
+}
+
+// newCurriculumFromConfig falls back to uniform sampling for configurations
+// ValidateCurriculum rejects; the CLI validates before training starts.
+func newCurriculumFromConfig(config SystemConfig, examples []TrainingExample) *Curriculum {
+	if curriculum, err := NewCurriculum(config, examples); err == nil {
+		return curriculum
+	}
+	config.Curriculum = CurriculumUniform
+	curriculum, _ := NewCurriculum(config, examples)
+	return curriculum
+}
+
+// Sample moves the schedule to episode and draws an example under the
+// ceiling. The curriculum must have at least one example.
+func (curriculum *Curriculum) Sample(episode int) TrainingExample {
+	curriculum.advance(episode)
+	return curriculum.examples[randIntn(curriculum.Rand, curriculum.available)]
+}
+
+// Ceiling is the current difficulty ceiling.
+func (curriculum *Curriculum) Ceiling() float64 {
+	return curriculum.ceiling
+}
+
+// Available is how many examples are at or under the ceiling.
+func (curriculum *Curriculum) Available() int {
+	return curriculum.available
+}
+
+// State captures the curriculum for a checkpoint.
+func (curriculum *Curriculum) State() CurriculumState {
+	state := CurriculumState{Ceiling: curriculum.ceiling, Recent: make(map[string]float64, len(curriculum.recent))}
+	for id, reward := range curriculum.recent {
+		state.Recent[id] = reward
+	}
+	if curriculum.Rand != nil {
+		state.Random = curriculum.Rand.State()
+	}
+	return state
+}
+
+// Restore returns the curriculum to a checkpointed state. It must be built
+// over the same examples as the one that was saved.
+func (curriculum *Curriculum) Restore(state CurriculumState) {
+	if len(curriculum.examples) > 0 {
+		curriculum.setCeiling(state.Ceiling)
+	}
+	curriculum.recent = make(map[string]float64, len(state.Recent))
+	for id, reward := range state.Recent {
+		curriculum.recent[id] = reward
+	}
+	if curriculum.Rand == nil {
+		curriculum.Rand = NewRandom(0)
+	}
+	curriculum.Rand.SetState(state.Random)
+}
+
+// Observe records the episode played on example. Only the self-paced
+// curriculum uses it.
+func (curriculum *Curriculum) Observe(example TrainingExample, episode logging.EpisodeMetrics) {
+	if len(episode.Rewards) == 0 {
+		return
+	}
+	reward := episode.TotalReward / float64(len(episode.Rewards))
+	if previous, seen := curriculum.recent[example.ID]; seen {
+		reward = previous + curriculumRewardSmoothing*(reward-previous)
+	}
+	curriculum.recent[example.ID] = reward
+}
+
+func (curriculum *Curriculum) advance(episode int) {
+	if len(curriculum.examples) == 0 {
+		return
+	}
+	progress := math.Min(1, float64(episode)/float64(curriculum.Episodes))
+	switch curriculum.Strategy {
+	case CurriculumEasyToHard:
+		curriculum.setCeiling(curriculum.fromRange(curriculum.Start + (1-curriculum.Start)*progress))
+	case CurriculumCompetence:
+		c0 := curriculum.Start
+		competence := math.Min(1, math.Sqrt(progress*(1-c0*c0)+c0*c0))
+		count := int(math.Ceil(competence * float64(len(curriculum.examples))))
+		if count < 1 {
+			count = 1
+		}
+		curriculum.setCeiling(curriculum.examples[count-1].Difficulty)
+	case CurriculumSelfPaced:
+		if curriculum.mastered() {
+			curriculum.setCeiling(math.Min(curriculum.fromRange(1), curriculum.ceiling+curriculum.Step*curriculum.span()))
+		}
+	default:
+		curriculum.setCeiling(curriculum.fromRange(1))
+	}
+}
+
+// mastered reports whether every available example has been played and
+// their recent mean per-step reward reaches the threshold.
+func (curriculum *Curriculum) mastered() bool {
+	total := 0.0
+	for _, example := range curriculum.examples[:curriculum.available] {
+		reward, seen := curriculum.recent[example.ID]
+		if !seen {
+			return false
+		}
+		total += reward
+	}
+	return total/float64(curriculum.available) >= curriculum.RewardThreshold
+}
+
+func (curriculum *Curriculum) setCeiling(ceiling float64) {
+	curriculum.ceiling = ceiling
+	curriculum.available = sort.Search(len(curriculum.examples), func(i int) bool {
+		return curriculum.examples[i].Difficulty > ceiling+1e-9
+	})
+	if curriculum.available < 1 {
+		curriculum.available = 1
+	}
+}
+
+// fromRange maps a fraction of the way through the difficulty range to a
+// difficulty.
+func (curriculum *Curriculum) fromRange(fraction float64) float64 {
+	return curriculum.examples[0].Difficulty + fraction*curriculum.span()
+}
+
+func (curriculum *Curriculum) span() float64 {
+	return curriculum.examples[len(curriculum.examples)-1].Difficulty - curriculum.examples[0].Difficulty
+}
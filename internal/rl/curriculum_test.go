@@ -0,0 +1,125 @@
+package rl
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"textlib-rl-system/internal/logging"
+)
+
+// curriculumFixture has difficulties 0.0, 0.1, ..., 1.0.
+func curriculumFixture() []TrainingExample {
+	examples := make([]TrainingExample, 11)
+	for i := range examples {
+		examples[len(examples)-1-i] = TrainingExample{ID: fmt.Sprintf("d%d", i), Text: "text", Difficulty: float64(i) / 10}
+	}
+	return examples
+}
+
+func maxSampledDifficulty(curriculum *Curriculum, episode int) float64 {
+	hardest := 0.0
+	for i := 0; i < 200; i++ {
+		hardest = math.Max(hardest, curriculum.Sample(episode).Difficulty)
+	}
+	return hardest
+}
+
+func TestCurriculum_EasyToHard(t *testing.T) {
+	curriculum, err := NewCurriculum(SystemConfig{Curriculum: CurriculumEasyToHard, CurriculumStart: Float64(0.2), CurriculumEpisodes: 100}, curriculumFixture())
+	if err != nil {
+		t.Fatalf("NewCurriculum returned error: %v", err)
+	}
+	for _, tc := range []struct {
+		episode int
+		ceiling float64
+	}{{0, 0.2}, {50, 0.6}, {100, 1.0}, {500, 1.0}} {
+		if hardest := maxSampledDifficulty(curriculum, tc.episode); hardest > tc.ceiling+1e-9 {
+			t.Errorf("Episode %d: sampled difficulty %.1f above ceiling %.1f", tc.episode, hardest, tc.ceiling)
+		}
+		if math.Abs(curriculum.Ceiling()-tc.ceiling) > 1e-9 {
+			t.Errorf("Episode %d: expected ceiling %.1f, got %v", tc.episode, tc.ceiling, curriculum.Ceiling())
+		}
+	}
+	if curriculum.Available() != 11 {
+		t.Errorf("Expected every example available at the end, got %d", curriculum.Available())
+	}
+}
+
+func TestCurriculum_Competence(t *testing.T) {
+	curriculum, _ := NewCurriculum(SystemConfig{Curriculum: CurriculumCompetence, CurriculumStart: Float64(0.1), CurriculumEpisodes: 100}, curriculumFixture())
+	// c(0) = 0.1 of 11 examples rounds up to 2; c(25) = sqrt(0.25*0.99+0.01) ≈ 0.507, 6 examples
+	if curriculum.Sample(0); curriculum.Available() != 2 || curriculum.Ceiling() != 0.1 {
+		t.Errorf("Expected 2 examples under 0.1 at first, got %d under %v", curriculum.Available(), curriculum.Ceiling())
+	}
+	if curriculum.Sample(25); curriculum.Available() != 6 {
+		t.Errorf("Expected 6 examples at episode 25, got %d", curriculum.Available())
+	}
+	if curriculum.Sample(100); curriculum.Available() != 11 {
+		t.Errorf("Expected full competence at episode 100, got %d", curriculum.Available())
+	}
+}
+
+func TestCurriculum_SelfPaced(t *testing.T) {
+	curriculum, _ := NewCurriculum(SystemConfig{Curriculum: CurriculumSelfPaced, CurriculumStart: Float64(0.1), CurriculumStep: 0.2, CurriculumRewardThreshold: Float64(2)}, curriculumFixture())
+	play := func(reward float64) {
+		for i := 0; i < 50; i++ {
+			example := curriculum.Sample(i)
+			curriculum.Observe(example, logging.EpisodeMetrics{Rewards: []float64{reward, reward}, TotalReward: 2 * reward})
+		}
+	}
+
+	play(1.0)
+	if math.Abs(curriculum.Ceiling()-0.1) > 1e-9 {
+		t.Errorf("Expected the ceiling held while reward is below threshold, got %v", curriculum.Ceiling())
+	}
+	play(3.0)
+	if curriculum.Ceiling() <= 0.1 {
+		t.Errorf("Expected the ceiling to rise once the reward passes the threshold, got %v", curriculum.Ceiling())
+	}
+	for i := 0; i < 10; i++ {
+		play(3.0)
+	}
+	if curriculum.Ceiling() != 1.0 || curriculum.Available() != 11 {
+		t.Errorf("Expected the ceiling capped at the hardest example, got %v", curriculum.Ceiling())
+	}
+}
+
+func TestCurriculum_UniformAndValidation(t *testing.T) {
+	curriculum, _ := NewCurriculum(SystemConfig{}, curriculumFixture())
+	if curriculum.Strategy != CurriculumUniform || curriculum.Ceiling() != 1.0 || curriculum.Available() != 11 {
+		t.Errorf("Expected uniform sampling over everything by default, got %s %v", curriculum.Strategy, curriculum.Ceiling())
+	}
+	if _, err := NewCurriculum(SystemConfig{Curriculum: "hardest_first"}, nil); err == nil {
+		t.Error("Expected an unknown curriculum to be rejected")
+	}
+	if err := ValidateCurriculum(SystemConfig{CurriculumStart: Float64(1.5)}); err == nil {
+		t.Error("Expected an out-of-range start to be rejected")
+	}
+
+	defaults, _ := NewCurriculum(SystemConfig{Curriculum: CurriculumSelfPaced}, curriculumFixture())
+	zeros, _ := NewCurriculum(SystemConfig{Curriculum: CurriculumSelfPaced, CurriculumStart: Float64(0), CurriculumRewardThreshold: Float64(0)}, curriculumFixture())
+	if defaults.Start != 0.2 || defaults.RewardThreshold != 5.0 {
+		t.Errorf("Expected the default start and threshold, got %v and %v", defaults.Start, defaults.RewardThreshold)
+	}
+	if zeros.Start != 0 || zeros.RewardThreshold != 0 || zeros.Ceiling() != 0 {
+		t.Errorf("Expected an explicit zero start and threshold, got %v and %v", zeros.Start, zeros.RewardThreshold)
+	}
+}
+
+func TestEnhancedRLSystem_LogsDifficultyCeiling(t *testing.T) {
+	system := NewEnhancedRLSystem(SystemConfig{Curriculum: CurriculumEasyToHard, CurriculumEpisodes: 10, MaxStepsPerEpisode: 1})
+	system.LoadTrainingData(curriculumFixture())
+	example := system.selectTrainingExample(0)
+	if example.Difficulty > 0.2+1e-9 {
+		t.Errorf("Expected an easy example first, got difficulty %v", example.Difficulty)
+	}
+
+	system.SetExecutor(&recordingExecutor{})
+	system.SetLogger(logging.NewInsightLogger(t.TempDir(), 10, time.Second))
+	metrics := system.runEpisodeWithLogging("ep", example)
+	if metrics.ExampleID != example.ID || metrics.Difficulty != example.Difficulty {
+		t.Errorf("Expected the example recorded in episode metrics, got %+v", metrics)
+	}
+}
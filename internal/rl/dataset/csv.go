@@ -0,0 +1,238 @@
+package dataset
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"textlib-rl-system/internal/rl"
+)
+
+// expectedColumnPrefix marks CSV columns that hold one expected field each,
+// e.g. "expected.has_code".
+const expectedColumnPrefix = "expected."
+
+// ColumnMapping names the CSV column holding each example field. Empty names
+// default to the field's JSON name ("id", "text", "task_type", "difficulty",
+// "expected"). The Expected column holds a JSON object; columns named
+// expected.<field> add single fields on top of it.
+type ColumnMapping struct {
+	ID         string
+	Text       string
+	TaskType   string
+	Difficulty string
+	Expected   string
+}
+
+// ParseColumnMapping reads a mapping like "text=body,task_type=category".
+// Keys are example fields, values are CSV column names.
+func ParseColumnMapping(spec string) (ColumnMapping, error) {
+	var mapping ColumnMapping
+	if strings.TrimSpace(spec) == "" {
+		return mapping, nil
+	}
+	for _, pair := range strings.Split(spec, ",") {
+		field, column, found := strings.Cut(pair, "=")
+		field, column = strings.TrimSpace(field), strings.TrimSpace(column)
+		if !found || column == "" {
+			return mapping, fmt.Errorf("invalid column mapping %q: want field=column", pair)
+		}
+		switch field {
+		case "id":
+			mapping.ID = column
+		case "text":
+			mapping.Text = column
+		case "task_type":
+			mapping.TaskType = column
+		case "difficulty":
+			mapping.Difficulty = column
+		case "expected":
+			mapping.Expected = column
+		default:
+			return mapping, fmt.Errorf("unknown field %q in column mapping (want id, text, task_type, difficulty or expected)", field)
+		}
+	}
+	return mapping, nil
+}
+
+func (mapping ColumnMapping) withDefaults() ColumnMapping {
+	if mapping.ID == "" {
+		mapping.ID = "id"
+	}
+	if mapping.Text == "" {
+		mapping.Text = "text"
+	}
+	if mapping.TaskType == "" {
+		mapping.TaskType = "task_type"
+	}
+	if mapping.Difficulty == "" {
+		mapping.Difficulty = "difficulty"
+	}
+	if mapping.Expected == "" {
+		mapping.Expected = "expected"
+	}
+	return mapping
+}
+
+// csvColumns is the header resolved against a mapping; -1 marks an absent
+// optional column.
+type csvColumns struct {
+	id, text, taskType, difficulty, expected int
+	fields                                   map[string]int
+}
+
+func resolveColumns(header []string, mapping ColumnMapping, explicit ColumnMapping) (csvColumns, error) {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.TrimSpace(name)] = i
+	}
+	lookup := func(column, explicitColumn string) (int, error) {
+		if i, exists := index[column]; exists {
+			return i, nil
+		}
+		if explicitColumn != "" {
+			return -1, fmt.Errorf("mapped column %q not in header %v", column, header)
+		}
+		return -1, nil
+	}
+
+	var columns csvColumns
+	var err error
+	if columns.text, err = lookup(mapping.Text, explicit.Text); err != nil {
+		return columns, err
+	}
+	if columns.text < 0 {
+		return columns, fmt.Errorf("text column %q not in header %v", mapping.Text, header)
+	}
+	if columns.id, err = lookup(mapping.ID, explicit.ID); err != nil {
+		return columns, err
+	}
+	if columns.taskType, err = lookup(mapping.TaskType, explicit.TaskType); err != nil {
+		return columns, err
+	}
+	if columns.difficulty, err = lookup(mapping.Difficulty, explicit.Difficulty); err != nil {
+		return columns, err
+	}
+	if columns.expected, err = lookup(mapping.Expected, explicit.Expected); err != nil {
+		return columns, err
+	}
+
+	columns.fields = make(map[string]int)
+	for name, i := range index {
+		if field := strings.TrimPrefix(name, expectedColumnPrefix); field != name && field != "" {
+			columns.fields[field] = i
+		}
+	}
+	return columns, nil
+}
+
+// loadCSV reads one example per row after the header. Record numbers in
+// errors are the line the row starts on. A malformed row is rejected like an
+// invalid one; only a failure to read the file stops the load.
+func loadCSV(path string, mapping ColumnMapping) ([]rl.TrainingExample, []RecordError, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading CSV header of %s: %w", path, err)
+	}
+	columns, err := resolveColumns(header, mapping.withDefaults(), mapping)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var examples []rl.TrainingExample
+	var rejected []RecordError
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) && !errors.Is(err, csv.ErrFieldCount) {
+			// The reader resumes at the next line, so only this record is lost
+			rejected = append(rejected, RecordError{Source: path, Record: parseErr.StartLine, Err: parseErr.Err})
+			continue
+		}
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return nil, nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			rejected = append(rejected, RecordError{Source: path, Record: line, Err: fmt.Errorf("has %d fields, header has %d", len(record), len(header))})
+			continue
+		}
+
+		example, err := columns.example(record)
+		if err == nil {
+			if example.ID == "" {
+				example.ID = defaultID(path, row)
+			}
+			err = Validate(&example)
+		}
+		if err != nil {
+			rejected = append(rejected, RecordError{Source: path, Record: line, Err: err})
+			continue
+		}
+		examples = append(examples, example)
+	}
+	return examples, rejected, nil
+}
+
+func (columns csvColumns) example(record []string) (rl.TrainingExample, error) {
+	cell := func(i int) string {
+		if i < 0 {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	example := rl.TrainingExample{
+		ID:       cell(columns.id),
+		Text:     record[columns.text],
+		TaskType: cell(columns.taskType),
+		Expected: map[string]interface{}{},
+	}
+	if value := cell(columns.difficulty); value != "" {
+		difficulty, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return example, fmt.Errorf("difficulty %q is not a number", value)
+		}
+		example.Difficulty = difficulty
+	}
+	if value := cell(columns.expected); value != "" {
+		if err := json.Unmarshal([]byte(value), &example.Expected); err != nil {
+			return example, fmt.Errorf("expected is not a JSON object: %v", err)
+		}
+		if example.Expected == nil {
+			// null, like an empty cell, sets no expectations
+			example.Expected = map[string]interface{}{}
+		}
+	}
+	for field, i := range columns.fields {
+		if value := cell(i); value != "" {
+			example.Expected[field] = cellValue(value)
+		}
+	}
+	return example, nil
+}
+
+// cellValue decodes JSON literals such as true, 65 or ["a","b"] and keeps
+// anything else as a plain string, so labels need no quoting.
+func cellValue(value string) interface{} {
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(value), &decoded); err == nil {
+		return decoded
+	}
+	return value
+}
@@ -0,0 +1,156 @@
+// Package dataset loads training corpora from files. Three layouts are
+// supported, chosen by the path:
+//
+//   - a .jsonl (or .ndjson) file with one TrainingExample object per line
+//   - a .csv file with a header row, mapped to example fields by a
+//     ColumnMapping
+//   - a directory of .txt files, each with an optional sidecar
+//     <name>.expected.json holding its task_type, difficulty and expected
+//     fields
+//
+// Every record is validated. Invalid records are skipped and reported
+// together in a *LoadError, so one typo does not hide the rest of a corpus.
+package dataset
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"textlib-rl-system/internal/rl"
+)
+
+const (
+	FormatJSONL     = "jsonl"
+	FormatCSV       = "csv"
+	FormatDirectory = "directory"
+
+	// DefaultTaskType is used for records that do not name a task.
+	DefaultTaskType = "comprehensive"
+)
+
+// RecordError describes why one record was rejected. Record is the line the
+// record starts on for JSONL and CSV, and 0 for text files, whose Source
+// already names the record.
+type RecordError struct {
+	Source string
+	Record int
+	Err    error
+}
+
+func (e RecordError) Error() string {
+	if e.Record > 0 {
+		return fmt.Sprintf("%s:%d: %v", e.Source, e.Record, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Source, e.Err)
+}
+
+// LoadError lists every rejected record of a load.
+type LoadError struct {
+	Records []RecordError
+}
+
+func (e *LoadError) Error() string {
+	if len(e.Records) == 1 {
+		return e.Records[0].Error()
+	}
+	return fmt.Sprintf("%d invalid records, first: %v", len(e.Records), e.Records[0])
+}
+
+// Options configures Load. Columns is only used for CSV.
+type Options struct {
+	Columns ColumnMapping
+}
+
+// Load reads the corpus at path. It returns the valid examples and, if any
+// record was rejected, a *LoadError listing them. Other errors, such as an
+// unreadable file or an unsupported format, return no examples.
+func Load(path string, options Options) ([]rl.TrainingExample, error) {
+	format, err := DetectFormat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var examples []rl.TrainingExample
+	var rejected []RecordError
+	switch format {
+	case FormatJSONL:
+		examples, rejected, err = loadJSONL(path)
+	case FormatCSV:
+		examples, rejected, err = loadCSV(path, options.Columns)
+	case FormatDirectory:
+		examples, rejected, err = loadDirectory(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rejected = append(rejected, rejectDuplicates(path, &examples)...)
+	if len(rejected) > 0 {
+		return examples, &LoadError{Records: rejected}
+	}
+	return examples, nil
+}
+
+// DetectFormat picks the loader for path from its extension, or the
+// directory loader for directories.
+func DetectFormat(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return FormatDirectory, nil
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".ndjson":
+		return FormatJSONL, nil
+	case ".csv":
+		return FormatCSV, nil
+	}
+	return "", fmt.Errorf("unsupported data file %s: expected .jsonl, .csv or a directory", path)
+}
+
+// Validate applies defaults and checks one example against the schema: text
+// is required, difficulty must be in [0, 1] and ground-truth fields must
+// have the shape the scorer compares against.
+func Validate(example *rl.TrainingExample) error {
+	if strings.TrimSpace(example.Text) == "" {
+		return fmt.Errorf("text is required")
+	}
+	if example.TaskType == "" {
+		example.TaskType = DefaultTaskType
+	}
+	if example.Difficulty < 0 || example.Difficulty > 1 {
+		return fmt.Errorf("difficulty must be between 0 and 1, got %g", example.Difficulty)
+	}
+	if example.Expected == nil {
+		example.Expected = map[string]interface{}{}
+	}
+	return rl.ValidateExpected(example.Expected)
+}
+
+// rejectDuplicates drops every example whose ID was already seen; the first
+// occurrence wins.
+func rejectDuplicates(source string, examples *[]rl.TrainingExample) []RecordError {
+	var rejected []RecordError
+	seen := make(map[string]bool, len(*examples))
+	kept := (*examples)[:0]
+	for _, example := range *examples {
+		if seen[example.ID] {
+			rejected = append(rejected, RecordError{Source: source, Err: fmt.Errorf("duplicate id %q", example.ID)})
+			continue
+		}
+		seen[example.ID] = true
+		kept = append(kept, example)
+	}
+	*examples = kept
+	return rejected
+}
+
+// defaultID names records that carry no ID after their position.
+func defaultID(path string, record int) string {
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return fmt.Sprintf("%s_%d", base, record)
+}
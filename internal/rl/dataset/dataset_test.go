@@ -0,0 +1,174 @@
+package dataset
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func recordErrors(t *testing.T, err error) []string {
+	t.Helper()
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) {
+		t.Fatalf("Expected a *LoadError, got %v", err)
+	}
+	messages := make([]string, len(loadErr.Records))
+	for i, record := range loadErr.Records {
+		messages[i] = record.Error()
+	}
+	return messages
+}
+
+func TestLoad_JSONL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "corpus.jsonl")
+	writeFile(t, path, strings.Join([]string{
+		`{"id":"a","text":"Redis uses RDB.","task_type":"technical_analysis","difficulty":0.6,"expected":{"entities":["Redis","RDB"],"has_code":false}}`,
+		``,
+		`{"text":"No id or task here."}`,
+		`{"id":"b","text":"Bad difficulty.","difficulty":1.5}`,
+		`{"id":"c","txt":"Misspelled field."}`,
+		`{"id":"d","text":"Wrong shape.","expected":{"readability_score":"high"}}`,
+		`{"id":"a","text":"Duplicate id."}`,
+		`not json`,
+	}, "\n"))
+
+	examples, err := Load(path, Options{})
+	if len(examples) != 2 {
+		t.Fatalf("Expected 2 valid examples, got %d", len(examples))
+	}
+	if examples[0].Expected["entities"].([]interface{})[1] != "RDB" || examples[0].Difficulty != 0.6 {
+		t.Errorf("Expected fields decoded, got %+v", examples[0])
+	}
+	if examples[1].ID != "corpus_3" || examples[1].TaskType != DefaultTaskType {
+		t.Errorf("Expected a positional id and the default task, got %+v", examples[1])
+	}
+
+	messages := recordErrors(t, err)
+	want := []string{":4: difficulty must be between 0 and 1", `:5: invalid JSON: json: unknown field "txt"`,
+		":6: expected.readability_score must be a number", ":8: invalid JSON", `duplicate id "a"`}
+	if len(messages) != len(want) {
+		t.Fatalf("Expected %d record errors, got %v", len(want), messages)
+	}
+	for i, fragment := range want {
+		if !strings.Contains(messages[i], fragment) {
+			t.Errorf("Expected error %d to contain %q, got %q", i, fragment, messages[i])
+		}
+	}
+}
+
+func TestLoad_CSVWithMapping(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "corpus.csv")
+	writeFile(t, path, `name,body,category,level,expected.has_code,expected.keywords,expected.sentiment
+one,"Multi-line
+text, with a comma.",code_analysis,0.7,true,"[""loop"",""fibonacci""]",positive
+two,,news_analysis,0.2,,,
+three,Some text.,news_analysis,hard,,,
+`)
+
+	mapping, err := ParseColumnMapping("id=name, text=body,task_type=category,difficulty=level")
+	if err != nil {
+		t.Fatalf("ParseColumnMapping returned error: %v", err)
+	}
+	examples, err := Load(path, Options{Columns: mapping})
+	if len(examples) != 1 {
+		t.Fatalf("Expected 1 valid example, got %d", len(examples))
+	}
+	want := map[string]interface{}{
+		"has_code":  true,
+		"keywords":  []interface{}{"loop", "fibonacci"},
+		"sentiment": "positive",
+	}
+	example := examples[0]
+	if example.ID != "one" || example.TaskType != "code_analysis" || example.Difficulty != 0.7 ||
+		!strings.Contains(example.Text, "\n") || !reflect.DeepEqual(example.Expected, want) {
+		t.Errorf("Expected the mapped row, got %+v", example)
+	}
+
+	messages := recordErrors(t, err)
+	if len(messages) != 2 || !strings.Contains(messages[0], ":4: text is required") ||
+		!strings.Contains(messages[1], `:5: difficulty "hard" is not a number`) {
+		t.Errorf("Expected errors on the lines rows start on, got %v", messages)
+	}
+
+	if _, err := Load(path, Options{Columns: ColumnMapping{Text: "content"}}); err == nil || !strings.Contains(err.Error(), `"content"`) {
+		t.Errorf("Expected a missing mapped column to fail the load, got %v", err)
+	}
+	if _, err := ParseColumnMapping("body=text"); err == nil {
+		t.Error("Expected an unknown field in the mapping to be rejected")
+	}
+}
+
+func TestLoad_CSVRejectsMalformedRows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "corpus.csv")
+	writeFile(t, path, `id,text,task_type,expected,expected.has_code
+a,Plain text.,news_analysis,null,true
+b,A "bare" quote.,news_analysis,,
+c,Too few fields.,news_analysis
+d,More text.,news_analysis,,
+`)
+
+	examples, err := Load(path, Options{})
+	if len(examples) != 2 || examples[0].ID != "a" || examples[1].ID != "d" {
+		t.Fatalf("Expected rows a and d to load, got %+v", examples)
+	}
+	if want := map[string]interface{}{"has_code": true}; !reflect.DeepEqual(examples[0].Expected, want) {
+		t.Errorf("Expected a null expected cell to keep the expected.* fields, got %v", examples[0].Expected)
+	}
+
+	messages := recordErrors(t, err)
+	if len(messages) != 2 || !strings.Contains(messages[0], ":3: ") || !strings.Contains(messages[0], "quote") ||
+		!strings.Contains(messages[1], ":4: has 3 fields, header has 5") {
+		t.Errorf("Expected the malformed rows to be rejected on their own lines, got %v", messages)
+	}
+}
+
+func TestLoad_DirectoryWithSidecars(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "code", "fib.txt"), "def fib(n): return n")
+	writeFile(t, filepath.Join(root, "code", "fib.expected.json"),
+		`{"task_type":"code_analysis","difficulty":0.7,"expected":{"has_code":true}}`)
+	writeFile(t, filepath.Join(root, "plain.txt"), "Just prose.")
+	writeFile(t, filepath.Join(root, "bad.txt"), "Has a broken sidecar.")
+	writeFile(t, filepath.Join(root, "bad.expected.json"), `{"task":"x"}`)
+	writeFile(t, filepath.Join(root, "notes.md"), "Ignored.")
+
+	examples, err := Load(root, Options{})
+	if len(examples) != 2 {
+		t.Fatalf("Expected 2 valid examples, got %d", len(examples))
+	}
+	if examples[0].ID != "code/fib" || examples[0].TaskType != "code_analysis" || examples[0].Expected["has_code"] != true {
+		t.Errorf("Expected the sidecar applied, got %+v", examples[0])
+	}
+	if examples[1].ID != "plain" || examples[1].TaskType != DefaultTaskType || len(examples[1].Expected) != 0 {
+		t.Errorf("Expected defaults without a sidecar, got %+v", examples[1])
+	}
+
+	messages := recordErrors(t, err)
+	if len(messages) != 1 || !strings.Contains(messages[0], "bad.txt: invalid sidecar bad.expected.json") {
+		t.Errorf("Expected the broken sidecar reported, got %v", messages)
+	}
+}
+
+func TestLoad_UnsupportedFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "corpus.xml")
+	writeFile(t, path, "<corpus/>")
+	if _, err := Load(path, Options{}); err == nil || !strings.Contains(err.Error(), "unsupported data file") {
+		t.Errorf("Expected an unsupported format error, got %v", err)
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.jsonl"), Options{}); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
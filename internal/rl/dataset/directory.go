@@ -0,0 +1,87 @@
+package dataset
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"textlib-rl-system/internal/rl"
+)
+
+const sidecarSuffix = ".expected.json"
+
+// sidecar is the optional <name>.expected.json next to a text file.
+type sidecar struct {
+	TaskType   string                 `json:"task_type"`
+	Difficulty float64                `json:"difficulty"`
+	Expected   map[string]interface{} `json:"expected"`
+}
+
+// loadDirectory reads every .txt file under root, in lexical order. An
+// example's ID is its path relative to root without the extension.
+func loadDirectory(root string) ([]rl.TrainingExample, []RecordError, error) {
+	var examples []rl.TrainingExample
+	var rejected []RecordError
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || filepath.Ext(path) != ".txt" {
+			return nil
+		}
+
+		example, err := loadTextFile(root, path)
+		if err == nil {
+			err = Validate(&example)
+		}
+		if err != nil {
+			rejected = append(rejected, RecordError{Source: path, Err: err})
+			return nil
+		}
+		examples = append(examples, example)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return examples, rejected, nil
+}
+
+func loadTextFile(root, path string) (rl.TrainingExample, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return rl.TrainingExample{}, err
+	}
+	relative, err := filepath.Rel(root, path)
+	if err != nil {
+		return rl.TrainingExample{}, err
+	}
+	base := strings.TrimSuffix(path, ".txt")
+	example := rl.TrainingExample{
+		ID:   filepath.ToSlash(strings.TrimSuffix(relative, ".txt")),
+		Text: string(text),
+	}
+
+	data, err := os.ReadFile(base + sidecarSuffix)
+	if errors.Is(err, fs.ErrNotExist) {
+		return example, nil
+	}
+	if err != nil {
+		return example, err
+	}
+	var meta sidecar
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&meta); err != nil {
+		return example, fmt.Errorf("invalid sidecar %s: %v", filepath.Base(base+sidecarSuffix), err)
+	}
+	example.TaskType = meta.TaskType
+	example.Difficulty = meta.Difficulty
+	example.Expected = meta.Expected
+	return example, nil
+}
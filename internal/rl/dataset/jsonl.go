@@ -0,0 +1,55 @@
+package dataset
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"textlib-rl-system/internal/rl"
+)
+
+// maxLineSize bounds a single JSONL record.
+const maxLineSize = 16 << 20
+
+// loadJSONL reads one TrainingExample per line. Blank lines are skipped and
+// unknown fields are rejected so misspelled keys do not load silently.
+func loadJSONL(path string) ([]rl.TrainingExample, []RecordError, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	var examples []rl.TrainingExample
+	var rejected []RecordError
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var example rl.TrainingExample
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&example); err != nil {
+			rejected = append(rejected, RecordError{Source: path, Record: line, Err: fmt.Errorf("invalid JSON: %v", err)})
+			continue
+		}
+		if example.ID == "" {
+			example.ID = defaultID(path, line)
+		}
+		if err := Validate(&example); err != nil {
+			rejected = append(rejected, RecordError{Source: path, Record: line, Err: err})
+			continue
+		}
+		examples = append(examples, example)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return examples, rejected, nil
+}
@@ -0,0 +1,81 @@
+package rl
+
+import (
+	"textlib-rl-system/internal/logging"
+)
+
+const AgentKindDoubleQ = "double_q"
+
+func init() {
+	RegisterAgent(AgentKindDoubleQ, func(config SystemConfig) Agent {
+		return NewDoubleQLearningAgent(newQLearningAgentFromConfig(config))
+	})
+}
+
+// DoubleQLearningAgent keeps two Q-tables and alternates which one is updated.
+// The updated table picks the greedy next action and the other table values
+// it, which removes the maximization bias a single noisy table has when
+// failures and reward noise inflate getMaxQValue.
+//
+// The embedded QTable is table A; QTableB is the second estimator. Actions are
+// selected greedily on the average of both tables.
+type DoubleQLearningAgent struct {
+	*QLearningAgent
+	QTableB map[string]map[string]float64
+
+	updateCount int
+}
+
+func NewDoubleQLearningAgent(base *QLearningAgent) *DoubleQLearningAgent {
+	return &DoubleQLearningAgent{
+		QLearningAgent: base,
+		QTableB:        make(map[string]map[string]float64),
+	}
+}
+
+// GetQValue returns the average of both estimators.
+func (agent *DoubleQLearningAgent) GetQValue(state State, action Action) float64 {
+	return (agent.tableValue(agent.QTable, state, action) + agent.tableValue(agent.QTableB, state, action)) / 2
+}
+
+func (agent *DoubleQLearningAgent) SelectActionWithMetrics(state State) (Action, logging.ActionMetrics) {
+	action, isExploration := agent.chooseActionBy(state, agent.GetQValue)
+	return action, agent.actionMetrics(state, action, isExploration, agent.GetQValue(state, action))
+}
+
+func (agent *DoubleQLearningAgent) Update(state State, action Action, reward float64, nextState State, done bool) logging.LearningMetrics {
+	updateTable, evalTable := agent.QTable, agent.QTableB
+	if agent.updateCount%2 == 1 {
+		updateTable, evalTable = agent.QTableB, agent.QTable
+	}
+	agent.updateCount++
+
+	nextValue := 0.0
+	if !done {
+		bestNext := agent.selectBestActionBy(nextState, func(s State, a Action) float64 {
+			return agent.tableValue(updateTable, s, a)
+		})
+		nextValue = agent.tableValue(evalTable, nextState, bestNext)
+	}
+
+	agent.moveTableValue(updateTable, state, action, reward+agent.DiscountFactor*nextValue)
+	agent.Exploration.EndStep()
+
+	return agent.learningMetrics()
+}
+
+func (agent *DoubleQLearningAgent) Snapshot() AgentSnapshot {
+	snapshot := agent.snapshotAs(AgentKindDoubleQ)
+	snapshot.Tables["q_b"] = copyQTable(agent.QTableB)
+	snapshot.Hyperparameters["update_count"] = float64(agent.updateCount)
+	return snapshot
+}
+
+func (agent *DoubleQLearningAgent) Restore(snapshot AgentSnapshot) error {
+	if err := agent.restoreAs(AgentKindDoubleQ, snapshot); err != nil {
+		return err
+	}
+	agent.QTableB = copyQTable(snapshot.Tables["q_b"])
+	agent.updateCount = int(snapshot.Hyperparameters["update_count"])
+	return nil
+}
@@ -0,0 +1,85 @@
+package rl
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDoubleQLearningAgent_AlternatesTables(t *testing.T) {
+	agent := NewDoubleQLearningAgent(NewQLearningAgent(1.0, 0.9, 0.0, 0.0, 1.0))
+	state := testState("Double Q alternation text.")
+	action := getDefaultActions()[0]
+
+	agent.Update(state, action, 2.0, state, true)
+	if got := agent.tableValue(agent.QTable, state, action); got != 2.0 {
+		t.Errorf("Expected first update to hit table A, got %f", got)
+	}
+	if got := agent.tableValue(agent.QTableB, state, action); got != 0.0 {
+		t.Errorf("Expected table B untouched, got %f", got)
+	}
+
+	agent.Update(state, action, 4.0, state, true)
+	if got := agent.tableValue(agent.QTableB, state, action); got != 4.0 {
+		t.Errorf("Expected second update to hit table B, got %f", got)
+	}
+
+	if got := agent.GetQValue(state, action); got != 3.0 {
+		t.Errorf("Expected averaged Q-value 3.0, got %f", got)
+	}
+
+	selected, metrics := agent.SelectActionWithMetrics(state)
+	if selected.FunctionName != action.FunctionName {
+		t.Errorf("Expected greedy selection of %s, got %s", action.FunctionName, selected.FunctionName)
+	}
+	if metrics.QValue != 3.0 || metrics.ExplorationFlag {
+		t.Errorf("Expected averaged Q-value 3.0 without exploration, got %f (explored=%v)", metrics.QValue, metrics.ExplorationFlag)
+	}
+}
+
+func TestDoubleQLearningAgent_DecoupledBackup(t *testing.T) {
+	agent := NewDoubleQLearningAgent(NewQLearningAgent(1.0, 1.0, 0.0, 0.0, 1.0))
+	state := testState("Double Q backup text.")
+	nextState := state
+	nextState.StepCount = 1
+	nextState.ActionsUsed = []string{"extract_entities"}
+	actions := getDefaultActions()
+
+	// Table A overrates summarize_text; table B knows it is worth little
+	nextKey := agent.getStateKey(nextState)
+	agent.QTable[nextKey] = map[string]float64{agent.getActionKey(actions[5]): 10.0}
+	agent.QTableB[nextKey] = map[string]float64{agent.getActionKey(actions[5]): 1.0}
+
+	agent.Update(state, actions[0], 0.0, nextState, false)
+
+	if got := agent.tableValue(agent.QTable, state, actions[0]); math.Abs(got-1.0) > 1e-9 {
+		t.Errorf("Expected backup valued by table B (1.0), got %f", got)
+	}
+}
+
+func TestDoubleQLearningAgent_SnapshotRestore(t *testing.T) {
+	agent := NewDoubleQLearningAgent(NewQLearningAgent(0.5, 0.9, 0.1, 0.01, 0.99))
+	state := testState("Double Q snapshot text.")
+	action := getDefaultActions()[4]
+	agent.Update(state, action, 1.0, state, false)
+	agent.Update(state, action, 3.0, state, false)
+	agent.Update(state, action, 5.0, state, false)
+
+	snapshot := agent.Snapshot()
+	if snapshot.Kind != AgentKindDoubleQ {
+		t.Errorf("Expected kind %s, got %s", AgentKindDoubleQ, snapshot.Kind)
+	}
+	if _, ok := snapshot.Tables["q_b"]; !ok {
+		t.Fatal("Snapshot is missing the second Q-table")
+	}
+
+	restored := NewDoubleQLearningAgent(NewQLearningAgent(0.1, 0.9, 1.0, 0.01, 0.99))
+	if err := restored.Restore(snapshot); err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	if restored.GetQValue(state, action) != agent.GetQValue(state, action) {
+		t.Error("Restored averaged Q-value does not match")
+	}
+	if restored.updateCount != agent.updateCount {
+		t.Errorf("Expected update count %d, got %d", agent.updateCount, restored.updateCount)
+	}
+}
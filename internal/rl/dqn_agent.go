@@ -0,0 +1,444 @@
+package rl
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strconv"
+	"strings"
+
+	"textlib-rl-system/internal/logging"
+	"textlib-rl-system/internal/rl/replay"
+)
+
+const AgentKindDQN = "dqn"
+
+// taskHashBuckets is how many input units task types are hashed into. The
+// set of task types is open, so a fixed-width network cannot one-hot them.
+const taskHashBuckets = 8
+
+func init() {
+	RegisterAgent(AgentKindDQN, func(config SystemConfig) Agent {
+		return NewDQNAgent(newQLearningAgentFromConfig(config), dqnConfigFrom(config))
+	})
+}
+
+func dqnConfigFrom(config SystemConfig) DQNConfig {
+	config = withAgentDefaults(config)
+	return DQNConfig{
+		HiddenSizes:        config.HiddenSizes,
+		LearningRate:       config.NetworkLearningRate,
+		ReplayCapacity:     config.ReplayCapacity,
+		ReplaySampling:     config.ReplaySampling,
+		PriorityAlpha:      config.PriorityAlpha,
+		PriorityBeta:       config.PriorityBeta,
+		BatchSize:          config.BatchSize,
+		TargetSyncInterval: config.TargetSyncInterval,
+		Seed:               config.Seed,
+	}
+}
+
+type DQNConfig struct {
+	HiddenSizes        []int
+	LearningRate       float64
+	ReplayCapacity     int
+	ReplaySampling     string
+	PriorityAlpha      *float64 // nil keeps the replay default
+	PriorityBeta       *float64
+	BatchSize          int
+	TargetSyncInterval int
+	// Seed initializes the network weights and drives replay sampling
+	Seed int64
+}
+
+// replayConfig maps the agent settings onto the replay package, keeping its
+// defaults for anything left unset.
+func (config DQNConfig) replayConfig() replay.Config {
+	replayConfig := replay.DefaultConfig(config.ReplayCapacity)
+	if config.ReplaySampling != "" {
+		replayConfig.Sampling = config.ReplaySampling
+	}
+	if config.PriorityAlpha != nil {
+		replayConfig.Alpha = *config.PriorityAlpha
+	}
+	if config.PriorityBeta != nil {
+		replayConfig.Beta = *config.PriorityBeta
+	}
+	return replayConfig
+}
+
+// newReplayBuffer builds the agent's buffer sampling from rng, falling back
+// to uniform replay for settings NewAgent would have rejected.
+func (config DQNConfig) newReplayBuffer(rng *Random) *replay.Buffer[dqnTransition] {
+	replayConfig := config.replayConfig()
+	if err := replay.Validate(replayConfig); err != nil {
+		replayConfig = replay.DefaultConfig(config.ReplayCapacity)
+	}
+	if rng != nil {
+		replayConfig.Rand = rng.Rand
+	}
+	buffer, _ := replay.New[dqnTransition](replayConfig)
+	return buffer
+}
+
+// DQNAgent approximates Q with an MLP that outputs one value per action. It
+// learns from minibatches drawn from an experience replay buffer against a
+// target network that is synced every TargetSyncInterval updates, minimizing
+// the Huber loss of the TD error. With prioritized replay the gradient of each
+// sample is scaled by its importance-sampling weight and its priority is reset
+// to the new TD error.
+//
+// The embedded QLearningAgent supplies action enumeration, exploration and the
+// featurizer; its QTable and LearningRate are unused.
+type DQNAgent struct {
+	*QLearningAgent
+	Online *MLP
+	Target *MLP
+	Config DQNConfig
+
+	replay        *replay.Buffer[dqnTransition]
+	replayRand    *Random // drives replay sampling
+	actionKeys    []string
+	actionIndex   map[string]int // output unit by action key
+	functionIndex map[string]int // state flag slot by function name
+	updateCount   int
+}
+
+// dqnTransition is one replayed step. Fields are exported so checkpoints can
+// save the buffer.
+type dqnTransition struct {
+	State     []float64 `json:"state"`
+	Action    int       `json:"action"`
+	Reward    float64   `json:"reward"`
+	NextState []float64 `json:"next_state"`
+	NextMask  []bool    `json:"next_mask"` // actions available in NextState, by output index
+	Done      bool      `json:"done"`
+}
+
+func NewDQNAgent(base *QLearningAgent, config DQNConfig) *DQNAgent {
+	if config.BatchSize < 1 {
+		config.BatchSize = 1
+	}
+	if config.TargetSyncInterval < 1 {
+		config.TargetSyncInterval = 1
+	}
+	if config.ReplayCapacity < 1 {
+		config.ReplayCapacity = 1
+	}
+	agent := &DQNAgent{
+		QLearningAgent: base,
+		Config:         config,
+		replayRand:     newRand(config.Seed, "replay"),
+		actionIndex:    make(map[string]int),
+		functionIndex:  make(map[string]int),
+	}
+	agent.replay = config.newReplayBuffer(agent.replayRand)
+	for _, action := range agent.actionSpace().Actions {
+		key := agent.getActionKey(action)
+		agent.actionIndex[key] = len(agent.actionKeys)
+		agent.actionKeys = append(agent.actionKeys, key)
+		if _, exists := agent.functionIndex[action.FunctionName]; !exists {
+			agent.functionIndex[action.FunctionName] = len(agent.functionIndex)
+		}
+	}
+
+	sizes := append([]int{agent.inputSize()}, config.HiddenSizes...)
+	sizes = append(sizes, len(agent.actionKeys))
+	agent.Online = NewMLP(sizes, newRand(config.Seed, "network"))
+	agent.Target = agent.Online.Clone()
+	return agent
+}
+
+func (agent *DQNAgent) inputSize() int {
+	// length buckets, code, math, entity density, budget, then used/result/last
+	// flags per function and the hashed task type
+	return 4 + 4 + 3*len(agent.functionIndex) + taskHashBuckets
+}
+
+// stateVector encodes the featurizer output as a fixed-width network input.
+func (agent *DQNAgent) stateVector(state State) []float64 {
+	features := agent.featurizer().Featurize(state)
+	vector := make([]float64, agent.inputSize())
+
+	switch features.LengthBucket {
+	case LengthShort:
+		vector[0] = 1
+	case LengthMedium:
+		vector[1] = 1
+	case LengthLong:
+		vector[2] = 1
+	default:
+		vector[3] = 1
+	}
+	if features.CodePresence {
+		vector[4] = 1
+	}
+	if features.MathPresence {
+		vector[5] = 1
+	}
+	vector[6] = math.Min(1.0, features.EntityDensity)
+	vector[7] = math.Max(0.0, math.Min(1.0, float64(features.RemainingBudget)/50.0))
+
+	offset := 8
+	n := len(agent.functionIndex)
+	for _, name := range features.ActionsUsed {
+		if i, exists := agent.functionIndex[name]; exists {
+			vector[offset+i] = 1
+		}
+	}
+	for _, name := range features.ResultsPresent {
+		if i, exists := agent.functionIndex[name]; exists {
+			vector[offset+n+i] = 1
+		}
+	}
+	if i, exists := agent.functionIndex[features.LastAction]; exists {
+		vector[offset+2*n+i] = 1
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(features.TaskType))
+	vector[offset+3*n+int(hash.Sum32()%taskHashBuckets)] = 1
+
+	return vector
+}
+
+func (agent *DQNAgent) GetQValue(state State, action Action) float64 {
+	index, exists := agent.actionIndex[agent.getActionKey(action)]
+	if !exists {
+		return 0.0
+	}
+	return agent.Online.Forward(agent.stateVector(state))[index]
+}
+
+func (agent *DQNAgent) SelectActionWithMetrics(state State) (Action, logging.ActionMetrics) {
+	action, isExploration := agent.chooseActionBy(state, agent.GetQValue)
+	return action, agent.actionMetrics(state, action, isExploration, agent.GetQValue(state, action))
+}
+
+// Update stores the transition and, once the buffer holds a full batch,
+// trains the online network on one sampled minibatch.
+func (agent *DQNAgent) Update(state State, action Action, reward float64, nextState State, done bool) logging.LearningMetrics {
+	metrics := agent.learningMetrics()
+	defer agent.Exploration.EndStep()
+
+	index, exists := agent.actionIndex[agent.getActionKey(action)]
+	if !exists {
+		return metrics
+	}
+	agent.replay.Add(dqnTransition{
+		State:     agent.stateVector(state),
+		Action:    index,
+		Reward:    reward,
+		NextState: agent.stateVector(nextState),
+		NextMask:  agent.availableMask(nextState),
+		Done:      done,
+	})
+	if agent.replay.Len() < agent.Config.BatchSize {
+		return metrics
+	}
+
+	batch := agent.replay.Sample(agent.Config.BatchSize)
+	tdErrors := agent.trainBatch(batch.Items, batch.Weights)
+	agent.replay.UpdatePriorities(batch.Indices, tdErrors)
+	metrics.Loss, metrics.TDError = batchLoss(tdErrors)
+
+	agent.updateCount++
+	if agent.updateCount%agent.Config.TargetSyncInterval == 0 {
+		agent.Target.CopyFrom(agent.Online)
+	}
+	return metrics
+}
+
+// trainBatch takes one gradient step on the batch, weighting each sample's
+// gradient, and returns the TD errors from before the step.
+func (agent *DQNAgent) trainBatch(batch []dqnTransition, weights []float64) []float64 {
+	grads := agent.Online.newGradients()
+	tdErrors := make([]float64, len(batch))
+
+	for i, transition := range batch {
+		target := transition.Reward
+		if !transition.Done {
+			target += agent.DiscountFactor * maxMasked(agent.Target.Forward(transition.NextState), transition.NextMask)
+		}
+
+		activations := agent.Online.forwardTrace(transition.State)
+		output := activations[len(activations)-1]
+		tdError := target - output[transition.Action]
+
+		outputGrad := make([]float64, len(output))
+		outputGrad[transition.Action] = -weights[i] * huberGradient(tdError)
+		agent.Online.backward(activations, outputGrad, grads)
+
+		tdErrors[i] = tdError
+	}
+
+	agent.Online.apply(grads, agent.Config.LearningRate, len(batch))
+	return tdErrors
+}
+
+// availableMask flags the network outputs whose actions are available in state.
+func (agent *DQNAgent) availableMask(state State) []bool {
+	mask := make([]bool, len(agent.actionKeys))
+	for _, action := range agent.getAvailableActions(state) {
+		if index, exists := agent.actionIndex[agent.getActionKey(action)]; exists {
+			mask[index] = true
+		}
+	}
+	return mask
+}
+
+// batchLoss returns the mean Huber loss and mean absolute TD error.
+func batchLoss(tdErrors []float64) (float64, float64) {
+	totalLoss, totalTD := 0.0, 0.0
+	for _, tdError := range tdErrors {
+		totalLoss += huberLoss(tdError)
+		totalTD += math.Abs(tdError)
+	}
+	return totalLoss / float64(len(tdErrors)), totalTD / float64(len(tdErrors))
+}
+
+func huberLoss(tdError float64) float64 {
+	if math.Abs(tdError) <= 1 {
+		return 0.5 * tdError * tdError
+	}
+	return math.Abs(tdError) - 0.5
+}
+
+// huberGradient is the derivative of huberLoss, i.e. the TD error clipped to
+// [-1, 1].
+func huberGradient(tdError float64) float64 {
+	return math.Max(-1, math.Min(1, tdError))
+}
+
+// maxMasked is the largest value whose mask entry is set, or 0 when nothing is
+// available, matching getMaxQValue for a state with no legal actions.
+func maxMasked(values []float64, mask []bool) float64 {
+	best, found := 0.0, false
+	for i, value := range values {
+		if mask[i] && (!found || value > best) {
+			best, found = value, true
+		}
+	}
+	return best
+}
+
+func (agent *DQNAgent) Snapshot() AgentSnapshot {
+	snapshot := snapshotLearner(AgentKindDQN, agent.LearningRate, agent.DiscountFactor, agent.Exploration,
+		map[string]map[string]map[string]float64{})
+	snapshot.Hyperparameters["network_learning_rate"] = agent.Config.LearningRate
+	snapshot.Hyperparameters["batch_size"] = float64(agent.Config.BatchSize)
+	snapshot.Hyperparameters["replay_capacity"] = float64(agent.Config.ReplayCapacity)
+	replayConfig := agent.Config.replayConfig()
+	snapshot.Hyperparameters["priority_alpha"] = replayConfig.Alpha
+	snapshot.Hyperparameters["priority_beta"] = replayConfig.Beta
+	snapshot.Hyperparameters["target_sync_interval"] = float64(agent.Config.TargetSyncInterval)
+	snapshot.Hyperparameters["update_count"] = float64(agent.updateCount)
+	snapshot.Options["state_featurizer"] = agent.featurizer().Name()
+	snapshot.Options["actions"] = strings.Join(agent.actionKeys, ",")
+	snapshot.Options["hidden_sizes"] = joinInts(agent.Config.HiddenSizes)
+	snapshot.Options["replay_sampling"] = agent.replay.Sampling()
+	saveRandom(&snapshot, "replay_rng", agent.replayRand)
+
+	snapshot.Weights = make(map[string][]float64)
+	agent.Online.saveWeights("online", snapshot.Weights)
+	agent.Target.saveWeights("target", snapshot.Weights)
+	return snapshot
+}
+
+// Restore loads network parameters into an agent with the same architecture.
+// The replay buffer is not part of the snapshot and starts empty; checkpoints
+// refill it with RestoreExperience.
+func (agent *DQNAgent) Restore(snapshot AgentSnapshot) error {
+	if name, exists := snapshot.Options["state_featurizer"]; exists && name != agent.featurizer().Name() {
+		return fmt.Errorf("snapshot features come from featurizer %s, agent uses %s", name, agent.featurizer().Name())
+	}
+	if actions := snapshot.Options["actions"]; actions != strings.Join(agent.actionKeys, ",") {
+		return fmt.Errorf("snapshot outputs actions %q, agent has %q", actions, strings.Join(agent.actionKeys, ","))
+	}
+	hidden, err := splitInts(snapshot.Options["hidden_sizes"])
+	if err != nil {
+		return fmt.Errorf("invalid hidden_sizes: %w", err)
+	}
+
+	sizes := append([]int{agent.inputSize()}, hidden...)
+	sizes = append(sizes, len(agent.actionKeys))
+	online, target := NewMLP(sizes, nil), NewMLP(sizes, nil)
+	if err := online.loadWeights("online", snapshot.Weights); err != nil {
+		return err
+	}
+	if err := target.loadWeights("target", snapshot.Weights); err != nil {
+		return err
+	}
+
+	exploration, err := restoreLearner(AgentKindDQN, snapshot, &agent.LearningRate, &agent.DiscountFactor, agent.Exploration)
+	if err != nil {
+		return err
+	}
+	agent.Exploration = exploration
+	agent.Online, agent.Target = online, target
+	agent.Config.HiddenSizes = hidden
+	if value, exists := snapshot.Hyperparameters["network_learning_rate"]; exists {
+		agent.Config.LearningRate = value
+	}
+	if value, exists := snapshot.Hyperparameters["batch_size"]; exists {
+		agent.Config.BatchSize = int(value)
+	}
+	if value, exists := snapshot.Hyperparameters["target_sync_interval"]; exists {
+		agent.Config.TargetSyncInterval = int(value)
+	}
+	if value, exists := snapshot.Hyperparameters["replay_capacity"]; exists {
+		agent.Config.ReplayCapacity = int(value)
+	}
+	agent.updateCount = int(snapshot.Hyperparameters["update_count"])
+	if sampling, exists := snapshot.Options["replay_sampling"]; exists {
+		agent.Config.ReplaySampling = sampling
+	}
+	if value, exists := snapshot.Hyperparameters["priority_alpha"]; exists {
+		agent.Config.PriorityAlpha = Float64(value)
+	}
+	if value, exists := snapshot.Hyperparameters["priority_beta"]; exists {
+		agent.Config.PriorityBeta = Float64(value)
+	}
+	agent.replayRand = loadRandom(snapshot, "replay_rng", agent.replayRand)
+	agent.replay = agent.Config.newReplayBuffer(agent.replayRand)
+	return nil
+}
+
+// Experience is the replay buffer, for checkpoints.
+func (agent *DQNAgent) Experience() (json.RawMessage, error) {
+	return json.Marshal(agent.replay.State())
+}
+
+// RestoreExperience refills the replay buffer from Experience.
+func (agent *DQNAgent) RestoreExperience(data json.RawMessage) error {
+	var state replay.State[dqnTransition]
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("invalid replay buffer: %w", err)
+	}
+	return agent.replay.Restore(state)
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = strconv.Itoa(value)
+	}
+	return strings.Join(parts, ",")
+}
+
+func splitInts(joined string) ([]int, error) {
+	if joined == "" {
+		return nil, nil
+	}
+	parts := strings.Split(joined, ",")
+	values := make([]int, len(parts))
+	for i, part := range parts {
+		value, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
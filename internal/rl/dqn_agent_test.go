@@ -0,0 +1,163 @@
+package rl
+
+import (
+	"math"
+	"testing"
+
+	"textlib-rl-system/internal/logging"
+)
+
+func TestMLP_GradientMatchesFiniteDifference(t *testing.T) {
+	net := NewMLP([]int{3, 4, 2}, nil)
+	input := []float64{0.5, -0.2, 1.0}
+
+	// Loss is the first output, so the output gradient is [1, 0]
+	grads := net.newGradients()
+	net.backward(net.forwardTrace(input), []float64{1, 0}, grads)
+
+	const eps = 1e-6
+	for l := range net.Weights {
+		for i := range net.Weights[l] {
+			original := net.Weights[l][i]
+			net.Weights[l][i] = original + eps
+			up := net.Forward(input)[0]
+			net.Weights[l][i] = original - eps
+			down := net.Forward(input)[0]
+			net.Weights[l][i] = original
+
+			numeric := (up - down) / (2 * eps)
+			if math.Abs(numeric-grads.weights[l][i]) > 1e-4 {
+				t.Fatalf("layer %d weight %d: analytic %f, numeric %f", l, i, grads.weights[l][i], numeric)
+			}
+		}
+	}
+}
+
+func TestHuber(t *testing.T) {
+	if huberLoss(0.5) != 0.125 || huberLoss(-3) != 2.5 {
+		t.Errorf("Unexpected Huber loss values %f %f", huberLoss(0.5), huberLoss(-3))
+	}
+	if huberGradient(0.5) != 0.5 || huberGradient(-3) != -1 {
+		t.Errorf("Unexpected Huber gradients %f %f", huberGradient(0.5), huberGradient(-3))
+	}
+}
+
+func newTestDQNAgent() *DQNAgent {
+	return NewDQNAgent(NewQLearningAgent(0.1, 0.9, 0.0, 0.0, 1.0), DQNConfig{
+		HiddenSizes:        []int{16},
+		LearningRate:       0.05,
+		ReplayCapacity:     100,
+		BatchSize:          4,
+		TargetSyncInterval: 10,
+	})
+}
+
+func TestDQNAgent_LearnsTerminalReward(t *testing.T) {
+	agent := newTestDQNAgent()
+	state := testState("DQN learning text.")
+	action := getDefaultActions()[0]
+
+	metrics := agent.Update(state, action, 1.0, state, true)
+	if metrics.Loss != 0 {
+		t.Error("Expected no training before the buffer holds a batch")
+	}
+
+	for i := 1; i < agent.Config.BatchSize; i++ {
+		metrics = agent.Update(state, action, 1.0, state, true)
+	}
+	if metrics.TDError == 0 || metrics.Loss == 0 {
+		t.Errorf("Expected loss and TD error once training starts, got %+v", metrics)
+	}
+
+	for i := 0; i < 500; i++ {
+		agent.Update(state, action, 1.0, state, true)
+	}
+	if got := agent.GetQValue(state, action); math.Abs(got-1.0) > 0.05 {
+		t.Errorf("Expected Q to approach 1.0, got %f", got)
+	}
+	if agent.replay.Len() != 100 {
+		t.Errorf("Expected replay buffer capped at 100, got %d", agent.replay.Len())
+	}
+}
+
+func TestDQNAgent_TargetNetworkSync(t *testing.T) {
+	agent := newTestDQNAgent()
+	state := testState("Target sync text.")
+	action := getDefaultActions()[0]
+
+	for i := 0; i < agent.Config.BatchSize; i++ {
+		agent.Update(state, action, 1.0, state, true)
+	}
+	if agent.Target.Forward(agent.stateVector(state))[0] == agent.Online.Forward(agent.stateVector(state))[0] {
+		t.Error("Expected target network to lag the online network between syncs")
+	}
+
+	for agent.updateCount%agent.Config.TargetSyncInterval != 0 {
+		agent.Update(state, action, 1.0, state, true)
+	}
+	if agent.Target.Forward(agent.stateVector(state))[0] != agent.Online.Forward(agent.stateVector(state))[0] {
+		t.Error("Expected target network to match online network after sync")
+	}
+}
+
+func TestDQNAgent_SnapshotRestore(t *testing.T) {
+	// One parameter level keeps the plain actions newTestDQNAgent outputs
+	created, err := NewAgent(SystemConfig{AgentType: AgentKindDQN, HiddenSizes: []int{8, 8}, ParameterLevels: 1})
+	if err != nil {
+		t.Fatalf("NewAgent returned error: %v", err)
+	}
+	agent := created.(*DQNAgent)
+	state := testState("DQN snapshot text.")
+	action := getDefaultActions()[3]
+
+	restored := newTestDQNAgent()
+	if err := restored.Restore(agent.Snapshot()); err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	if restored.GetQValue(state, action) != agent.GetQValue(state, action) {
+		t.Error("Restored network output does not match original")
+	}
+	if len(restored.Config.HiddenSizes) != 2 || restored.Config.BatchSize != 32 {
+		t.Errorf("Expected restored architecture and config, got %+v", restored.Config)
+	}
+
+	snapshot := agent.Snapshot()
+	delete(snapshot.Weights, "target_w1")
+	if err := restored.Restore(snapshot); err == nil {
+		t.Error("Expected error restoring a snapshot with missing layers")
+	}
+}
+
+func TestDQNAgent_PrioritizedReplay(t *testing.T) {
+	if _, err := NewAgent(SystemConfig{AgentType: AgentKindDQN, ReplaySampling: "lifo"}); err == nil {
+		t.Error("Expected error for unknown replay sampling")
+	}
+
+	created, err := NewAgent(SystemConfig{AgentType: AgentKindDQN, ReplaySampling: "proportional", BatchSize: 4, ParameterLevels: 1})
+	if err != nil {
+		t.Fatalf("NewAgent returned error: %v", err)
+	}
+	agent := created.(*DQNAgent)
+	state := testState("Prioritized DQN text.")
+	action := getDefaultActions()[0]
+
+	var metrics logging.LearningMetrics
+	for i := 0; i < 10; i++ {
+		metrics = agent.Update(state, action, 1.0, state, true)
+	}
+	if metrics.TDError == 0 {
+		t.Error("Expected TD error from prioritized training")
+	}
+
+	snapshot := agent.Snapshot()
+	if snapshot.Options["replay_sampling"] != "proportional" {
+		t.Errorf("Expected replay sampling in snapshot, got %q", snapshot.Options["replay_sampling"])
+	}
+	restored := newTestDQNAgent()
+	if err := restored.Restore(snapshot); err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	if restored.replay.Sampling() != "proportional" || restored.replay.Len() != 0 {
+		t.Errorf("Expected an empty proportional buffer after restore, got %s/%d", restored.replay.Sampling(), restored.replay.Len())
+	}
+}
@@ -0,0 +1,181 @@
+package rl
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"textlib-rl-system/internal/logging"
+)
+
+const AgentKindDynaQ = "dyna_q"
+
+// dynaOutcomesPerPair bounds how many observed outcomes the model keeps for a
+// state-action pair. Simulated calls succeed or fail at random, so the model
+// samples from recent outcomes rather than replaying only the last one.
+const dynaOutcomesPerPair = 10
+
+func init() {
+	RegisterAgent(AgentKindDynaQ, func(config SystemConfig) Agent {
+		agent := NewDynaQAgent(newQLearningAgentFromConfig(config), *withAgentDefaults(config).PlanningSteps)
+		agent.Rand = newRand(config.Seed, "planning")
+		return agent
+	})
+}
+
+// DynaQAgent is tabular Q-learning plus a learned model of the environment.
+// Every real step updates Q directly, records the outcome in the model, and
+// then performs PlanningSteps further Q updates on outcomes replayed from the
+// model, so each slow simulator call is worth PlanningSteps+1 updates.
+//
+// The model is not part of the snapshot; checkpoints save it through
+// Experience.
+type DynaQAgent struct {
+	*QLearningAgent
+	PlanningSteps int
+	// Rand picks the outcomes replayed while planning; nil uses math/rand
+	Rand *Random
+
+	model           map[string]map[string]*dynaEntry
+	visited         []*dynaEntry
+	realUpdates     int
+	planningUpdates int
+}
+
+// dynaEntry holds the recent outcomes of one state-action pair. Fields are
+// exported so checkpoints can save the model.
+type dynaEntry struct {
+	StateKey  string        `json:"state"`
+	ActionKey string        `json:"action"`
+	Outcomes  []dynaOutcome `json:"outcomes"`
+	Next      int           `json:"next"` // slot the next outcome overwrites once full
+}
+
+// dynaOutcome records where a step led by the next state's key and the keys
+// of the actions available there, which is all a backup needs.
+type dynaOutcome struct {
+	Reward      float64  `json:"reward"`
+	NextState   string   `json:"next_state"`
+	NextActions []string `json:"next_actions"`
+	Done        bool     `json:"done"`
+}
+
+func NewDynaQAgent(base *QLearningAgent, planningSteps int) *DynaQAgent {
+	if planningSteps < 0 {
+		planningSteps = 0
+	}
+	return &DynaQAgent{
+		QLearningAgent: base,
+		PlanningSteps:  planningSteps,
+		model:          make(map[string]map[string]*dynaEntry),
+	}
+}
+
+func (agent *DynaQAgent) Update(state State, action Action, reward float64, nextState State, done bool) logging.LearningMetrics {
+	stateKey := agent.getStateKey(state)
+	actionKey := agent.getActionKey(action)
+	outcome := dynaOutcome{Reward: reward, NextState: agent.getStateKey(nextState), Done: done}
+	for _, next := range agent.getAvailableActions(nextState) {
+		outcome.NextActions = append(outcome.NextActions, agent.getActionKey(next))
+	}
+
+	agent.backup(stateKey, actionKey, outcome)
+	agent.realUpdates++
+	agent.record(stateKey, actionKey, outcome)
+
+	for i := 0; i < agent.PlanningSteps; i++ {
+		entry := agent.visited[randIntn(agent.Rand, len(agent.visited))]
+		agent.backup(entry.StateKey, entry.ActionKey, entry.Outcomes[randIntn(agent.Rand, len(entry.Outcomes))])
+		agent.planningUpdates++
+	}
+
+	agent.Exploration.EndStep()
+
+	metrics := agent.learningMetrics()
+	metrics.RealUpdates = agent.realUpdates
+	metrics.PlanningUpdates = agent.planningUpdates
+	return metrics
+}
+
+func (agent *DynaQAgent) backup(stateKey, actionKey string, outcome dynaOutcome) {
+	target := outcome.Reward
+	if !outcome.Done && len(outcome.NextActions) > 0 {
+		values := agent.QTable[outcome.NextState]
+		best := values[outcome.NextActions[0]]
+		for _, actionKey := range outcome.NextActions[1:] {
+			best = math.Max(best, values[actionKey])
+		}
+		target += agent.DiscountFactor * best
+	}
+	agent.moveKeyValue(agent.QTable, stateKey, actionKey, target)
+}
+
+func (agent *DynaQAgent) record(stateKey, actionKey string, outcome dynaOutcome) {
+	if agent.model[stateKey] == nil {
+		agent.model[stateKey] = make(map[string]*dynaEntry)
+	}
+	entry := agent.model[stateKey][actionKey]
+	if entry == nil {
+		entry = &dynaEntry{StateKey: stateKey, ActionKey: actionKey}
+		agent.model[stateKey][actionKey] = entry
+		agent.visited = append(agent.visited, entry)
+	}
+
+	if len(entry.Outcomes) < dynaOutcomesPerPair {
+		entry.Outcomes = append(entry.Outcomes, outcome)
+		return
+	}
+	entry.Outcomes[entry.Next] = outcome
+	entry.Next = (entry.Next + 1) % dynaOutcomesPerPair
+}
+
+func (agent *DynaQAgent) Snapshot() AgentSnapshot {
+	snapshot := agent.snapshotAs(AgentKindDynaQ)
+	snapshot.Hyperparameters["planning_steps"] = float64(agent.PlanningSteps)
+	snapshot.Hyperparameters["real_updates"] = float64(agent.realUpdates)
+	snapshot.Hyperparameters["planning_updates"] = float64(agent.planningUpdates)
+	saveRandom(&snapshot, "planning_rng", agent.Rand)
+	return snapshot
+}
+
+func (agent *DynaQAgent) Restore(snapshot AgentSnapshot) error {
+	if err := agent.restoreAs(AgentKindDynaQ, snapshot); err != nil {
+		return err
+	}
+	if steps, exists := snapshot.Hyperparameters["planning_steps"]; exists {
+		agent.PlanningSteps = int(math.Max(0, steps))
+	}
+	agent.realUpdates = int(snapshot.Hyperparameters["real_updates"])
+	agent.planningUpdates = int(snapshot.Hyperparameters["planning_updates"])
+	agent.Rand = loadRandom(snapshot, "planning_rng", agent.Rand)
+	agent.model = make(map[string]map[string]*dynaEntry)
+	agent.visited = nil
+	return nil
+}
+
+// Experience is the learned model, as its entries in the order they were
+// first visited, which is the order planning draws them by.
+func (agent *DynaQAgent) Experience() (json.RawMessage, error) {
+	return json.Marshal(agent.visited)
+}
+
+// RestoreExperience replaces the model with one saved by Experience.
+func (agent *DynaQAgent) RestoreExperience(data json.RawMessage) error {
+	var visited []*dynaEntry
+	if err := json.Unmarshal(data, &visited); err != nil {
+		return fmt.Errorf("invalid Dyna-Q model: %w", err)
+	}
+	model := make(map[string]map[string]*dynaEntry)
+	for _, entry := range visited {
+		if entry == nil || len(entry.Outcomes) == 0 || len(entry.Outcomes) > dynaOutcomesPerPair ||
+			entry.Next < 0 || entry.Next >= dynaOutcomesPerPair {
+			return fmt.Errorf("invalid Dyna-Q model entry")
+		}
+		if model[entry.StateKey] == nil {
+			model[entry.StateKey] = make(map[string]*dynaEntry)
+		}
+		model[entry.StateKey][entry.ActionKey] = entry
+	}
+	agent.model, agent.visited = model, visited
+	return nil
+}
@@ -0,0 +1,73 @@
+package rl
+
+import (
+	"testing"
+)
+
+func TestDynaQAgent_PlanningPropagatesReward(t *testing.T) {
+	states := stepStates("Dyna planning text.", 3)
+	actions := getDefaultActions()
+	action := actions[0]
+
+	run := func(planningSteps int) *DynaQAgent {
+		agent := NewDynaQAgent(NewQLearningAgent(0.5, 0.9, 0.0, 0.0, 1.0), planningSteps)
+		agent.Update(states[0], actions[0], 0.0, states[1], false)
+		agent.Update(states[1], actions[1], 1.0, states[2], true)
+		return agent
+	}
+
+	if got := run(0).GetQValue(states[0], action); got != 0.0 {
+		t.Errorf("Expected no propagation without planning, got %f", got)
+	}
+	if got := run(50).GetQValue(states[0], action); got <= 0.0 {
+		t.Errorf("Expected planning to propagate the reward back to s0, got %f", got)
+	}
+}
+
+func TestDynaQAgent_Metrics(t *testing.T) {
+	agent := NewDynaQAgent(NewQLearningAgent(0.5, 0.9, 0.0, 0.0, 1.0), 5)
+	state := testState("Dyna metrics text.")
+	action := getDefaultActions()[1]
+
+	var realUpdates, planningUpdates int
+	for i := 0; i < 3; i++ {
+		metrics := agent.Update(state, action, 1.0, state, true)
+		realUpdates, planningUpdates = metrics.RealUpdates, metrics.PlanningUpdates
+	}
+	if realUpdates != 3 || planningUpdates != 15 {
+		t.Errorf("Expected 3 real and 15 planning updates, got %d and %d", realUpdates, planningUpdates)
+	}
+
+	for i := 0; i < 2*dynaOutcomesPerPair; i++ {
+		agent.Update(state, action, float64(i), state, true)
+	}
+	if len(agent.visited) != 1 || len(agent.visited[0].Outcomes) != dynaOutcomesPerPair {
+		t.Errorf("Expected one pair with %d outcomes, got %d pairs", dynaOutcomesPerPair, len(agent.visited))
+	}
+}
+
+func TestDynaQAgent_FromConfigAndSnapshot(t *testing.T) {
+	created, err := NewAgent(SystemConfig{AgentType: AgentKindDynaQ, PlanningSteps: Int(7)})
+	if err != nil {
+		t.Fatalf("NewAgent returned error: %v", err)
+	}
+	agent := created.(*DynaQAgent)
+	if agent.PlanningSteps != 7 {
+		t.Errorf("Expected 7 planning steps, got %d", agent.PlanningSteps)
+	}
+
+	state := testState("Dyna snapshot text.")
+	agent.Update(state, getDefaultActions()[0], 1.0, state, true)
+
+	restored := NewDynaQAgent(NewQLearningAgent(0.1, 0.95, 0.0, 0.0, 1.0), 1)
+	if err := restored.Restore(agent.Snapshot()); err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	if restored.PlanningSteps != 7 || restored.realUpdates != 1 || restored.planningUpdates != 7 {
+		t.Errorf("Expected restored counters, got steps=%d real=%d planning=%d",
+			restored.PlanningSteps, restored.realUpdates, restored.planningUpdates)
+	}
+	if restored.GetQValue(state, getDefaultActions()[0]) != agent.GetQValue(state, getDefaultActions()[0]) {
+		t.Error("Restored Q-value does not match original")
+	}
+}
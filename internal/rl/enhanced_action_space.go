@@ -1,7 +1,7 @@
 package rl
 
 import (
-	"math/rand"
+	"sort"
 )
 
 // Enhanced action space that includes parameter optimization
@@ -58,11 +58,20 @@ type ParameterOptimizer struct {
 	crossoverRate  float64
 	generations    int
 	
-	// Track best parameters for each function
+	// Rand drives the GA; nil uses math/rand
+	Rand *Random
+	
+	// Track best parameters for each function, and its most recent
+	// generations (at most parameterHistoryLimit)
 	bestParameters map[string]map[string]interface{}
 	parameterHistory map[string][]ParameterGeneration
 }
 
+// parameterHistoryLimit bounds the generations kept per function. Every
+// generation holds its whole population, and the history is saved with each
+// checkpoint and model, so it cannot grow with the length of training.
+const parameterHistoryLimit = 20
+
 type ParameterGeneration struct {
 	Generation int                           `json:"generation"`
 	Population []map[string]interface{}     `json:"population"`
@@ -89,6 +98,12 @@ func (po *ParameterOptimizer) OptimizeParameters(functionName string, ranges map
 	// Initialize population
 	population := po.initializePopulation(ranges)
 	
+	// Generations keep counting across repeated runs for the same function
+	offset := 0
+	if history := po.parameterHistory[functionName]; len(history) > 0 {
+		offset = history[len(history)-1].Generation + 1
+	}
+	
 	for generation := 0; generation < po.generations; generation++ {
 		// Evaluate fitness
 		fitness := make([]float64, len(population))
@@ -104,16 +119,18 @@ func (po *ParameterOptimizer) OptimizeParameters(functionName string, ranges map
 			}
 		}
 		
-		// Store generation history
-		po.parameterHistory[functionName] = append(po.parameterHistory[functionName], ParameterGeneration{
-			Generation:  generation,
-			Population:  make([]map[string]interface{}, len(population)),
-			Fitness:     make([]float64, len(fitness)),
+		// Store generation history, dropping the oldest past the limit
+		history := append(po.parameterHistory[functionName], ParameterGeneration{
+			Generation:  offset + generation,
+			Population:  append([]map[string]interface{}(nil), population...),
+			Fitness:     append([]float64(nil), fitness...),
 			BestParams:  copyParams(population[bestIdx]),
 			BestFitness: fitness[bestIdx],
 		})
-		copy(po.parameterHistory[functionName][generation].Population, population)
-		copy(po.parameterHistory[functionName][generation].Fitness, fitness)
+		if len(history) > parameterHistoryLimit {
+			history = append([]ParameterGeneration(nil), history[len(history)-parameterHistoryLimit:]...)
+		}
+		po.parameterHistory[functionName] = history
 		
 		// Create next generation
 		newPopulation := po.evolvePopulation(population, fitness, ranges)
@@ -131,8 +148,8 @@ func (po *ParameterOptimizer) initializePopulation(ranges map[string]ParameterRa
 	
 	for i := 0; i < po.populationSize; i++ {
 		individual := make(map[string]interface{})
-		for paramName, paramRange := range ranges {
-			individual[paramName] = po.generateRandomValue(paramRange)
+		for _, paramName := range sortedParamNames(ranges) {
+			individual[paramName] = po.generateRandomValue(ranges[paramName])
 		}
 		population[i] = individual
 	}
@@ -145,19 +162,19 @@ func (po *ParameterOptimizer) generateRandomValue(paramRange ParameterRange) int
 	case "int":
 		min := paramRange.Min.(int)
 		max := paramRange.Max.(int)
-		return rand.Intn(max-min+1) + min
+		return randIntn(po.Rand, max-min+1) + min
 		
 	case "float":
 		min := paramRange.Min.(float64)
 		max := paramRange.Max.(float64)
-		return min + rand.Float64()*(max-min)
+		return min + randFloat64(po.Rand)*(max-min)
 		
 	case "bool":
-		return rand.Float64() < 0.5
+		return randFloat64(po.Rand) < 0.5
 		
 	case "enum":
 		options := paramRange.Options
-		return options[rand.Intn(len(options))]
+		return options[randIntn(po.Rand, len(options))]
 		
 	default:
 		return paramRange.Default
@@ -194,11 +211,11 @@ func (po *ParameterOptimizer) tournamentSelection(population []map[string]interf
 	fitness []float64) map[string]interface{} {
 	
 	tournamentSize := 3
-	bestIdx := rand.Intn(len(population))
+	bestIdx := randIntn(po.Rand, len(population))
 	bestFitness := fitness[bestIdx]
 	
 	for i := 1; i < tournamentSize; i++ {
-		idx := rand.Intn(len(population))
+		idx := randIntn(po.Rand, len(population))
 		if fitness[idx] > bestFitness {
 			bestIdx = idx
 			bestFitness = fitness[idx]
@@ -213,8 +230,8 @@ func (po *ParameterOptimizer) crossover(parent1, parent2 map[string]interface{},
 	
 	child := make(map[string]interface{})
 	
-	for paramName := range ranges {
-		if rand.Float64() < po.crossoverRate {
+	for _, paramName := range sortedParamNames(ranges) {
+		if randFloat64(po.Rand) < po.crossoverRate {
 			// Take from parent1
 			child[paramName] = parent1[paramName]
 		} else {
@@ -231,9 +248,9 @@ func (po *ParameterOptimizer) mutate(individual map[string]interface{},
 	
 	mutated := copyParams(individual)
 	
-	for paramName, paramRange := range ranges {
-		if rand.Float64() < po.mutationRate {
-			mutated[paramName] = po.generateRandomValue(paramRange)
+	for _, paramName := range sortedParamNames(ranges) {
+		if randFloat64(po.Rand) < po.mutationRate {
+			mutated[paramName] = po.generateRandomValue(ranges[paramName])
 		}
 	}
 	
@@ -275,6 +292,17 @@ func (po *ParameterOptimizer) sortByFitness(fitness []float64) []int {
 	return indices
 }
 
+// sortedParamNames fixes the order parameters are visited in, so a seeded GA
+// makes the same draws for the same parameter every run.
+func sortedParamNames(ranges map[string]ParameterRange) []string {
+	names := make([]string, 0, len(ranges))
+	for name := range ranges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func copyParams(params map[string]interface{}) map[string]interface{} {
 	copy := make(map[string]interface{})
 	for k, v := range params {
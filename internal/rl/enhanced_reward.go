@@ -135,17 +135,24 @@ func (erc *EnhancedRewardCalculator) assessOutputQuality(action Action, result A
 	
 	quality := 0.0
 	
-	// Check against expected outcomes
-	if _, exists := example.Expected[action.FunctionName]; exists {
-		// Compare output with expected (simplified)
-		quality += 0.5
+	// Outputs that answer a field of the example's ground truth earn up to
+	// 1.0 for matching it
+	if score, ok := ScoreOutput(action.FunctionName, result.Output, example.Expected); ok {
+		quality += score.Score
 	}
 	
 	// Assess based on output completeness
 	switch action.FunctionName {
 	case "extract_entities":
 		if entities, ok := outputMap["entities"].([]map[string]interface{}); ok {
-			quality += float64(len(entities)) * 0.1
+			// Confident entities add value, weak ones are mostly noise
+			for _, entity := range entities {
+				if confidence, ok := entity["confidence"].(float64); ok && confidence < 0.6 {
+					quality -= 0.05
+				} else {
+					quality += 0.1
+				}
+			}
 		}
 	case "analyze_readability":
 		if score, ok := outputMap["readability_score"].(float64); ok {
@@ -154,6 +161,16 @@ func (erc *EnhancedRewardCalculator) assessOutputQuality(action Action, result A
 				quality += 0.4
 			}
 		}
+		if _, ok := outputMap["advanced"]; ok {
+			quality += 0.1
+		}
+		if sampled, ok := outputMap["sampled"].(bool); ok && sampled {
+			quality -= 0.1
+		}
+	case "summarize_text":
+		if ratio, ok := outputMap["compression_ratio"].(float64); ok && ratio >= 0.1 && ratio <= 0.6 {
+			quality += 0.4
+		}
 	case "sentiment_analysis":
 		if _, hasScore := outputMap["score"]; hasScore {
 			if _, hasConfidence := outputMap["confidence"]; hasConfidence {
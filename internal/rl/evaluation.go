@@ -0,0 +1,129 @@
+package rl
+
+import (
+	"math"
+)
+
+// EvaluationResult summarizes a greedy, non-learning pass over held-out
+// examples. AverageReward is the mean episode return per example;
+// AverageQuality is the mean ground-truth score of the outputs that could be
+// scored, and 0 when none could.
+type EvaluationResult struct {
+	Split          string  `json:"split"`
+	Episode        int     `json:"episode"`
+	Examples       int     `json:"examples"`
+	AverageReward  float64 `json:"average_reward"`
+	AverageQuality float64 `json:"average_quality"`
+	ScoredOutputs  int     `json:"scored_outputs"`
+	SuccessRate    float64 `json:"success_rate"`
+	AverageSteps   float64 `json:"average_steps"`
+}
+
+// Evaluate runs one greedy episode per example without exploring, updating
+// the agent or logging events. The greedy action is the available action
+// with the highest GetQValue, which every agent implements. Actions run on
+// evaluationExecutor.
+func (system *EnhancedRLSystem) Evaluate(split string, examples []TrainingExample) EvaluationResult {
+	result := EvaluationResult{Split: split, Examples: len(examples)}
+	if len(examples) == 0 {
+		return result
+	}
+
+	executor := system.evaluationExecutor()
+	rewardCalc := NewEnhancedRewardCalculator()
+	totalReward, totalQuality := 0.0, 0.0
+	calls, successes, steps := 0, 0, 0
+	for _, example := range examples {
+		state := system.createInitialState(example)
+		for step := 0; step < system.Config.MaxStepsPerEpisode; step++ {
+			action, ok := system.BestAction(state)
+			if !ok {
+				break
+			}
+			executed := system.applyTunedParameters(action)
+			actionResult := executor.ExecuteAction(executed, state.Text, executed.Parameters)
+			totalReward += rewardCalc.CalculateReward(state, executed, actionResult, example)
+
+			calls++
+			if actionResult.Success {
+				successes++
+			}
+			if score, ok := ScoreOutput(executed.FunctionName, actionResult.Output, example.Expected); ok {
+				totalQuality += score.Score
+				result.ScoredOutputs++
+			}
+
+			state = system.updateState(state, action, actionResult)
+			steps++
+			if system.isTaskComplete(state) {
+				break
+			}
+		}
+	}
+
+	result.AverageReward = totalReward / float64(len(examples))
+	result.AverageSteps = float64(steps) / float64(len(examples))
+	if calls > 0 {
+		result.SuccessRate = float64(successes) / float64(calls)
+	}
+	if result.ScoredOutputs > 0 {
+		result.AverageQuality = totalQuality / float64(result.ScoredOutputs)
+	}
+	return result
+}
+
+// BestAction returns the available action the agent values highest in
+// state, or false when no action is available. It neither explores nor
+// learns, so it is how a trained agent is queried for inference.
+func (system *EnhancedRLSystem) BestAction(state State) (Action, bool) {
+	best, bestValue, found := Action{}, math.Inf(-1), false
+	for _, action := range system.actionSpace.Available(state) {
+		if value := system.Agent.GetQValue(state, action); !found || value > bestValue {
+			best, bestValue, found = action, value, true
+		}
+	}
+	return best, found
+}
+
+// GreedySequence plays one greedy episode on example, as Evaluate does, and
+// returns the calls the agent made.
+func (system *EnhancedRLSystem) GreedySequence(example TrainingExample) []Action {
+	executor := system.evaluationExecutor()
+	var sequence []Action
+	state := system.createInitialState(example)
+	for step := 0; step < system.Config.MaxStepsPerEpisode; step++ {
+		action, ok := system.BestAction(state)
+		if !ok {
+			break
+		}
+		executed := system.applyTunedParameters(action)
+		sequence = append(sequence, executed)
+		state = system.updateState(state, action, executor.ExecuteAction(executed, state.Text, executed.Parameters))
+		if system.isTaskComplete(state) {
+			break
+		}
+	}
+	return sequence
+}
+
+// evaluationExecutor is the executor greedy evaluation runs actions on. On
+// the simulator that is the system's own instant copy, so evaluating neither
+// sleeps nor draws from the training simulator's stream; any other executor
+// is used as is, since its results are what the policy is judged on.
+func (system *EnhancedRLSystem) evaluationExecutor() Executor {
+	if _, isSimulator := system.executor.(*ActionSimulator); isSimulator && system.evaluator != nil {
+		return system.evaluator
+	}
+	return system.executor
+}
+
+// LoadValidationData sets the examples evaluated every LoggingInterval
+// episodes during training.
+func (system *EnhancedRLSystem) LoadValidationData(data []TrainingExample) {
+	system.ValidationData = data
+}
+
+// ValidationHistory returns the periodic validation results so far.
+func (system *EnhancedRLSystem) ValidationHistory() []EvaluationResult {
+	return append([]EvaluationResult(nil), system.validationHistory...)
+}
@@ -0,0 +1,69 @@
+package rl
+
+import (
+	"testing"
+	"time"
+
+	"textlib-rl-system/internal/logging"
+)
+
+func TestEnhancedRLSystem_EvaluateIsGreedyAndReadOnly(t *testing.T) {
+	system := NewEnhancedRLSystem(SystemConfig{MaxStepsPerEpisode: 3})
+	agent := NewQLearningAgent(0.1, 0.9, 1.0, 1.0, 1.0)
+	system.SetAgent(agent)
+
+	example := TrainingExample{ID: "e", Text: "Plain prose without any code.", TaskType: "code_analysis",
+		Expected: map[string]interface{}{"has_code": false}}
+	state := system.createInitialState(example)
+	agent.QTable[agent.getStateKey(state)] = map[string]float64{
+		agent.getActionKey(Action{FunctionName: "detect_code", Category: "analysis", Cost: 2}): 5.0,
+	}
+	before := len(agent.QTable)
+
+	recorder := &recordingExecutor{}
+	system.SetExecutor(recorder)
+	result := system.Evaluate("validation", []TrainingExample{example, example})
+	if recorder.calls[0] != "detect_code" || recorder.calls[len(recorder.calls)/2] != "detect_code" {
+		t.Errorf("Expected the greedy first call despite exploration 1.0, got %v", recorder.calls)
+	}
+	if result.Examples != 2 || result.AverageSteps != 3 || result.SuccessRate != 1 {
+		t.Errorf("Expected 2 examples of 3 successful steps, got %+v", result)
+	}
+	if len(agent.QTable) != before || agent.Exploration.Rate() != 1.0 {
+		t.Error("Expected evaluation to leave the agent untouched")
+	}
+}
+
+func TestEnhancedRLSystem_EvaluateHasItsOwnSimulator(t *testing.T) {
+	system := NewEnhancedRLSystem(SystemConfig{MaxStepsPerEpisode: 5, Seed: 9})
+	training := system.executor.(*ActionSimulator)
+	before := training.Rand.State()
+
+	start := time.Now()
+	system.Evaluate("validation", GetRealisticTrainingData()[:3])
+	if elapsed := time.Since(start); elapsed >= 100*time.Millisecond {
+		t.Errorf("Expected evaluation to skip the simulated latency, took %v", elapsed)
+	}
+	if training.Rand.State() != before {
+		t.Error("Expected evaluation to leave the training simulator's stream alone")
+	}
+	if system.evaluator.Rand.State() == newRand(9, "evaluation").State() {
+		t.Error("Expected evaluation to draw from its own stream")
+	}
+}
+
+func TestEnhancedRLSystem_ValidatesEveryLoggingInterval(t *testing.T) {
+	system := NewEnhancedRLSystem(SystemConfig{MaxEpisodes: 5, MaxStepsPerEpisode: 2, LoggingInterval: 2, CheckpointInterval: 100})
+	simulator := NewActionSimulator()
+	simulator.Instant = true
+	system.SetExecutor(simulator)
+	system.SetLogger(logging.NewInsightLogger(t.TempDir(), 10, time.Second))
+	system.LoadTrainingData(GetRealisticTrainingData()[:3])
+	system.LoadValidationData(GetRealisticTrainingData()[3:5])
+
+	system.TrainWithLogging()
+	history := system.ValidationHistory()
+	if len(history) != 3 || history[0].Episode != 0 || history[2].Episode != 4 || history[1].Examples != 2 {
+		t.Errorf("Expected validation at episodes 0, 2 and 4, got %+v", history)
+	}
+}
@@ -0,0 +1,74 @@
+package rl
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+const (
+	ExecutorSimulator = "simulator"
+)
+
+// Executor runs one action on an input text. The simulator fakes latency,
+// failures and outputs; other executors call the real library and report the
+// measured duration and allocated memory in the ActionResult.
+type Executor interface {
+	Name() string
+	ExecuteAction(action Action, input string, params map[string]interface{}) ActionResult
+}
+
+// ExecutorFactory builds a fresh executor from the system configuration.
+type ExecutorFactory func(config SystemConfig) Executor
+
+var (
+	executorFactoriesMu sync.RWMutex
+	executorFactories   = map[string]ExecutorFactory{
+		ExecutorSimulator: func(config SystemConfig) Executor {
+			return newSimulatorFromConfig(config)
+		},
+	}
+)
+
+// RegisterExecutor makes an executor available to NewExecutor and the
+// --executor flag. Registering an existing name replaces its factory.
+func RegisterExecutor(name string, factory ExecutorFactory) {
+	executorFactoriesMu.Lock()
+	defer executorFactoriesMu.Unlock()
+
+	executorFactories[name] = factory
+}
+
+// NewExecutor builds the executor registered under name, defaulting to the
+// simulator.
+func NewExecutor(name string, config SystemConfig) (Executor, error) {
+	if name == "" {
+		name = ExecutorSimulator
+	}
+
+	executorFactoriesMu.RLock()
+	factory, exists := executorFactories[name]
+	executorFactoriesMu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("unknown executor %q (available: %v)", name, ExecutorNames())
+	}
+	return factory(config), nil
+}
+
+// ExecutorNames lists the registered executors in sorted order.
+func ExecutorNames() []string {
+	executorFactoriesMu.RLock()
+	defer executorFactoriesMu.RUnlock()
+
+	names := make([]string, 0, len(executorFactories))
+	for name := range executorFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (sim *ActionSimulator) Name() string {
+	return ExecutorSimulator
+}
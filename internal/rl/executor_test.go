@@ -0,0 +1,63 @@
+package rl
+
+import (
+	"testing"
+)
+
+type recordingExecutor struct {
+	calls []string
+}
+
+func (executor *recordingExecutor) Name() string {
+	return "recording"
+}
+
+func (executor *recordingExecutor) ExecuteAction(action Action, input string, params map[string]interface{}) ActionResult {
+	executor.calls = append(executor.calls, action.FunctionName)
+	return ActionResult{Success: true, Output: map[string]interface{}{}}
+}
+
+func TestNewExecutor_Registry(t *testing.T) {
+	executor, err := NewExecutor("", SystemConfig{})
+	if err != nil {
+		t.Fatalf("NewExecutor returned error: %v", err)
+	}
+	if executor.Name() != ExecutorSimulator {
+		t.Errorf("Expected the simulator by default, got %s", executor.Name())
+	}
+	if _, err := NewExecutor("missing", SystemConfig{}); err == nil {
+		t.Error("Expected an error for an unknown executor")
+	}
+
+	RegisterExecutor("recording", func(SystemConfig) Executor { return &recordingExecutor{} })
+	defer func() {
+		executorFactoriesMu.Lock()
+		delete(executorFactories, "recording")
+		executorFactoriesMu.Unlock()
+	}()
+	if executor, err := NewExecutor("recording", SystemConfig{}); err != nil || executor.Name() != "recording" {
+		t.Errorf("Expected the registered executor, got %v (%v)", executor, err)
+	}
+}
+
+func TestEnhancedRLSystem_SetExecutor(t *testing.T) {
+	recorder := &recordingExecutor{}
+	system := NewEnhancedRLSystem(SystemConfig{})
+	system.SetExecutor(recorder)
+
+	example := TrainingExample{ID: "a", Text: "Executor routing text.", TaskType: "comprehensive"}
+	result := system.simulateAction(testState(example.Text), Action{FunctionName: "detect_code"}, example)
+	if !result.Success || len(recorder.calls) != 1 || recorder.calls[0] != "detect_code" {
+		t.Errorf("Expected the action routed to the executor, got %v", recorder.calls)
+	}
+
+	trainer, err := NewBanditTrainer(SystemConfig{}, []TrainingExample{example})
+	if err != nil {
+		t.Fatalf("NewBanditTrainer returned error: %v", err)
+	}
+	trainer.SetExecutor(recorder)
+	action, _ := trainer.Step(example)
+	if len(recorder.calls) != 2 || recorder.calls[1] != action.FunctionName {
+		t.Errorf("Expected the bandit arm routed to the executor, got %v", recorder.calls)
+	}
+}
@@ -0,0 +1,380 @@
+package rl
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	ExplorationEpsilonGreedy = "epsilon_greedy"
+	ExplorationBoltzmann     = "boltzmann"
+	ExplorationUCB1          = "ucb1"
+
+	ScheduleExponential = "exponential"
+	ScheduleLinear      = "linear"
+
+	DecayPerStep    = "step"
+	DecayPerEpisode = "episode"
+)
+
+// ExplorationStrategy picks among the available actions of a state given the
+// agent's value estimates. Agents own the values; strategies own everything
+// about how far to trust them, including any per-state statistics.
+type ExplorationStrategy interface {
+	Name() string
+	// Choose returns the index of the selected action and whether the choice
+	// deviated from the greedy one.
+	Choose(stateKey string, actionKeys []string, values []float64) (int, bool)
+	// Probabilities returns the selection distribution without side effects.
+	Probabilities(stateKey string, actionKeys []string, values []float64) []float64
+	// Rate is the current exploration level reported as LearningMetrics.ExplorationRate.
+	Rate() float64
+	EndStep()
+	EndEpisode()
+	SaveState(snapshot *AgentSnapshot)
+	LoadState(snapshot AgentSnapshot)
+}
+
+// ExplorationConfig selects and tunes the exploration strategy. Epsilon-greedy
+// takes its initial, minimum and decay values from SystemConfig's
+// ExplorationRate, MinExploration and DecayRate.
+type ExplorationConfig struct {
+	Strategy   string // epsilon_greedy (default), boltzmann or ucb1
+	Schedule   string // exponential (default) or linear
+	DecayPer   string // step (default) or episode
+	DecaySteps int    // linear schedule length, in decay ticks
+
+	Temperature      float64
+	MinTemperature   float64
+	TemperatureDecay float64
+
+	UCBConstant float64
+}
+
+// NewExplorationStrategy builds the strategy named by config.Exploration.Strategy.
+func NewExplorationStrategy(config SystemConfig) (ExplorationStrategy, error) {
+	config = withAgentDefaults(config)
+	exploration := config.Exploration
+
+	if exploration.Schedule != ScheduleExponential && exploration.Schedule != ScheduleLinear {
+		return nil, fmt.Errorf("unknown exploration schedule %q", exploration.Schedule)
+	}
+	if exploration.DecayPer != DecayPerStep && exploration.DecayPer != DecayPerEpisode {
+		return nil, fmt.Errorf("unknown exploration decay unit %q", exploration.DecayPer)
+	}
+
+	switch exploration.Strategy {
+	case ExplorationEpsilonGreedy:
+		return &EpsilonGreedy{Rand: newRand(config.Seed, "exploration"), decaySchedule: decaySchedule{
+			Current:    *config.ExplorationRate,
+			Initial:    *config.ExplorationRate,
+			Min:        *config.MinExploration,
+			DecayRate:  config.DecayRate,
+			DecaySteps: exploration.DecaySteps,
+			Schedule:   exploration.Schedule,
+			DecayPer:   exploration.DecayPer,
+		}}, nil
+	case ExplorationBoltzmann:
+		return &Boltzmann{Rand: newRand(config.Seed, "exploration"), decaySchedule: decaySchedule{
+			Current:    exploration.Temperature,
+			Initial:    exploration.Temperature,
+			Min:        exploration.MinTemperature,
+			DecayRate:  exploration.TemperatureDecay,
+			DecaySteps: exploration.DecaySteps,
+			Schedule:   exploration.Schedule,
+			DecayPer:   exploration.DecayPer,
+		}}, nil
+	case ExplorationUCB1:
+		return NewUCB1(exploration.UCBConstant), nil
+	default:
+		return nil, fmt.Errorf("unknown exploration strategy %q", exploration.Strategy)
+	}
+}
+
+// decaySchedule anneals a value from Initial towards Min, either geometrically
+// by DecayRate or linearly over DecaySteps, ticking per step or per episode.
+type decaySchedule struct {
+	Current    float64
+	Initial    float64
+	Min        float64
+	DecayRate  float64
+	DecaySteps int
+	Schedule   string
+	DecayPer   string
+}
+
+func (d *decaySchedule) tick() {
+	switch d.Schedule {
+	case ScheduleLinear:
+		steps := d.DecaySteps
+		if steps < 1 {
+			steps = 1
+		}
+		d.Current -= (d.Initial - d.Min) / float64(steps)
+	default:
+		d.Current *= d.DecayRate
+	}
+	d.Current = math.Max(d.Min, d.Current)
+}
+
+func (d *decaySchedule) EndStep() {
+	if d.DecayPer != DecayPerEpisode {
+		d.tick()
+	}
+}
+
+func (d *decaySchedule) EndEpisode() {
+	if d.DecayPer == DecayPerEpisode {
+		d.tick()
+	}
+}
+
+func (d *decaySchedule) save(snapshot *AgentSnapshot, prefix string) {
+	snapshot.Hyperparameters[prefix] = d.Current
+	snapshot.Hyperparameters["initial_"+prefix] = d.Initial
+	snapshot.Hyperparameters["min_"+prefix] = d.Min
+	snapshot.Hyperparameters[prefix+"_decay"] = d.DecayRate
+	snapshot.Hyperparameters[prefix+"_decay_steps"] = float64(d.DecaySteps)
+	snapshot.Options["exploration_schedule"] = d.Schedule
+	snapshot.Options["exploration_decay_per"] = d.DecayPer
+}
+
+func (d *decaySchedule) load(snapshot AgentSnapshot, prefix string) {
+	params := snapshot.Hyperparameters
+	if value, exists := params[prefix]; exists {
+		d.Current = value
+	}
+	if value, exists := params["initial_"+prefix]; exists {
+		d.Initial = value
+	}
+	if value, exists := params["min_"+prefix]; exists {
+		d.Min = value
+	}
+	if value, exists := params[prefix+"_decay"]; exists {
+		d.DecayRate = value
+	}
+	if value, exists := params[prefix+"_decay_steps"]; exists {
+		d.DecaySteps = int(value)
+	}
+	if schedule, exists := snapshot.Options["exploration_schedule"]; exists {
+		d.Schedule = schedule
+	}
+	if decayPer, exists := snapshot.Options["exploration_decay_per"]; exists {
+		d.DecayPer = decayPer
+	}
+}
+
+// EpsilonGreedy explores uniformly with probability Current and otherwise
+// takes the first action with the highest value.
+type EpsilonGreedy struct {
+	decaySchedule
+	// Rand decides when and where to explore; nil uses math/rand
+	Rand *Random
+}
+
+func (eg *EpsilonGreedy) Name() string {
+	return ExplorationEpsilonGreedy
+}
+
+func (eg *EpsilonGreedy) Choose(stateKey string, actionKeys []string, values []float64) (int, bool) {
+	if randFloat64(eg.Rand) < eg.Current {
+		return randIntn(eg.Rand, len(values)), true
+	}
+	return argmax(values), false
+}
+
+func (eg *EpsilonGreedy) Probabilities(stateKey string, actionKeys []string, values []float64) []float64 {
+	probabilities := make([]float64, len(values))
+	if len(values) == 0 {
+		return probabilities
+	}
+
+	uniform := eg.Current / float64(len(values))
+	for i := range probabilities {
+		probabilities[i] = uniform
+	}
+	probabilities[argmax(values)] += 1.0 - eg.Current
+	return probabilities
+}
+
+func (eg *EpsilonGreedy) Rate() float64 {
+	return eg.Current
+}
+
+func (eg *EpsilonGreedy) SaveState(snapshot *AgentSnapshot) {
+	eg.save(snapshot, "exploration_rate")
+	saveRandom(snapshot, "exploration_rng", eg.Rand)
+}
+
+func (eg *EpsilonGreedy) LoadState(snapshot AgentSnapshot) {
+	eg.load(snapshot, "exploration_rate")
+	eg.Rand = loadRandom(snapshot, "exploration_rng", eg.Rand)
+}
+
+// Boltzmann samples actions from a softmax over values divided by a
+// temperature that anneals on the configured schedule.
+type Boltzmann struct {
+	decaySchedule
+	// Rand samples from the softmax; nil uses math/rand
+	Rand *Random
+}
+
+func (b *Boltzmann) Name() string {
+	return ExplorationBoltzmann
+}
+
+func (b *Boltzmann) Choose(stateKey string, actionKeys []string, values []float64) (int, bool) {
+	probabilities := b.Probabilities(stateKey, actionKeys, values)
+	idx := sampleIndex(probabilities, randFloat64(b.Rand))
+	return idx, idx != argmax(values)
+}
+
+func (b *Boltzmann) Probabilities(stateKey string, actionKeys []string, values []float64) []float64 {
+	return softmax(values, b.Current)
+}
+
+func (b *Boltzmann) Rate() float64 {
+	return b.Current
+}
+
+func (b *Boltzmann) SaveState(snapshot *AgentSnapshot) {
+	b.save(snapshot, "temperature")
+	saveRandom(snapshot, "exploration_rng", b.Rand)
+}
+
+func (b *Boltzmann) LoadState(snapshot AgentSnapshot) {
+	b.load(snapshot, "temperature")
+	b.Rand = loadRandom(snapshot, "exploration_rng", b.Rand)
+}
+
+// UCB1 adds an optimism bonus of C*sqrt(ln N / n) to each action's value,
+// where n counts how often the action was chosen in this state and N counts
+// all choices in the state. Untried actions are chosen first.
+type UCB1 struct {
+	C float64
+
+	visits    map[string]map[string]float64
+	lastBonus float64
+}
+
+func NewUCB1(c float64) *UCB1 {
+	return &UCB1{
+		C:      c,
+		visits: make(map[string]map[string]float64),
+	}
+}
+
+func (u *UCB1) Name() string {
+	return ExplorationUCB1
+}
+
+func (u *UCB1) Choose(stateKey string, actionKeys []string, values []float64) (int, bool) {
+	idx, bonus := u.best(stateKey, actionKeys, values)
+
+	if u.visits[stateKey] == nil {
+		u.visits[stateKey] = make(map[string]float64)
+	}
+	u.visits[stateKey][actionKeys[idx]]++
+	u.lastBonus = bonus
+
+	return idx, idx != argmax(values)
+}
+
+func (u *UCB1) best(stateKey string, actionKeys []string, values []float64) (int, float64) {
+	counts := u.visits[stateKey]
+	total := 0.0
+	for _, actionKey := range actionKeys {
+		total += counts[actionKey]
+	}
+
+	bestIdx := -1
+	bestScore := math.Inf(-1)
+	bestBonus := 0.0
+	for i, actionKey := range actionKeys {
+		count := counts[actionKey]
+		if count == 0 {
+			return i, math.Inf(1)
+		}
+		bonus := u.C * math.Sqrt(math.Log(total)/count)
+		if score := values[i] + bonus; score > bestScore {
+			bestIdx, bestScore, bestBonus = i, score, bonus
+		}
+	}
+	return bestIdx, bestBonus
+}
+
+func (u *UCB1) Probabilities(stateKey string, actionKeys []string, values []float64) []float64 {
+	probabilities := make([]float64, len(values))
+	if len(values) == 0 {
+		return probabilities
+	}
+	idx, _ := u.best(stateKey, actionKeys, values)
+	probabilities[idx] = 1.0
+	return probabilities
+}
+
+// Rate reports the bonus that drove the most recent choice; untried actions
+// are reported as the constant itself rather than infinity.
+func (u *UCB1) Rate() float64 {
+	if math.IsInf(u.lastBonus, 1) {
+		return u.C
+	}
+	return u.lastBonus
+}
+
+func (u *UCB1) EndStep() {}
+
+func (u *UCB1) EndEpisode() {}
+
+func (u *UCB1) SaveState(snapshot *AgentSnapshot) {
+	snapshot.Hyperparameters["ucb_constant"] = u.C
+	snapshot.Tables["ucb_visits"] = copyQTable(u.visits)
+}
+
+func (u *UCB1) LoadState(snapshot AgentSnapshot) {
+	if c, exists := snapshot.Hyperparameters["ucb_constant"]; exists {
+		u.C = c
+	}
+	u.visits = copyQTable(snapshot.Tables["ucb_visits"])
+}
+
+func argmax(values []float64) int {
+	bestIdx := 0
+	for i, value := range values {
+		if value > values[bestIdx] {
+			bestIdx = i
+		}
+	}
+	return bestIdx
+}
+
+func softmax(values []float64, temperature float64) []float64 {
+	probabilities := make([]float64, len(values))
+	if len(values) == 0 {
+		return probabilities
+	}
+	temperature = math.Max(temperature, 1e-6)
+
+	maxValue := values[argmax(values)]
+	total := 0.0
+	for i, value := range values {
+		probabilities[i] = math.Exp((value - maxValue) / temperature)
+		total += probabilities[i]
+	}
+	for i := range probabilities {
+		probabilities[i] /= total
+	}
+	return probabilities
+}
+
+// sampleIndex draws an index from probabilities using a uniform draw u in [0, 1).
+func sampleIndex(probabilities []float64, u float64) int {
+	cumulative := 0.0
+	for i, p := range probabilities {
+		cumulative += p
+		if u < cumulative {
+			return i
+		}
+	}
+	return len(probabilities) - 1
+}
@@ -0,0 +1,172 @@
+package rl
+
+import (
+	"math"
+	"testing"
+
+	"textlib-rl-system/internal/logging"
+)
+
+func TestNewExplorationStrategy(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   ExplorationConfig
+		expected string
+		wantErr  bool
+	}{
+		{"default", ExplorationConfig{}, ExplorationEpsilonGreedy, false},
+		{"boltzmann", ExplorationConfig{Strategy: ExplorationBoltzmann}, ExplorationBoltzmann, false},
+		{"ucb1", ExplorationConfig{Strategy: ExplorationUCB1}, ExplorationUCB1, false},
+		{"unknown strategy", ExplorationConfig{Strategy: "thompson"}, "", true},
+		{"unknown schedule", ExplorationConfig{Schedule: "cosine"}, "", true},
+		{"unknown decay unit", ExplorationConfig{DecayPer: "batch"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy, err := NewExplorationStrategy(SystemConfig{Exploration: tt.config})
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if strategy.Name() != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, strategy.Name())
+			}
+		})
+	}
+
+	if _, err := NewAgent(SystemConfig{Exploration: ExplorationConfig{Strategy: "thompson"}}); err == nil {
+		t.Error("Expected NewAgent to reject an unknown exploration strategy")
+	}
+}
+
+func TestDecaySchedule(t *testing.T) {
+	exponential := decaySchedule{Current: 1.0, Initial: 1.0, Min: 0.1, DecayRate: 0.5,
+		Schedule: ScheduleExponential, DecayPer: DecayPerStep}
+	exponential.EndStep()
+	exponential.EndEpisode()
+	if exponential.Current != 0.5 {
+		t.Errorf("Expected per-step exponential decay to 0.5, got %f", exponential.Current)
+	}
+	for i := 0; i < 10; i++ {
+		exponential.EndStep()
+	}
+	if exponential.Current != 0.1 {
+		t.Errorf("Expected decay to clamp at 0.1, got %f", exponential.Current)
+	}
+
+	linear := decaySchedule{Current: 1.0, Initial: 1.0, Min: 0.0, DecaySteps: 4,
+		Schedule: ScheduleLinear, DecayPer: DecayPerEpisode}
+	linear.EndStep()
+	if linear.Current != 1.0 {
+		t.Errorf("Expected per-episode schedule to ignore steps, got %f", linear.Current)
+	}
+	linear.EndEpisode()
+	linear.EndEpisode()
+	if math.Abs(linear.Current-0.5) > 1e-9 {
+		t.Errorf("Expected linear decay to 0.5 after two episodes, got %f", linear.Current)
+	}
+}
+
+func TestEpsilonGreedy_Probabilities(t *testing.T) {
+	eg := &EpsilonGreedy{decaySchedule: decaySchedule{Current: 0.4}}
+	probabilities := eg.Probabilities("s", []string{"a", "b"}, []float64{1.0, 3.0})
+
+	if math.Abs(probabilities[0]-0.2) > 1e-9 || math.Abs(probabilities[1]-0.8) > 1e-9 {
+		t.Errorf("Expected [0.2 0.8], got %v", probabilities)
+	}
+
+	idx, explored := (&EpsilonGreedy{decaySchedule: decaySchedule{Current: 0.0}}).Choose("s", []string{"a", "b"}, []float64{1.0, 3.0})
+	if idx != 1 || explored {
+		t.Errorf("Expected greedy choice 1, got %d (explored=%v)", idx, explored)
+	}
+}
+
+func TestBoltzmann_Probabilities(t *testing.T) {
+	hot := &Boltzmann{decaySchedule: decaySchedule{Current: 1000.0}}
+	probabilities := hot.Probabilities("s", []string{"a", "b"}, []float64{0.0, 1.0})
+	if math.Abs(probabilities[0]-probabilities[1]) > 0.01 {
+		t.Errorf("Expected near-uniform probabilities at high temperature, got %v", probabilities)
+	}
+
+	cold := &Boltzmann{decaySchedule: decaySchedule{Current: 0.01}}
+	probabilities = cold.Probabilities("s", []string{"a", "b"}, []float64{0.0, 1.0})
+	if probabilities[1] < 0.999 {
+		t.Errorf("Expected near-greedy probabilities at low temperature, got %v", probabilities)
+	}
+
+	idx, explored := cold.Choose("s", []string{"a", "b"}, []float64{0.0, 1.0})
+	if idx != 1 || explored {
+		t.Errorf("Expected cold Boltzmann to pick greedy action, got %d (explored=%v)", idx, explored)
+	}
+}
+
+func TestUCB1_TriesEveryActionFirst(t *testing.T) {
+	ucb := NewUCB1(1.0)
+	keys := []string{"a", "b", "c"}
+	values := []float64{5.0, 0.0, 0.0}
+
+	seen := make(map[int]bool)
+	for i := 0; i < len(keys); i++ {
+		idx, _ := ucb.Choose("s", keys, values)
+		seen[idx] = true
+	}
+	if len(seen) != len(keys) {
+		t.Errorf("Expected every action tried once, saw %v", seen)
+	}
+
+	// With all actions tried once the high-value action should win
+	idx, explored := ucb.Choose("s", keys, values)
+	if idx != 0 || explored {
+		t.Errorf("Expected greedy action 0 after initial sweep, got %d (explored=%v)", idx, explored)
+	}
+	if ucb.Rate() <= 0 {
+		t.Errorf("Expected positive exploration bonus, got %f", ucb.Rate())
+	}
+
+	// Probabilities must not count as visits
+	before := ucb.visits["s"]["a"]
+	ucb.Probabilities("s", keys, values)
+	if ucb.visits["s"]["a"] != before {
+		t.Error("Probabilities mutated visit counts")
+	}
+}
+
+func TestQLearningAgent_ExplorationSnapshot(t *testing.T) {
+	config := SystemConfig{Exploration: ExplorationConfig{Strategy: ExplorationUCB1, UCBConstant: 2.0}}
+	agent, err := NewAgent(config)
+	if err != nil {
+		t.Fatalf("NewAgent returned error: %v", err)
+	}
+
+	state := testState("UCB snapshot text.")
+	action, _ := agent.SelectActionWithMetrics(state)
+	metrics := agent.Update(state, action, 1.0, state, false)
+	if metrics.ExplorationRate != 2.0 {
+		t.Errorf("Expected exploration rate to report the untried-action bonus 2.0, got %f", metrics.ExplorationRate)
+	}
+	agent.EndEpisode(logging.EpisodeMetrics{})
+
+	snapshot := agent.Snapshot()
+	if snapshot.Options["exploration"] != ExplorationUCB1 {
+		t.Errorf("Expected exploration option ucb1, got %q", snapshot.Options["exploration"])
+	}
+
+	// Restoring into a default agent switches it to the saved strategy
+	restored := newQLearningAgentFromConfig(SystemConfig{})
+	if err := restored.Restore(snapshot); err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	ucb, ok := restored.Exploration.(*UCB1)
+	if !ok {
+		t.Fatalf("Expected restored strategy *UCB1, got %T", restored.Exploration)
+	}
+	if ucb.C != 2.0 || len(ucb.visits) != 1 {
+		t.Errorf("Expected restored UCB state, got C=%f states=%d", ucb.C, len(ucb.visits))
+	}
+}
@@ -0,0 +1,201 @@
+package rl
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// StateFeaturizer turns a raw State into the characteristics the agent keys
+// its value estimates on. Two documents with the same features share Q-values,
+// which is what lets a trained table generalize to unseen text.
+type StateFeaturizer interface {
+	Name() string
+	Featurize(state State) StateFeatures
+}
+
+// StateFeatures describes a state by what the text looks like and what the
+// episode has done so far, not by its literal content.
+type StateFeatures struct {
+	TaskType        string
+	LengthBucket    string
+	EntityDensity   float64
+	CodePresence    bool
+	MathPresence    bool
+	ActionsUsed     []string // distinct functions already called, sorted
+	LastAction      string
+	ResultsPresent  []string // functions with a successful result, sorted
+	RemainingBudget int
+}
+
+const (
+	LengthShort    = "short"
+	LengthMedium   = "medium"
+	LengthLong     = "long"
+	LengthVeryLong = "very_long"
+)
+
+// TextFeaturizer is the default featurizer. Text-level features only depend on
+// the document, so they are computed once per text and reused for every step
+// of the episode.
+type TextFeaturizer struct {
+	cache map[string]textFeatures
+}
+
+type textFeatures struct {
+	lengthBucket  string
+	entityDensity float64
+	codePresence  bool
+	mathPresence  bool
+}
+
+// textFeatureCacheSize bounds the per-text cache; it is cleared when full.
+const textFeatureCacheSize = 1024
+
+func NewTextFeaturizer() *TextFeaturizer {
+	return &TextFeaturizer{cache: make(map[string]textFeatures)}
+}
+
+func (f *TextFeaturizer) Name() string {
+	return "text_features"
+}
+
+func (f *TextFeaturizer) Featurize(state State) StateFeatures {
+	text := f.textFeatures(state.Text)
+
+	used := make([]string, 0, len(state.ActionsUsed))
+	seen := make(map[string]bool, len(state.ActionsUsed))
+	for _, name := range state.ActionsUsed {
+		if !seen[name] {
+			seen[name] = true
+			used = append(used, name)
+		}
+	}
+	sort.Strings(used)
+
+	lastAction := ""
+	if len(state.ActionsUsed) > 0 {
+		lastAction = state.ActionsUsed[len(state.ActionsUsed)-1]
+	}
+
+	results := make([]string, 0, len(state.CurrentResults))
+	for name := range state.CurrentResults {
+		results = append(results, name)
+	}
+	sort.Strings(results)
+
+	return StateFeatures{
+		TaskType:        state.TaskType,
+		LengthBucket:    text.lengthBucket,
+		EntityDensity:   text.entityDensity,
+		CodePresence:    text.codePresence,
+		MathPresence:    text.mathPresence,
+		ActionsUsed:     used,
+		LastAction:      lastAction,
+		ResultsPresent:  results,
+		RemainingBudget: state.RemainingBudget,
+	}
+}
+
+func (f *TextFeaturizer) textFeatures(text string) textFeatures {
+	if cached, exists := f.cache[text]; exists {
+		return cached
+	}
+	if f.cache == nil || len(f.cache) >= textFeatureCacheSize {
+		f.cache = make(map[string]textFeatures)
+	}
+
+	features := textFeatures{
+		lengthBucket:  lengthBucket(len(text)),
+		entityDensity: calculateEntityDensity(text),
+		codePresence:  detectCodePresence(text),
+		mathPresence:  detectMathPresence(text),
+	}
+	f.cache[text] = features
+	return features
+}
+
+func lengthBucket(length int) string {
+	switch {
+	case length < 200:
+		return LengthShort
+	case length < 1000:
+		return LengthMedium
+	case length < 5000:
+		return LengthLong
+	default:
+		return LengthVeryLong
+	}
+}
+
+var (
+	codePatterns = []*regexp.Regexp{
+		regexp.MustCompile("```"),
+		regexp.MustCompile(`(?m)^\s*(func|def|class|import|package|#include|public|private)\b`),
+		regexp.MustCompile(`\b(function|return|const|let|var)\b[^.\n]*[=({;]`),
+		regexp.MustCompile(`[a-zA-Z_]\w*\([^)]*\)\s*[{:]`),
+		regexp.MustCompile(`(==|!=|=>|->|:=|&&|\|\|)`),
+		regexp.MustCompile(`(?m)[;{}]\s*$|^\s*[{}]`),
+	}
+	mathPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`\d\s*[+*/^×÷]\s*\d|\d\s+-\s+\d`),
+		regexp.MustCompile(`\b[a-zA-Z]\s*=\s*[-\d(a-zA-Z]`),
+		regexp.MustCompile(`\\(frac|sum|int|sqrt|alpha|beta|theta|pi)\b`),
+		regexp.MustCompile(`[∑∫√≤≥≠≈∞π±∂∆]`),
+		regexp.MustCompile(`\$[^$\n]+\$`),
+	}
+)
+
+// detectCodePresence reports whether at least two independent code signals
+// appear, so prose that merely mentions "function" or "class" is not flagged.
+func detectCodePresence(text string) bool {
+	hits := 0
+	for _, pattern := range codePatterns {
+		if pattern.MatchString(text) {
+			hits++
+			if hits >= 2 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func detectMathPresence(text string) bool {
+	for _, pattern := range mathPatterns {
+		if pattern.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// calculateEntityDensity approximates named-entity density as the share of
+// words that are capitalized mid-sentence or numeric.
+func calculateEntityDensity(text string) float64 {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return 0.0
+	}
+
+	entities := 0
+	sentenceStart := true
+	for _, word := range words {
+		trimmed := strings.TrimFunc(word, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if trimmed != "" {
+			first := []rune(trimmed)[0]
+			switch {
+			case unicode.IsDigit(first):
+				entities++
+			case unicode.IsUpper(first) && !sentenceStart:
+				entities++
+			}
+		}
+		sentenceStart = strings.HasSuffix(word, ".") || strings.HasSuffix(word, "!") || strings.HasSuffix(word, "?")
+	}
+
+	return float64(entities) / float64(len(words))
+}
@@ -0,0 +1,142 @@
+package rl
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDetectCodePresence(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected bool
+	}{
+		{"go function", "func main() {\n\tfmt.Println(x)\n}", true},
+		{"python", "def add(a, b):\n    return a + b", true},
+		{"fenced block", "Example:\n```\nx := 1\n```", true},
+		{"prose mentioning code words", "The class will import ideas from the function of art.", false},
+		{"plain prose", "The quarterly report shows strong growth in Europe.", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectCodePresence(tt.text); got != tt.expected {
+				t.Errorf("detectCodePresence(%q) = %v, expected %v", tt.text, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDetectMathPresence(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected bool
+	}{
+		{"The result is 3 + 4 which equals seven.", true},
+		{"Solve for x = 2y when y is known.", true},
+		{"The integral \\int f(x) dx converges.", true},
+		{"Error stays ≤ 0.01 across runs.", true},
+		{"Released on 2023-01-15 in Berlin.", false},
+		{"A plain sentence about reading.", false},
+	}
+
+	for _, tt := range tests {
+		if got := detectMathPresence(tt.text); got != tt.expected {
+			t.Errorf("detectMathPresence(%q) = %v, expected %v", tt.text, got, tt.expected)
+		}
+	}
+}
+
+func TestCalculateEntityDensity(t *testing.T) {
+	if got := calculateEntityDensity(""); got != 0.0 {
+		t.Errorf("Expected 0 density for empty text, got %f", got)
+	}
+
+	// "Apple", "Cupertino" and "1976" count; sentence-initial "The" does not
+	got := calculateEntityDensity("The company Apple was founded in Cupertino in 1976.")
+	if math.Abs(got-3.0/9.0) > 1e-9 {
+		t.Errorf("Expected density 3/9, got %f", got)
+	}
+}
+
+func TestTextFeaturizer_Featurize(t *testing.T) {
+	featurizer := NewTextFeaturizer()
+	state := testState("Short text.")
+	state.ActionsUsed = []string{"extract_entities", "detect_code", "extract_entities"}
+	state.CurrentResults["extract_entities"] = true
+	state.CurrentResults["detect_code"] = true
+
+	features := featurizer.Featurize(state)
+
+	if features.LengthBucket != LengthShort {
+		t.Errorf("Expected short length bucket, got %s", features.LengthBucket)
+	}
+	if len(features.ActionsUsed) != 2 || features.ActionsUsed[0] != "detect_code" {
+		t.Errorf("Expected sorted distinct actions, got %v", features.ActionsUsed)
+	}
+	if features.LastAction != "extract_entities" {
+		t.Errorf("Expected last action extract_entities, got %s", features.LastAction)
+	}
+	if len(features.ResultsPresent) != 2 || features.ResultsPresent[0] != "detect_code" {
+		t.Errorf("Expected sorted results, got %v", features.ResultsPresent)
+	}
+}
+
+func TestQLearningAgent_StateKeyGeneralizes(t *testing.T) {
+	agent := NewQLearningAgent(0.1, 0.95, 0.0, 0.0, 1.0)
+
+	first := testState("The report covers revenue growth this year.")
+	second := testState("A short note about weather patterns lately.")
+	if agent.getStateKey(first) != agent.getStateKey(second) {
+		t.Errorf("Expected similar documents to share a key: %s vs %s",
+			agent.getStateKey(first), agent.getStateKey(second))
+	}
+
+	withCode := testState("func main() {\n\treturn\n}")
+	if agent.getStateKey(first) == agent.getStateKey(withCode) {
+		t.Error("Expected code presence to change the state key")
+	}
+
+	progressed := first
+	progressed.ActionsUsed = []string{"detect_code"}
+	if agent.getStateKey(first) == agent.getStateKey(progressed) {
+		t.Error("Expected actions used to change the state key")
+	}
+}
+
+func TestUpdateState_DoesNotAliasPreviousState(t *testing.T) {
+	system := &EnhancedRLSystem{}
+	state := testState("Aliasing check text.")
+	action := getDefaultActions()[0]
+
+	next := system.updateState(state, action, ActionResult{Success: true, Output: "ok"})
+
+	if len(state.CurrentResults) != 0 || len(state.ActionsUsed) != 0 {
+		t.Error("updateState modified the previous state")
+	}
+	if len(next.CurrentResults) != 1 || len(next.ActionsUsed) != 1 {
+		t.Errorf("Expected next state to record the action, got %v %v", next.ActionsUsed, next.CurrentResults)
+	}
+}
+
+type fixedFeaturizer struct{}
+
+func (fixedFeaturizer) Name() string { return "fixed" }
+
+func (fixedFeaturizer) Featurize(state State) StateFeatures {
+	return StateFeatures{TaskType: state.TaskType}
+}
+
+func TestQLearningAgent_RestoreRejectsOtherFeaturizer(t *testing.T) {
+	agent := NewQLearningAgent(0.1, 0.95, 0.0, 0.0, 1.0)
+	snapshot := agent.Snapshot()
+	if snapshot.Options["state_featurizer"] != "text_features" {
+		t.Errorf("Expected featurizer recorded in snapshot, got %q", snapshot.Options["state_featurizer"])
+	}
+
+	other := NewQLearningAgent(0.1, 0.95, 0.0, 0.0, 1.0)
+	other.Featurizer = fixedFeaturizer{}
+	if err := other.Restore(snapshot); err == nil {
+		t.Error("Expected error restoring a snapshot keyed by another featurizer")
+	}
+}
@@ -0,0 +1,233 @@
+package rl
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	MetricF1            = "f1"
+	MetricAbsoluteError = "absolute_error"
+	MetricExactMatch    = "exact_match"
+)
+
+// readabilityScale is the width of the readability scale; an absolute error
+// this large or larger scores 0.
+const readabilityScale = 100.0
+
+// GroundTruthScore compares one action output with the matching field of
+// TrainingExample.Expected. Score is in [0, 1], higher is better: F1 for
+// lists, 1 - error/scale for numbers and 1 or 0 for booleans and labels.
+type GroundTruthScore struct {
+	Function      string  `json:"function"`
+	Field         string  `json:"field"`
+	Metric        string  `json:"metric"`
+	Score         float64 `json:"score"`
+	Precision     float64 `json:"precision,omitempty"`
+	Recall        float64 `json:"recall,omitempty"`
+	AbsoluteError float64 `json:"absolute_error,omitempty"`
+}
+
+// groundTruthField says which expected field an action's output answers and
+// how to read the comparable value from the output.
+type groundTruthField struct {
+	Field  string
+	Metric string
+	Read   func(output map[string]interface{}) (interface{}, bool)
+}
+
+var groundTruthFields = map[string]groundTruthField{
+	"extract_entities": {Field: "entities", Metric: MetricF1, Read: func(output map[string]interface{}) (interface{}, bool) {
+		return listField(output["entities"], "text")
+	}},
+	"extract_keywords": {Field: "keywords", Metric: MetricF1, Read: func(output map[string]interface{}) (interface{}, bool) {
+		return listField(output["keywords"], "keyword")
+	}},
+	"analyze_readability": {Field: "readability_score", Metric: MetricAbsoluteError, Read: func(output map[string]interface{}) (interface{}, bool) {
+		value, ok := output["readability_score"]
+		return value, ok
+	}},
+	"detect_code": {Field: "has_code", Metric: MetricExactMatch, Read: func(output map[string]interface{}) (interface{}, bool) {
+		value, ok := output["has_code"].(bool)
+		return value, ok
+	}},
+	"sentiment_analysis": {Field: "sentiment", Metric: MetricExactMatch, Read: func(output map[string]interface{}) (interface{}, bool) {
+		value, ok := output["sentiment"].(string)
+		return value, ok
+	}},
+}
+
+// ScoreOutput scores the output of functionName against expected. It returns
+// false when the function answers no expected field, the example does not
+// carry that field, or either side has the wrong shape.
+func ScoreOutput(functionName string, output interface{}, expected map[string]interface{}) (GroundTruthScore, bool) {
+	field, exists := groundTruthFields[functionName]
+	if !exists {
+		return GroundTruthScore{}, false
+	}
+	want, exists := expected[field.Field]
+	if !exists {
+		return GroundTruthScore{}, false
+	}
+	outputMap, ok := output.(map[string]interface{})
+	if !ok {
+		return GroundTruthScore{}, false
+	}
+	got, ok := field.Read(outputMap)
+	if !ok {
+		return GroundTruthScore{}, false
+	}
+
+	score := GroundTruthScore{Function: functionName, Field: field.Field, Metric: field.Metric}
+	switch field.Metric {
+	case MetricF1:
+		wantSet, ok := stringSet(want)
+		if !ok {
+			return GroundTruthScore{}, false
+		}
+		gotSet, _ := stringSet(got)
+		score.Precision, score.Recall, score.Score = setF1(gotSet, wantSet)
+	case MetricAbsoluteError:
+		wantValue, wantOK := numericValue(want)
+		gotValue, gotOK := numericValue(got)
+		if !wantOK || !gotOK {
+			return GroundTruthScore{}, false
+		}
+		score.AbsoluteError = math.Abs(gotValue - wantValue)
+		score.Score = math.Max(0, 1-score.AbsoluteError/readabilityScale)
+	case MetricExactMatch:
+		if normalizeLabel(want) == normalizeLabel(got) {
+			score.Score = 1
+		}
+	}
+	return score, true
+}
+
+// ValidateExpected checks that every ground-truth field present in expected
+// has the shape ScoreOutput compares against: a list of strings for F1
+// fields, a number for absolute error and a boolean or string label for
+// exact match. Other fields are free-form.
+func ValidateExpected(expected map[string]interface{}) error {
+	functions := make([]string, 0, len(groundTruthFields))
+	for functionName := range groundTruthFields {
+		functions = append(functions, functionName)
+	}
+	sort.Strings(functions)
+
+	for _, functionName := range functions {
+		field := groundTruthFields[functionName]
+		value, exists := expected[field.Field]
+		if !exists {
+			continue
+		}
+		switch field.Metric {
+		case MetricF1:
+			if _, ok := stringSet(value); !ok {
+				return fmt.Errorf("expected.%s must be a list of strings, got %T", field.Field, value)
+			}
+		case MetricAbsoluteError:
+			if _, ok := numericValue(value); !ok {
+				return fmt.Errorf("expected.%s must be a number, got %T", field.Field, value)
+			}
+		case MetricExactMatch:
+			switch value.(type) {
+			case bool, string:
+			default:
+				return fmt.Errorf("expected.%s must be a boolean or string, got %T", field.Field, value)
+			}
+		}
+	}
+	return nil
+}
+
+// setF1 returns precision, recall and F1 of got against want. Two empty sets
+// agree perfectly.
+func setF1(got, want map[string]bool) (float64, float64, float64) {
+	if len(got) == 0 && len(want) == 0 {
+		return 1, 1, 1
+	}
+	matched := 0
+	for item := range got {
+		if want[item] {
+			matched++
+		}
+	}
+	if matched == 0 {
+		return 0, 0, 0
+	}
+	precision := float64(matched) / float64(len(got))
+	recall := float64(matched) / float64(len(want))
+	return precision, recall, 2 * precision * recall / (precision + recall)
+}
+
+// listField reads key from every element of a list of maps, as produced by
+// the executors or decoded from JSON.
+func listField(value interface{}, key string) (interface{}, bool) {
+	switch items := value.(type) {
+	case []map[string]interface{}:
+		values := make([]string, 0, len(items))
+		for _, item := range items {
+			if text, ok := item[key].(string); ok {
+				values = append(values, text)
+			}
+		}
+		return values, true
+	case []interface{}:
+		values := make([]string, 0, len(items))
+		for _, item := range items {
+			if itemMap, ok := item.(map[string]interface{}); ok {
+				if text, ok := itemMap[key].(string); ok {
+					values = append(values, text)
+				}
+			} else if text, ok := item.(string); ok {
+				values = append(values, text)
+			}
+		}
+		return values, true
+	case []string:
+		return items, true
+	}
+	return nil, false
+}
+
+// stringSet normalizes a []string or JSON-decoded []interface{} into a set.
+func stringSet(value interface{}) (map[string]bool, bool) {
+	var items []string
+	switch list := value.(type) {
+	case []string:
+		items = list
+	case []interface{}:
+		for _, item := range list {
+			text, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			items = append(items, text)
+		}
+	default:
+		return nil, false
+	}
+
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		if normalized := normalizeText(item); normalized != "" {
+			set[normalized] = true
+		}
+	}
+	return set, true
+}
+
+// normalizeLabel compares strings case-insensitively without surrounding
+// punctuation; other values compare as-is.
+func normalizeLabel(value interface{}) interface{} {
+	if text, ok := value.(string); ok {
+		return normalizeText(text)
+	}
+	return value
+}
+
+func normalizeText(text string) string {
+	return strings.ToLower(strings.Trim(text, " \t\n.,;:!?()\"'"))
+}
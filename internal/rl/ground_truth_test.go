@@ -0,0 +1,90 @@
+package rl
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestScoreOutput_Metrics(t *testing.T) {
+	expected := map[string]interface{}{
+		"entities":          []string{"Redis", "RDB", "AOF", "Append Only File"},
+		"readability_score": 65.0,
+		"has_code":          false,
+		"sentiment":         "positive",
+	}
+
+	entities := map[string]interface{}{"entities": []map[string]interface{}{
+		{"text": "redis"}, {"text": "RDB."}, {"text": "snapshots"},
+	}}
+	score, ok := ScoreOutput("extract_entities", entities, expected)
+	if !ok || score.Metric != MetricF1 {
+		t.Fatalf("Expected an F1 score, got %+v (%v)", score, ok)
+	}
+	// 2 of 3 found are expected, 2 of 4 expected are found
+	if math.Abs(score.Precision-2.0/3.0) > 1e-9 || score.Recall != 0.5 || math.Abs(score.Score-4.0/7.0) > 1e-9 {
+		t.Errorf("Expected P=0.667 R=0.5 F1=0.571, got %+v", score)
+	}
+
+	score, _ = ScoreOutput("analyze_readability", map[string]interface{}{"readability_score": 80.0}, expected)
+	if score.AbsoluteError != 15 || math.Abs(score.Score-0.85) > 1e-9 {
+		t.Errorf("Expected an absolute error of 15 scoring 0.85, got %+v", score)
+	}
+
+	if score, _ := ScoreOutput("detect_code", map[string]interface{}{"has_code": false}, expected); score.Score != 1 {
+		t.Errorf("Expected a matching boolean to score 1, got %+v", score)
+	}
+	if score, _ := ScoreOutput("sentiment_analysis", map[string]interface{}{"sentiment": "Negative"}, expected); score.Score != 0 {
+		t.Errorf("Expected a wrong label to score 0, got %+v", score)
+	}
+}
+
+func TestScoreOutput_Unscorable(t *testing.T) {
+	expected := map[string]interface{}{"entities": []string{"Redis"}}
+	if _, ok := ScoreOutput("format_text", map[string]interface{}{"formatted_text": "x"}, expected); ok {
+		t.Error("Expected no score for a function without a ground-truth field")
+	}
+	if _, ok := ScoreOutput("extract_keywords", map[string]interface{}{"keywords": []map[string]interface{}{}}, expected); ok {
+		t.Error("Expected no score when the example lacks the field")
+	}
+	if _, ok := ScoreOutput("extract_entities", nil, expected); ok {
+		t.Error("Expected no score for a failed call")
+	}
+}
+
+func TestScoreOutput_JSONDecodedExpected(t *testing.T) {
+	var example TrainingExample
+	data := `{"id":"j","text":"x","expected":{"keywords":["Gradient","benchmark"],"readability_score":40}}`
+	if err := json.Unmarshal([]byte(data), &example); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+
+	keywords := map[string]interface{}{"keywords": []map[string]interface{}{{"keyword": "gradient,"}, {"keyword": "benchmark"}}}
+	if score, ok := ScoreOutput("extract_keywords", keywords, example.Expected); !ok || score.Score != 1 {
+		t.Errorf("Expected a perfect keyword match, got %+v (%v)", score, ok)
+	}
+	if score, ok := ScoreOutput("analyze_readability", map[string]interface{}{"readability_score": 40}, example.Expected); !ok || score.Score != 1 {
+		t.Errorf("Expected an exact readability match, got %+v (%v)", score, ok)
+	}
+}
+
+func TestGroundTruth_FeedsRewardAndQuality(t *testing.T) {
+	example := TrainingExample{ID: "g", Text: "Code free text.", TaskType: "comprehensive", Expected: map[string]interface{}{"has_code": false}}
+	action := Action{FunctionName: "detect_code", Category: "analysis", Cost: 2}
+	right := ActionResult{Success: true, Output: map[string]interface{}{"has_code": false}}
+	wrong := ActionResult{Success: true, Output: map[string]interface{}{"has_code": true}}
+
+	calc := NewEnhancedRewardCalculator()
+	state := testState(example.Text)
+	if delta := calc.CalculateReward(state, action, right, example) - calc.CalculateReward(state, action, wrong, example); math.Abs(delta-1.0) > 1e-9 {
+		t.Errorf("Expected the correct answer to earn 1.0 more reward, got %v", delta)
+	}
+
+	system := NewEnhancedRLSystem(SystemConfig{})
+	if quality := system.extractResultMetrics(action, wrong, example).OutputQuality; quality != 0 {
+		t.Errorf("Expected OutputQuality 0 for a wrong answer, got %v", quality)
+	}
+	if quality := system.extractResultMetrics(Action{FunctionName: "format_text"}, right, example).OutputQuality; quality != 0.8 {
+		t.Errorf("Expected the heuristic for unscorable outputs, got %v", quality)
+	}
+}
@@ -0,0 +1,187 @@
+package rl
+
+import (
+	"fmt"
+	"math"
+
+	"textlib-rl-system/internal/logging"
+)
+
+const AgentKindLinearQ = "linear_q"
+
+const (
+	LearningRateConstant    = "constant"
+	LearningRateInverseTime = "inverse_time"
+	LearningRateExponential = "exponential"
+)
+
+func init() {
+	RegisterAgent(AgentKindLinearQ, func(config SystemConfig) Agent {
+		config = withAgentDefaults(config)
+		return NewLinearQAgent(newQLearningAgentFromConfig(config), *config.L2Regularization,
+			LearningRateSchedule{Kind: config.LearningRateSchedule, Decay: config.LearningRateDecay})
+	})
+}
+
+// LinearQAgent is a semi-gradient Q-learner with one weight vector per action
+// over the featurizer's output, so what it learns about one document carries
+// over to unseen documents with similar characteristics.
+//
+// Features are named ("length:short", "used:detect_code", ...) and the
+// vocabulary grows as new task types and functions appear during training.
+// The embedded QLearningAgent supplies action enumeration, exploration and the
+// featurizer; its QTable is unused.
+type LinearQAgent struct {
+	*QLearningAgent
+	Weights  map[string][]float64
+	L2       float64
+	Schedule LearningRateSchedule
+
+	vocabulary *featureVocabulary
+}
+
+// LearningRateSchedule anneals the base learning rate with the number of
+// updates made: constant, inverse_time (α/(1+decay·t)) or exponential (α·decay^t).
+type LearningRateSchedule struct {
+	Kind  string
+	Decay float64
+	Steps int
+}
+
+func (schedule LearningRateSchedule) rate(initial float64) float64 {
+	steps := float64(schedule.Steps)
+	switch schedule.Kind {
+	case LearningRateInverseTime:
+		return initial / (1 + schedule.Decay*steps)
+	case LearningRateExponential:
+		return initial * math.Pow(schedule.Decay, steps)
+	default:
+		return initial
+	}
+}
+
+func validateLearningRateSchedule(kind string) error {
+	switch kind {
+	case "", LearningRateConstant, LearningRateInverseTime, LearningRateExponential:
+		return nil
+	default:
+		return fmt.Errorf("unknown learning rate schedule %q", kind)
+	}
+}
+
+func NewLinearQAgent(base *QLearningAgent, l2 float64, schedule LearningRateSchedule) *LinearQAgent {
+	return &LinearQAgent{
+		QLearningAgent: base,
+		Weights:        make(map[string][]float64),
+		L2:             l2,
+		Schedule:       schedule,
+		vocabulary:     newFeatureVocabulary(),
+	}
+}
+
+// stateFeatures returns the sparse feature vector for state, growing the
+// vocabulary when learning from it.
+func (agent *LinearQAgent) stateFeatures(state State, grow bool) []activeFeature {
+	return agent.vocabulary.active(namedStateFeatures(agent.featurizer().Featurize(state)), grow)
+}
+
+func (agent *LinearQAgent) GetQValue(state State, action Action) float64 {
+	return dotFeatures(agent.Weights[agent.getActionKey(action)], agent.stateFeatures(state, false))
+}
+
+func (agent *LinearQAgent) SelectActionWithMetrics(state State) (Action, logging.ActionMetrics) {
+	action, isExploration := agent.chooseActionBy(state, agent.GetQValue)
+	return action, agent.actionMetrics(state, action, isExploration, agent.GetQValue(state, action))
+}
+
+// Update takes one semi-gradient step on the squared TD error. The step is
+// normalized by the squared feature norm so the configured learning rate
+// means the same thing however many features are active, and L2
+// regularization decays the updated action's weights.
+func (agent *LinearQAgent) Update(state State, action Action, reward float64, nextState State, done bool) logging.LearningMetrics {
+	target := reward
+	if !done {
+		bestNext := agent.selectBestActionBy(nextState, agent.GetQValue)
+		target += agent.DiscountFactor * agent.GetQValue(nextState, bestNext)
+	}
+
+	actionKey := agent.getActionKey(action)
+	active := agent.stateFeatures(state, true)
+	weights := agent.vocabulary.fit(agent.Weights[actionKey])
+
+	learningRate := agent.Schedule.rate(agent.LearningRate)
+	tdError := target - dotFeatures(weights, active)
+	norm := squaredNorm(active)
+
+	if agent.L2 > 0 {
+		shrink := 1 - learningRate*agent.L2
+		for i := range weights {
+			weights[i] *= shrink
+		}
+	}
+	if norm > 0 {
+		step := learningRate * tdError / norm
+		for _, feature := range active {
+			weights[feature.index] += step * feature.value
+		}
+	}
+	agent.Weights[actionKey] = weights
+
+	agent.Schedule.Steps++
+	agent.Exploration.EndStep()
+
+	return agent.learningMetrics()
+}
+
+func (agent *LinearQAgent) Snapshot() AgentSnapshot {
+	snapshot := snapshotLearner(AgentKindLinearQ, agent.LearningRate, agent.DiscountFactor, agent.Exploration,
+		map[string]map[string]map[string]float64{})
+	snapshot.Hyperparameters["l2_regularization"] = agent.L2
+	snapshot.Hyperparameters["learning_rate_decay"] = agent.Schedule.Decay
+	snapshot.Hyperparameters["learning_rate_steps"] = float64(agent.Schedule.Steps)
+	snapshot.Options["learning_rate_schedule"] = agent.Schedule.Kind
+	snapshot.Options["state_featurizer"] = agent.featurizer().Name()
+	snapshot.Options["features"] = agent.vocabulary.encode()
+
+	snapshot.Weights = make(map[string][]float64, len(agent.Weights))
+	for actionKey, weights := range agent.Weights {
+		snapshot.Weights[actionKey] = append([]float64(nil), weights...)
+	}
+	return snapshot
+}
+
+func (agent *LinearQAgent) Restore(snapshot AgentSnapshot) error {
+	if name, exists := snapshot.Options["state_featurizer"]; exists && name != agent.featurizer().Name() {
+		return fmt.Errorf("snapshot features come from featurizer %s, agent uses %s", name, agent.featurizer().Name())
+	}
+
+	vocabulary, err := parseFeatureVocabulary(snapshot.Options["features"])
+	if err != nil {
+		return err
+	}
+	if err := vocabulary.check(snapshot.Weights); err != nil {
+		return err
+	}
+
+	exploration, err := restoreLearner(AgentKindLinearQ, snapshot, &agent.LearningRate, &agent.DiscountFactor, agent.Exploration)
+	if err != nil {
+		return err
+	}
+	agent.Exploration = exploration
+
+	if value, exists := snapshot.Hyperparameters["l2_regularization"]; exists {
+		agent.L2 = value
+	}
+	agent.Schedule = LearningRateSchedule{
+		Kind:  snapshot.Options["learning_rate_schedule"],
+		Decay: snapshot.Hyperparameters["learning_rate_decay"],
+		Steps: int(snapshot.Hyperparameters["learning_rate_steps"]),
+	}
+
+	agent.vocabulary = vocabulary
+	agent.Weights = make(map[string][]float64, len(snapshot.Weights))
+	for actionKey, weights := range snapshot.Weights {
+		agent.Weights[actionKey] = append([]float64(nil), weights...)
+	}
+	return nil
+}
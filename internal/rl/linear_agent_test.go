@@ -0,0 +1,123 @@
+package rl
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func newTestLinearAgent(l2 float64) *LinearQAgent {
+	return NewLinearQAgent(NewQLearningAgent(0.5, 0.9, 0.0, 0.0, 1.0), l2, LearningRateSchedule{Kind: LearningRateConstant})
+}
+
+func TestLinearQAgent_GeneralizesToUnseenText(t *testing.T) {
+	agent := newTestLinearAgent(0)
+	action := getDefaultActions()[0]
+	trained := testState("The report covers revenue growth this year.")
+
+	for i := 0; i < 50; i++ {
+		agent.Update(trained, action, 2.0, trained, true)
+	}
+	if got := agent.GetQValue(trained, action); math.Abs(got-2.0) > 1e-6 {
+		t.Errorf("Expected Q to converge to 2.0, got %f", got)
+	}
+
+	unseen := testState("A short note about weather patterns lately.")
+	if got := agent.GetQValue(unseen, action); math.Abs(got-2.0) > 1e-6 {
+		t.Errorf("Expected unseen text with the same features to share the estimate, got %f", got)
+	}
+	if got := agent.GetQValue(trained, getDefaultActions()[1]); got != 0.0 {
+		t.Errorf("Expected untrained action to stay at 0, got %f", got)
+	}
+}
+
+func TestLinearQAgent_L2ShrinksWeights(t *testing.T) {
+	agent := newTestLinearAgent(0.5)
+	action := getDefaultActions()[0]
+	state := testState("Regularization text.")
+
+	agent.Update(state, action, 1.0, state, true)
+	before := agent.GetQValue(state, action)
+
+	// A zero TD-error update only applies weight decay
+	agent.Update(state, action, before, state, true)
+	if after := agent.GetQValue(state, action); after >= before {
+		t.Errorf("Expected L2 to shrink the estimate, before=%f after=%f", before, after)
+	}
+}
+
+func TestLearningRateSchedule(t *testing.T) {
+	tests := []struct {
+		schedule LearningRateSchedule
+		expected float64
+	}{
+		{LearningRateSchedule{Kind: LearningRateConstant, Steps: 100}, 0.1},
+		{LearningRateSchedule{Kind: LearningRateInverseTime, Decay: 0.01, Steps: 100}, 0.05},
+		{LearningRateSchedule{Kind: LearningRateExponential, Decay: 0.5, Steps: 2}, 0.025},
+	}
+
+	for _, tt := range tests {
+		if got := tt.schedule.rate(0.1); math.Abs(got-tt.expected) > 1e-12 {
+			t.Errorf("%s: expected %f, got %f", tt.schedule.Kind, tt.expected, got)
+		}
+	}
+
+	if _, err := NewAgent(SystemConfig{AgentType: AgentKindLinearQ, LearningRateSchedule: "cosine"}); err == nil {
+		t.Error("Expected error for unknown learning rate schedule")
+	}
+}
+
+func TestLinearQAgent_SnapshotJSONRoundTrip(t *testing.T) {
+	created, err := NewAgent(SystemConfig{AgentType: AgentKindLinearQ, LearningRateSchedule: LearningRateInverseTime})
+	if err != nil {
+		t.Fatalf("NewAgent returned error: %v", err)
+	}
+	agent := created.(*LinearQAgent)
+	if agent.Schedule.Decay != 1e-3 || agent.L2 != 1e-4 {
+		t.Errorf("Expected config defaults, got decay=%f l2=%f", agent.Schedule.Decay, agent.L2)
+	}
+
+	// A comma in a feature name must survive the snapshot
+	state := testState("Snapshot round trip text.")
+	state.TaskType = "analysis, technical"
+	next := state
+	next.ActionsUsed = []string{"extract_entities"}
+	action := getDefaultActions()[0]
+	agent.Update(state, action, 1.0, next, false)
+	agent.Update(next, getDefaultActions()[2], 0.5, next, true)
+
+	data, err := json.Marshal(agent.Snapshot())
+	if err != nil {
+		t.Fatalf("Marshal returned error: %v", err)
+	}
+	var snapshot AgentSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+
+	restored := newTestLinearAgent(0)
+	if err := restored.Restore(snapshot); err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	if restored.GetQValue(state, action) != agent.GetQValue(state, action) {
+		t.Error("Restored estimate does not match original")
+	}
+	if restored.Schedule != agent.Schedule || restored.L2 != agent.L2 {
+		t.Errorf("Restored schedule %+v l2=%f, expected %+v l2=%f", restored.Schedule, restored.L2, agent.Schedule, agent.L2)
+	}
+	if len(snapshot.Tables) != 0 {
+		t.Error("Expected linear snapshot to carry weights, not tables")
+	}
+
+	if restored.vocabulary.size() != agent.vocabulary.size() {
+		t.Errorf("Restored %d feature names, expected %d", restored.vocabulary.size(), agent.vocabulary.size())
+	}
+
+	snapshot.Options["features"] = `["bias"]`
+	if err := restored.Restore(snapshot); err == nil {
+		t.Error("Expected error when weights outnumber feature names")
+	}
+	if _, err := parseFeatureVocabulary("bias,budget"); err == nil {
+		t.Error("Expected feature names that are not a JSON list to be rejected")
+	}
+}
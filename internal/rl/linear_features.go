@@ -0,0 +1,153 @@
+package rl
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// namedStateFeatures expands featurizer output into sparse named features
+// ("length:short", "used:detect_code", ...) for the linear learners. Values
+// are scaled to [0, 1] so a single learning rate suits all weights.
+func namedStateFeatures(features StateFeatures) map[string]float64 {
+	named := map[string]float64{
+		"bias":                            1.0,
+		"task:" + features.TaskType:       1.0,
+		"length:" + features.LengthBucket: 1.0,
+		"entity_density":                  math.Min(1.0, features.EntityDensity),
+		"budget":                          math.Max(0.0, math.Min(1.0, float64(features.RemainingBudget)/50.0)),
+	}
+	if features.CodePresence {
+		named["code"] = 1.0
+	}
+	if features.MathPresence {
+		named["math"] = 1.0
+	}
+	for _, name := range features.ActionsUsed {
+		named["used:"+name] = 1.0
+	}
+	for _, name := range features.ResultsPresent {
+		named["result:"+name] = 1.0
+	}
+	if features.LastAction != "" {
+		named["last:"+features.LastAction] = 1.0
+	}
+	return named
+}
+
+type activeFeature struct {
+	index int
+	value float64
+}
+
+// featureVocabulary assigns weight indices to named features. It grows as new
+// task types and functions appear during training, so weight vectors are
+// extended with fit before use.
+type featureVocabulary struct {
+	names []string
+	index map[string]int
+}
+
+func newFeatureVocabulary() *featureVocabulary {
+	return &featureVocabulary{index: make(map[string]int)}
+}
+
+// parseFeatureVocabulary is the inverse of encode. An empty string is an
+// empty vocabulary.
+func parseFeatureVocabulary(encoded string) (*featureVocabulary, error) {
+	var names []string
+	if encoded != "" {
+		if err := json.Unmarshal([]byte(encoded), &names); err != nil {
+			return nil, fmt.Errorf("invalid feature names: %w", err)
+		}
+	}
+
+	vocabulary := newFeatureVocabulary()
+	for _, name := range names {
+		if _, exists := vocabulary.index[name]; exists {
+			return nil, fmt.Errorf("feature %q is named twice", name)
+		}
+		vocabulary.index[name] = len(vocabulary.names)
+		vocabulary.names = append(vocabulary.names, name)
+	}
+	return vocabulary, nil
+}
+
+// encode lists the feature names in index order as a JSON array, which keeps
+// names containing any character intact in snapshot options.
+func (v *featureVocabulary) encode() string {
+	data, _ := json.Marshal(append([]string{}, v.names...))
+	return string(data)
+}
+
+func (v *featureVocabulary) size() int {
+	return len(v.names)
+}
+
+// active maps named features to weight indices. Names the vocabulary has
+// never seen are dropped unless grow is set, in which case they are added
+// with zero weight. Names are visited in sorted order so the vocabulary, and
+// therefore the saved weights, are reproducible.
+func (v *featureVocabulary) active(named map[string]float64, grow bool) []activeFeature {
+	names := make([]string, 0, len(named))
+	for name := range named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	active := make([]activeFeature, 0, len(named))
+	for _, name := range names {
+		value := named[name]
+		if value == 0 {
+			continue
+		}
+		index, exists := v.index[name]
+		if !exists {
+			if !grow {
+				continue
+			}
+			index = len(v.names)
+			v.names = append(v.names, name)
+			v.index[name] = index
+		}
+		active = append(active, activeFeature{index: index, value: value})
+	}
+	return active
+}
+
+// fit extends weights with zeros to cover every feature in the vocabulary.
+func (v *featureVocabulary) fit(weights []float64) []float64 {
+	if len(weights) < len(v.names) {
+		weights = append(weights, make([]float64, len(v.names)-len(weights))...)
+	}
+	return weights
+}
+
+// check rejects saved weight vectors longer than the saved vocabulary.
+func (v *featureVocabulary) check(weights map[string][]float64) error {
+	for key, vector := range weights {
+		if len(vector) > len(v.names) {
+			return fmt.Errorf("weights for %s have %d entries but only %d features are named", key, len(vector), len(v.names))
+		}
+	}
+	return nil
+}
+
+func dotFeatures(weights []float64, active []activeFeature) float64 {
+	total := 0.0
+	for _, feature := range active {
+		if feature.index < len(weights) {
+			total += weights[feature.index] * feature.value
+		}
+	}
+	return total
+}
+
+func squaredNorm(active []activeFeature) float64 {
+	norm := 0.0
+	for _, feature := range active {
+		norm += feature.value * feature.value
+	}
+	return norm
+}
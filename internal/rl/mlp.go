@@ -0,0 +1,182 @@
+package rl
+
+import (
+	"fmt"
+	"math"
+)
+
+// MLP is a small fully connected network with ReLU hidden layers and a linear
+// output layer. It is deliberately plain Go: the networks the agents need have
+// a few thousand parameters and train on the CPU between simulated calls.
+type MLP struct {
+	Sizes   []int
+	Weights [][]float64 // Weights[l] is Sizes[l+1] rows of Sizes[l] inputs, row-major
+	Biases  [][]float64
+}
+
+// NewMLP builds a network with He-initialized weights drawn from rng (math/rand
+// when nil) and zero biases.
+func NewMLP(sizes []int, rng *Random) *MLP {
+	net := &MLP{
+		Sizes:   append([]int(nil), sizes...),
+		Weights: make([][]float64, len(sizes)-1),
+		Biases:  make([][]float64, len(sizes)-1),
+	}
+	for l := 0; l < len(sizes)-1; l++ {
+		scale := math.Sqrt(2.0 / float64(sizes[l]))
+		net.Weights[l] = make([]float64, sizes[l+1]*sizes[l])
+		for i := range net.Weights[l] {
+			net.Weights[l][i] = randNormFloat64(rng) * scale
+		}
+		net.Biases[l] = make([]float64, sizes[l+1])
+	}
+	return net
+}
+
+// Forward returns the network output for input.
+func (net *MLP) Forward(input []float64) []float64 {
+	activations := net.forwardTrace(input)
+	return activations[len(activations)-1]
+}
+
+// forwardTrace returns the activations of every layer, input first, which is
+// what backpropagation needs.
+func (net *MLP) forwardTrace(input []float64) [][]float64 {
+	activations := make([][]float64, len(net.Sizes))
+	activations[0] = input
+	for l := range net.Weights {
+		in := activations[l]
+		out := make([]float64, net.Sizes[l+1])
+		last := l == len(net.Weights)-1
+		for j := range out {
+			row := net.Weights[l][j*net.Sizes[l] : (j+1)*net.Sizes[l]]
+			sum := net.Biases[l][j]
+			for i, x := range in {
+				sum += row[i] * x
+			}
+			if !last && sum < 0 {
+				sum = 0
+			}
+			out[j] = sum
+		}
+		activations[l+1] = out
+	}
+	return activations
+}
+
+// mlpGradients accumulates parameter gradients over a minibatch.
+type mlpGradients struct {
+	weights [][]float64
+	biases  [][]float64
+}
+
+func (net *MLP) newGradients() *mlpGradients {
+	grads := &mlpGradients{
+		weights: make([][]float64, len(net.Weights)),
+		biases:  make([][]float64, len(net.Biases)),
+	}
+	for l := range net.Weights {
+		grads.weights[l] = make([]float64, len(net.Weights[l]))
+		grads.biases[l] = make([]float64, len(net.Biases[l]))
+	}
+	return grads
+}
+
+// backward adds the gradient of the loss with respect to every parameter,
+// given the activations from forwardTrace and the loss gradient at the output.
+func (net *MLP) backward(activations [][]float64, outputGrad []float64, grads *mlpGradients) {
+	delta := outputGrad
+	for l := len(net.Weights) - 1; l >= 0; l-- {
+		in := activations[l]
+		width := net.Sizes[l]
+		var prev []float64
+		if l > 0 {
+			prev = make([]float64, width)
+		}
+		for j, d := range delta {
+			if d == 0 {
+				continue
+			}
+			grads.biases[l][j] += d
+			row := net.Weights[l][j*width : (j+1)*width]
+			gradRow := grads.weights[l][j*width : (j+1)*width]
+			for i, x := range in {
+				gradRow[i] += d * x
+				if prev != nil {
+					prev[i] += d * row[i]
+				}
+			}
+		}
+		if prev != nil {
+			// ReLU derivative: hidden units that were off pass no gradient
+			for i := range prev {
+				if in[i] <= 0 {
+					prev[i] = 0
+				}
+			}
+		}
+		delta = prev
+	}
+}
+
+// apply takes a gradient descent step of size learningRate on the averaged
+// gradients.
+func (net *MLP) apply(grads *mlpGradients, learningRate float64, batchSize int) {
+	step := learningRate / float64(batchSize)
+	for l := range net.Weights {
+		for i, g := range grads.weights[l] {
+			net.Weights[l][i] -= step * g
+		}
+		for i, g := range grads.biases[l] {
+			net.Biases[l][i] -= step * g
+		}
+	}
+}
+
+// CopyFrom overwrites the parameters with those of other, which must have the
+// same shape.
+func (net *MLP) CopyFrom(other *MLP) {
+	for l := range net.Weights {
+		copy(net.Weights[l], other.Weights[l])
+		copy(net.Biases[l], other.Biases[l])
+	}
+}
+
+func (net *MLP) Clone() *MLP {
+	clone := &MLP{
+		Sizes:   append([]int(nil), net.Sizes...),
+		Weights: make([][]float64, len(net.Weights)),
+		Biases:  make([][]float64, len(net.Biases)),
+	}
+	for l := range net.Weights {
+		clone.Weights[l] = append([]float64(nil), net.Weights[l]...)
+		clone.Biases[l] = append([]float64(nil), net.Biases[l]...)
+	}
+	return clone
+}
+
+// saveWeights stores the parameters in a snapshot under prefix.
+func (net *MLP) saveWeights(prefix string, weights map[string][]float64) {
+	for l := range net.Weights {
+		weights[fmt.Sprintf("%s_w%d", prefix, l)] = append([]float64(nil), net.Weights[l]...)
+		weights[fmt.Sprintf("%s_b%d", prefix, l)] = append([]float64(nil), net.Biases[l]...)
+	}
+}
+
+// loadWeights is the inverse of saveWeights and checks every layer's shape.
+func (net *MLP) loadWeights(prefix string, weights map[string][]float64) error {
+	for l := range net.Weights {
+		w, wOK := weights[fmt.Sprintf("%s_w%d", prefix, l)]
+		b, bOK := weights[fmt.Sprintf("%s_b%d", prefix, l)]
+		if !wOK || !bOK {
+			return fmt.Errorf("missing %s layer %d parameters", prefix, l)
+		}
+		if len(w) != len(net.Weights[l]) || len(b) != len(net.Biases[l]) {
+			return fmt.Errorf("%s layer %d has shape %d/%d, expected %d/%d",
+				prefix, l, len(w), len(b), len(net.Weights[l]), len(net.Biases[l]))
+		}
+		copy(net.Weights[l], w)
+		copy(net.Biases[l], b)
+	}
+	return nil
+}
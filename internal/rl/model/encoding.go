@@ -0,0 +1,391 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	FormatJSON   = "json"
+	FormatBinary = "binary"
+)
+
+// binaryMagic opens every binary model; the byte after it is the layout
+// version, which changes independently of the schema Version.
+const (
+	binaryMagic  = "TLRLMODEL"
+	binaryLayout = 1
+)
+
+var errTruncated = errors.New("truncated binary model")
+
+// Encoding selects how a model is written. Decode recognizes every encoding,
+// so readers never need to be told which one a file uses.
+type Encoding struct {
+	Format   string // json (default) or binary
+	Compress bool   // gzip the encoded model
+}
+
+// EncodingFor infers the encoding from a file name: .bin is binary, anything
+// else JSON, and a trailing .gz means compressed.
+func EncodingFor(path string) Encoding {
+	encoding := Encoding{Format: FormatJSON}
+	if strings.HasSuffix(path, ".gz") {
+		encoding.Compress = true
+		path = strings.TrimSuffix(path, ".gz")
+	}
+	if strings.HasSuffix(path, ".bin") {
+		encoding.Format = FormatBinary
+	}
+	return encoding
+}
+
+// Extension is the file extension EncodingFor maps back to this encoding.
+func (encoding Encoding) Extension() string {
+	extension := ".json"
+	if encoding.Format == FormatBinary {
+		extension = ".bin"
+	}
+	if encoding.Compress {
+		extension += ".gz"
+	}
+	return extension
+}
+
+// Validate checks the format name.
+func (encoding Encoding) Validate() error {
+	switch encoding.Format {
+	case "", FormatJSON, FormatBinary:
+		return nil
+	default:
+		return fmt.Errorf("unknown model format %q (want %s or %s)", encoding.Format, FormatJSON, FormatBinary)
+	}
+}
+
+// Write encodes model to w in the given encoding.
+func Write(w io.Writer, model Model, encoding Encoding) error {
+	if err := encoding.Validate(); err != nil {
+		return err
+	}
+	var compressor *gzip.Writer
+	if encoding.Compress {
+		compressor = gzip.NewWriter(w)
+		w = compressor
+	}
+
+	var err error
+	if encoding.Format == FormatBinary {
+		err = EncodeBinary(w, model)
+	} else {
+		err = Encode(w, model)
+	}
+	if compressor != nil {
+		if closeErr := compressor.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// Save writes model to path in the given encoding.
+func Save(path string, model Model, encoding Encoding) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = Write(file, model, encoding)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Decode reads a model in any encoding and any supported version, migrating
+// older versions to the current schema.
+func Decode(r io.Reader) (Model, error) {
+	reader := bufio.NewReader(r)
+	if header, _ := reader.Peek(2); bytes.Equal(header, []byte{0x1f, 0x8b}) {
+		decompressor, err := gzip.NewReader(reader)
+		if err != nil {
+			return Model{}, err
+		}
+		defer decompressor.Close()
+		reader = bufio.NewReader(decompressor)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return Model{}, err
+	}
+	if bytes.HasPrefix(data, []byte(binaryMagic)) {
+		return decodeBinary(data[len(binaryMagic):])
+	}
+	model, err := decodeJSON(data)
+	if err != nil {
+		return Model{}, err
+	}
+	return model, model.validate()
+}
+
+// EncodeBinary writes model in the binary layout. Tables and weights, which
+// are nearly all of a trained model, are written as varint indexes into a
+// table of the distinct keys and raw little-endian float64s, so nothing is
+// rounded. The rest of the model is stored as compact JSON so it follows
+// the same schema, and the same migrations, as the JSON form.
+//
+// Layout after the magic and layout byte, with every count a uvarint:
+//
+//	metadata: length, JSON bytes
+//	strings:  count, then length and bytes of each, sorted
+//	tables:   count, then per table its name index and state count, per
+//	          state its key index and action count, per action its key
+//	          index and value
+//	weights:  count, then per vector its name index, length and values
+//
+// Map entries are written in sorted order, so the same model always encodes
+// to the same bytes.
+func EncodeBinary(w io.Writer, model Model) error {
+	tables, weights := model.Agent.Tables, model.Agent.Weights
+	model.Agent.Tables, model.Agent.Weights = nil, nil
+	metadata, err := json.Marshal(model)
+	if err != nil {
+		return err
+	}
+
+	strs := newStringTable(tables, weights)
+	buffer := bytes.NewBufferString(binaryMagic)
+	buffer.WriteByte(binaryLayout)
+	putBytes(buffer, metadata)
+	putUvarint(buffer, uint64(len(strs.values)))
+	for _, value := range strs.values {
+		putBytes(buffer, []byte(value))
+	}
+
+	putUvarint(buffer, uint64(len(tables)))
+	for _, name := range sortedKeys(tables) {
+		table := tables[name]
+		putUvarint(buffer, strs.index[name])
+		putUvarint(buffer, uint64(len(table)))
+		for _, stateKey := range sortedKeys(table) {
+			actions := table[stateKey]
+			putUvarint(buffer, strs.index[stateKey])
+			putUvarint(buffer, uint64(len(actions)))
+			for _, actionKey := range sortedKeys(actions) {
+				putUvarint(buffer, strs.index[actionKey])
+				putFloat(buffer, actions[actionKey])
+			}
+		}
+	}
+
+	putUvarint(buffer, uint64(len(weights)))
+	for _, name := range sortedKeys(weights) {
+		putUvarint(buffer, strs.index[name])
+		putUvarint(buffer, uint64(len(weights[name])))
+		for _, value := range weights[name] {
+			putFloat(buffer, value)
+		}
+	}
+
+	_, err = w.Write(buffer.Bytes())
+	return err
+}
+
+func decodeBinary(data []byte) (Model, error) {
+	reader := &binaryReader{data: data}
+	if layout := reader.readByte(); reader.err == nil && layout != binaryLayout {
+		return Model{}, fmt.Errorf("unsupported binary model layout %d (this build reads %d)", layout, binaryLayout)
+	}
+	metadata := reader.readBytes()
+
+	strs := make([]string, reader.readCount())
+	for i := range strs {
+		strs[i] = string(reader.readBytes())
+	}
+	lookup := func() string {
+		index := reader.readUvarint()
+		if reader.err == nil && index >= uint64(len(strs)) {
+			reader.err = fmt.Errorf("string index %d out of range", index)
+		}
+		if reader.err != nil {
+			return ""
+		}
+		return strs[index]
+	}
+
+	var tables map[string]map[string]map[string]float64
+	if count := reader.readCount(); count > 0 {
+		tables = make(map[string]map[string]map[string]float64, count)
+		for i := 0; i < count && reader.err == nil; i++ {
+			name := lookup()
+			states := reader.readCount()
+			table := make(map[string]map[string]float64, states)
+			for j := 0; j < states && reader.err == nil; j++ {
+				stateKey := lookup()
+				entries := reader.readCount()
+				actions := make(map[string]float64, entries)
+				for k := 0; k < entries && reader.err == nil; k++ {
+					actionKey := lookup()
+					actions[actionKey] = reader.readFloat()
+				}
+				table[stateKey] = actions
+			}
+			tables[name] = table
+		}
+	}
+
+	var weights map[string][]float64
+	if count := reader.readCount(); count > 0 {
+		weights = make(map[string][]float64, count)
+		for i := 0; i < count && reader.err == nil; i++ {
+			name := lookup()
+			vector := make([]float64, reader.readCount())
+			for j := range vector {
+				vector[j] = reader.readFloat()
+			}
+			weights[name] = vector
+		}
+	}
+	if reader.err != nil {
+		return Model{}, fmt.Errorf("invalid binary model: %w", reader.err)
+	}
+	if reader.offset != len(data) {
+		return Model{}, fmt.Errorf("invalid binary model: %d trailing bytes", len(data)-reader.offset)
+	}
+
+	model, err := decodeJSON(metadata)
+	if err != nil {
+		return Model{}, err
+	}
+	model.Agent.Tables, model.Agent.Weights = tables, weights
+	return model, model.validate()
+}
+
+// stringTable numbers the distinct table names, state keys, action keys and
+// weight names of a model.
+type stringTable struct {
+	values []string
+	index  map[string]uint64
+}
+
+func newStringTable(tables map[string]map[string]map[string]float64, weights map[string][]float64) stringTable {
+	seen := make(map[string]bool)
+	for name, table := range tables {
+		seen[name] = true
+		for stateKey, actions := range table {
+			seen[stateKey] = true
+			for actionKey := range actions {
+				seen[actionKey] = true
+			}
+		}
+	}
+	for name := range weights {
+		seen[name] = true
+	}
+
+	strs := stringTable{values: sortedKeys(seen), index: make(map[string]uint64, len(seen))}
+	for i, value := range strs.values {
+		strs.index[value] = uint64(i)
+	}
+	return strs
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func putUvarint(buffer *bytes.Buffer, value uint64) {
+	buffer.Write(binary.AppendUvarint(nil, value))
+}
+
+func putBytes(buffer *bytes.Buffer, value []byte) {
+	putUvarint(buffer, uint64(len(value)))
+	buffer.Write(value)
+}
+
+func putFloat(buffer *bytes.Buffer, value float64) {
+	buffer.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(value)))
+}
+
+// binaryReader reads the binary layout, remembering the first error so the
+// decoder can check once at the end.
+type binaryReader struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+func (reader *binaryReader) readByte() byte {
+	if reader.err != nil || reader.offset >= len(reader.data) {
+		reader.fail()
+		return 0
+	}
+	value := reader.data[reader.offset]
+	reader.offset++
+	return value
+}
+
+func (reader *binaryReader) readUvarint() uint64 {
+	if reader.err != nil {
+		return 0
+	}
+	value, n := binary.Uvarint(reader.data[reader.offset:])
+	if n <= 0 {
+		reader.fail()
+		return 0
+	}
+	reader.offset += n
+	return value
+}
+
+// readCount reads a length. Every counted item takes at least one byte, so a
+// count larger than what is left is corrupt, and rejecting it keeps a bad
+// file from triggering a huge allocation.
+func (reader *binaryReader) readCount() int {
+	value := reader.readUvarint()
+	if value > uint64(len(reader.data)-reader.offset) {
+		reader.fail()
+		return 0
+	}
+	return int(value)
+}
+
+func (reader *binaryReader) readBytes() []byte {
+	length := reader.readCount()
+	if reader.err != nil {
+		return nil
+	}
+	value := reader.data[reader.offset : reader.offset+length]
+	reader.offset += length
+	return value
+}
+
+func (reader *binaryReader) readFloat() float64 {
+	if reader.err != nil || len(reader.data)-reader.offset < 8 {
+		reader.fail()
+		return 0
+	}
+	value := binary.LittleEndian.Uint64(reader.data[reader.offset:])
+	reader.offset += 8
+	return math.Float64frombits(value)
+}
+
+func (reader *binaryReader) fail() {
+	if reader.err == nil {
+		reader.err = errTruncated
+	}
+}
@@ -0,0 +1,145 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"textlib-rl-system/internal/rl"
+)
+
+var allEncodings = []Encoding{
+	{Format: FormatJSON},
+	{Format: FormatJSON, Compress: true},
+	{Format: FormatBinary},
+	{Format: FormatBinary, Compress: true},
+}
+
+func encodeJSON(t *testing.T, model Model) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	if err := Encode(&buffer, model); err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+func TestEncoding_RoundTripsTablesAndWeights(t *testing.T) {
+	for _, kind := range []string{rl.AgentKindQLearning, rl.AgentKindDoubleQ, rl.AgentKindDQN, rl.AgentKindLinearQ} {
+		saved := New(trainedSystem(t, kind))
+		want := encodeJSON(t, saved)
+		for _, encoding := range allEncodings {
+			path := filepath.Join(t.TempDir(), "model"+encoding.Extension())
+			if err := Save(path, saved, encoding); err != nil {
+				t.Fatalf("%s: Save(%+v) returned error: %v", kind, encoding, err)
+			}
+			loaded, err := Load(path)
+			if err != nil {
+				t.Fatalf("%s: Load(%+v) returned error: %v", kind, encoding, err)
+			}
+			if got := encodeJSON(t, loaded); !bytes.Equal(got, want) {
+				t.Errorf("%s: model changed across a %+v round trip", kind, encoding)
+			}
+			if _, err := loaded.NewSystem(); err != nil {
+				t.Errorf("%s: NewSystem after a %+v round trip returned error: %v", kind, encoding, err)
+			}
+		}
+	}
+}
+
+func TestEncoding_BinaryKeepsExactValues(t *testing.T) {
+	saved := Model{
+		Version: Version,
+		Agent: Agent{
+			Kind:    rl.AgentKindQLearning,
+			Actions: []string{"detect_code_analysis"},
+			Tables: map[string]map[string]map[string]float64{
+				"q": {"state": {"detect_code_analysis": math.Pi, "validate_output_validation": -1e-300}},
+			},
+			Weights: map[string][]float64{"w": {math.MaxFloat64, math.SmallestNonzeroFloat64, 0}},
+		},
+	}
+	var buffer bytes.Buffer
+	if err := EncodeBinary(&buffer, saved); err != nil {
+		t.Fatalf("EncodeBinary returned error: %v", err)
+	}
+	loaded, err := Decode(&buffer)
+	if err != nil {
+		t.Fatalf("Decode returned error: %v", err)
+	}
+	if got := loaded.Agent.Tables["q"]["state"]; got["detect_code_analysis"] != math.Pi || got["validate_output_validation"] != -1e-300 {
+		t.Errorf("Table values changed: %v", got)
+	}
+	if got := loaded.Agent.Weights["w"]; got[0] != math.MaxFloat64 || got[1] != math.SmallestNonzeroFloat64 || got[2] != 0 {
+		t.Errorf("Weights changed: %v", got)
+	}
+}
+
+func TestEncoding_BinaryIsCompactAndDeterministic(t *testing.T) {
+	// A long run visits far more states than a test can train on
+	saved := New(trainedSystem(t, rl.AgentKindQLearning))
+	table := saved.Agent.Tables["q"]
+	for i := 0; i < 2000; i++ {
+		actions := make(map[string]float64, len(saved.Agent.Actions))
+		for j, actionKey := range saved.Agent.Actions {
+			actions[actionKey] = float64(i*j) / 7
+		}
+		table[fmt.Sprintf("%016x", uint64(i)*0x9e3779b97f4a7c15)] = actions
+	}
+	sizes := make(map[Encoding]int)
+	for _, encoding := range allEncodings {
+		var first, second bytes.Buffer
+		if err := Write(&first, saved, encoding); err != nil {
+			t.Fatalf("Write(%+v) returned error: %v", encoding, err)
+		}
+		Write(&second, saved, encoding)
+		if !bytes.Equal(first.Bytes(), second.Bytes()) {
+			t.Errorf("Encoding %+v is not deterministic", encoding)
+		}
+		sizes[encoding] = first.Len()
+	}
+	t.Logf("Encoded sizes: %v", sizes)
+
+	json, binary := sizes[Encoding{Format: FormatJSON}], sizes[Encoding{Format: FormatBinary}]
+	if binary >= json/2 {
+		t.Errorf("Expected binary (%d bytes) to be under half of JSON (%d bytes)", binary, json)
+	}
+	if compressed := sizes[Encoding{Format: FormatBinary, Compress: true}]; compressed >= binary {
+		t.Errorf("Expected compression to shrink the binary model, got %d from %d bytes", compressed, binary)
+	}
+}
+
+func TestEncoding_RejectsCorruptBinary(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := EncodeBinary(&buffer, New(trainedSystem(t, rl.AgentKindQLearning))); err != nil {
+		t.Fatalf("EncodeBinary returned error: %v", err)
+	}
+	encoded := buffer.Bytes()
+
+	for length := len(binaryMagic); length < len(encoded); length++ {
+		if _, err := Decode(bytes.NewReader(encoded[:length])); err == nil {
+			t.Fatalf("Expected a model truncated to %d of %d bytes to be rejected", length, len(encoded))
+		}
+	}
+	if _, err := Decode(bytes.NewReader(append(encoded, 0))); err == nil {
+		t.Error("Expected trailing bytes to be rejected")
+	}
+	future := append([]byte(nil), encoded...)
+	future[len(binaryMagic)] = binaryLayout + 1
+	if _, err := Decode(bytes.NewReader(future)); err == nil {
+		t.Error("Expected an unknown layout to be rejected")
+	}
+}
+
+func TestEncoding_FileExtensions(t *testing.T) {
+	for _, encoding := range allEncodings {
+		if got := EncodingFor("model" + encoding.Extension()); got != encoding {
+			t.Errorf("EncodingFor(%q) = %+v, want %+v", "model"+encoding.Extension(), got, encoding)
+		}
+	}
+	if err := (Encoding{Format: "protobuf"}).Validate(); err == nil {
+		t.Error("Expected an unknown format to be rejected")
+	}
+}
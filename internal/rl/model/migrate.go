@@ -0,0 +1,106 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"textlib-rl-system/internal/rl"
+)
+
+// modelV1_0 is the file the first release wrote: the Q-learning agent's raw
+// table, keyed by hashed state text and "function_category" actions.
+type modelV1_0 struct {
+	QTable    map[string]map[string]float64 `json:"q_table"`
+	Config    rl.SystemConfig               `json:"config"`
+	Timestamp time.Time                     `json:"timestamp"`
+}
+
+// modelV1_1 added pluggable agents, saved as snapshots, and seeded training.
+type modelV1_1 struct {
+	Agent           rl.AgentSnapshot         `json:"agent"`
+	Config          rl.SystemConfig          `json:"config"`
+	Seed            int64                    `json:"seed"`
+	Timestamp       time.Time                `json:"timestamp"`
+	ParameterTuning *rl.ParameterTuningState `json:"parameter_tuning,omitempty"`
+}
+
+// migrateV1_0 loads a 1.0 table as a Q-learning agent on the text_hash
+// scheme. Those agents chose among plain actions, so the config pins one
+// parameter level. The file kept no hyperparameters; the agent defaults are
+// the values 1.0 trained with. Neither old version recorded how many episodes
+// ran, so migrated models report the configured budget.
+func migrateV1_0(data []byte) (Model, error) {
+	var old modelV1_0
+	if err := json.Unmarshal(data, &old); err != nil {
+		return Model{}, fmt.Errorf("invalid 1.0 model: %w", err)
+	}
+	if old.QTable == nil {
+		return Model{}, fmt.Errorf("1.0 model has no q_table")
+	}
+
+	config := legacyConfig(old.Config)
+	config.AgentType = rl.AgentKindQLearning
+	config.ParameterLevels = 1
+	return Model{
+		Version: Version,
+		Agent: Agent{
+			Kind:            rl.AgentKindQLearning,
+			Hyperparameters: map[string]float64{},
+			StateKeys:       rl.StateKeysTextHash,
+			Actions:         rl.NewActionSpace(config).Keys(),
+			Tables:          map[string]map[string]map[string]float64{"q": old.QTable},
+			Options:         map[string]string{"state_keys": rl.StateKeysTextHash},
+		},
+		Training: Training{
+			Episodes:     config.MaxEpisodes,
+			SavedAt:      old.Timestamp,
+			MigratedFrom: "1.0",
+		},
+		Config: config,
+	}, nil
+}
+
+// migrateV1_1 types a 1.1 snapshot. The action list was not saved, but the
+// action space is determined by the config, so it is rebuilt from that.
+func migrateV1_1(data []byte) (Model, error) {
+	var old modelV1_1
+	if err := json.Unmarshal(data, &old); err != nil {
+		return Model{}, fmt.Errorf("invalid 1.1 model: %w", err)
+	}
+
+	config := legacyConfig(old.Config)
+	config.AgentType = old.Agent.Kind
+	return Model{
+		Version: Version,
+		Agent:   fromSnapshot(old.Agent, rl.NewActionSpace(config).Keys()),
+		Training: Training{
+			Episodes:     config.MaxEpisodes,
+			Seed:         old.Seed,
+			SavedAt:      old.Timestamp,
+			MigratedFrom: "1.1",
+		},
+		Config:          config,
+		ParameterTuning: old.ParameterTuning,
+	}, nil
+}
+
+// legacyConfig reads a pre-2.0 config, which stored every unset setting as
+// zero. Zero is a valid value for the optional settings now, so those are
+// cleared back to unset to keep the defaults the model trained with.
+func legacyConfig(config rl.SystemConfig) rl.SystemConfig {
+	for _, field := range []**float64{
+		&config.DiscountFactor, &config.ExplorationRate, &config.MinExploration, &config.TraceLambda,
+		&config.L2Regularization, &config.EntropyCoefficient,
+		&config.PriorityAlpha, &config.PriorityBeta,
+		&config.CurriculumStart, &config.CurriculumRewardThreshold,
+	} {
+		if *field != nil && **field == 0 {
+			*field = nil
+		}
+	}
+	if config.PlanningSteps != nil && *config.PlanningSteps == 0 {
+		config.PlanningSteps = nil
+	}
+	return config
+}
@@ -0,0 +1,221 @@
+// Package model saves trained agents and loads them back for inference.
+//
+// A model file is a versioned JSON document, or the same model in a compact
+// binary layout, either of them optionally gzipped (see Encoding). Version
+// 2.0 is the typed schema in this package; files written by earlier releases
+// are migrated forward when they are decoded:
+//
+//   - 1.0 files hold a bare Q-table keyed by a hash of the state text, next
+//     to the config and a timestamp. They load as a Q-learning agent that
+//     keeps the text_hash state-key scheme, so the table still matches.
+//   - 1.1 files hold an agent snapshot, the config, the seed and the
+//     parameter tuner's state.
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"time"
+
+	"textlib-rl-system/internal/rl"
+)
+
+// Version is the schema version this package writes.
+const Version = "2.0"
+
+// Model is a trained agent together with everything needed to rebuild it:
+// the configuration it was trained with and the tuned action parameters.
+type Model struct {
+	Version         string                   `json:"version"`
+	Agent           Agent                    `json:"agent"`
+	Training        Training                 `json:"training"`
+	Config          rl.SystemConfig          `json:"config"`
+	ParameterTuning *rl.ParameterTuningState `json:"parameter_tuning,omitempty"`
+}
+
+// Agent is the learned state of an agent. Tables hold Q-values by table name,
+// state key and action key; Weights hold the parameters of agents that
+// generalize across states.
+type Agent struct {
+	Kind            string                                   `json:"kind"`
+	Hyperparameters map[string]float64                       `json:"hyperparameters"`
+	StateKeys       string                                   `json:"state_keys"` // featurizer name, or text_hash
+	Actions         []string                                 `json:"actions"`    // action keys, in action-space order
+	Tables          map[string]map[string]map[string]float64 `json:"tables,omitempty"`
+	Weights         map[string][]float64                     `json:"weights,omitempty"`
+	Options         map[string]string                        `json:"options,omitempty"`
+}
+
+// Training describes the run that produced a model. MigratedFrom is the
+// version of the file the model was read from when it predates Version.
+type Training struct {
+	Episodes          int                   `json:"episodes"`
+	Seed              int64                 `json:"seed"`
+	Executor          string                `json:"executor,omitempty"`
+	TrainingData      string                `json:"training_data,omitempty"` // fingerprint of the examples
+	ValidationHistory []rl.EvaluationResult `json:"validation_history,omitempty"`
+	SavedAt           time.Time             `json:"saved_at"`
+	MigratedFrom      string                `json:"migrated_from,omitempty"`
+}
+
+// New captures the trained state of system.
+func New(system *rl.EnhancedRLSystem) Model {
+	checkpoint := system.Checkpoint()
+	return Model{
+		Version: Version,
+		Agent:   fromSnapshot(checkpoint.Agent, rl.NewActionSpace(checkpoint.Config).Keys()),
+		Training: Training{
+			Episodes:          checkpoint.Episode,
+			Seed:              checkpoint.Seed,
+			Executor:          checkpoint.Executor,
+			TrainingData:      checkpoint.TrainingData,
+			ValidationHistory: checkpoint.ValidationHistory,
+			SavedAt:           checkpoint.Timestamp,
+		},
+		Config:          checkpoint.Config,
+		ParameterTuning: checkpoint.ParameterTuning,
+	}
+}
+
+// fromSnapshot types an agent snapshot. The state-key scheme is read from
+// the options the agents write.
+func fromSnapshot(snapshot rl.AgentSnapshot, actions []string) Agent {
+	stateKeys := snapshot.Options["state_keys"]
+	if stateKeys == "" {
+		stateKeys = snapshot.Options["state_featurizer"]
+	}
+	return Agent{
+		Kind:            snapshot.Kind,
+		Hyperparameters: snapshot.Hyperparameters,
+		StateKeys:       stateKeys,
+		Actions:         actions,
+		Tables:          snapshot.Tables,
+		Weights:         snapshot.Weights,
+		Options:         snapshot.Options,
+	}
+}
+
+// Snapshot returns the agent in the form Agent.Restore takes.
+func (agent Agent) Snapshot() rl.AgentSnapshot {
+	options := make(map[string]string, len(agent.Options)+1)
+	for name, value := range agent.Options {
+		options[name] = value
+	}
+	if agent.StateKeys == rl.StateKeysTextHash {
+		options["state_keys"] = agent.StateKeys
+	}
+	return rl.AgentSnapshot{
+		Kind:            agent.Kind,
+		Hyperparameters: agent.Hyperparameters,
+		Tables:          agent.Tables,
+		Weights:         agent.Weights,
+		Options:         options,
+	}
+}
+
+// NewSystem rebuilds the trained system for inference: the agent restored
+// from the model and the tuned parameters applied. Query it with BestAction,
+// GreedySequence or Evaluate. It runs actions on the simulator without its
+// simulated latency, since inference only needs their outputs; SetExecutor
+// switches it to another executor.
+func (model Model) NewSystem() (*rl.EnhancedRLSystem, error) {
+	config := model.Config
+	config.AgentType = model.Agent.Kind
+
+	if len(model.Agent.Actions) > 0 {
+		if actions := rl.NewActionSpace(config).Keys(); !reflect.DeepEqual(actions, model.Agent.Actions) {
+			return nil, fmt.Errorf("model was trained on %d actions, its config builds %d different ones",
+				len(model.Agent.Actions), len(actions))
+		}
+	}
+	agent, err := rl.NewAgent(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := agent.Restore(model.Agent.Snapshot()); err != nil {
+		return nil, fmt.Errorf("restoring %s agent: %w", model.Agent.Kind, err)
+	}
+
+	system := rl.NewEnhancedRLSystem(config)
+	system.SetAgent(agent)
+	system.SetExecutor(rl.NewInstantSimulator(config))
+	if model.ParameterTuning != nil {
+		system.RestoreParameterTuning(*model.ParameterTuning)
+	}
+	return system, nil
+}
+
+// NewAgent rebuilds the trained agent.
+func (model Model) NewAgent() (rl.Agent, error) {
+	system, err := model.NewSystem()
+	if err != nil {
+		return nil, err
+	}
+	return system.Agent, nil
+}
+
+// Encode writes model as indented JSON.
+func Encode(w io.Writer, model Model) error {
+	data, err := json.MarshalIndent(model, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// decodeJSON reads a JSON model of any supported version, migrating older
+// versions to the current schema. Callers validate the result.
+func decodeJSON(data []byte) (Model, error) {
+	var header struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return Model{}, fmt.Errorf("invalid model: %w", err)
+	}
+
+	switch header.Version {
+	case Version:
+		var model Model
+		if err := json.Unmarshal(data, &model); err != nil {
+			return Model{}, fmt.Errorf("invalid model: %w", err)
+		}
+		return model, nil
+	case "1.0":
+		return migrateV1_0(data)
+	case "1.1":
+		return migrateV1_1(data)
+	case "":
+		return Model{}, fmt.Errorf("model has no version")
+	default:
+		return Model{}, fmt.Errorf("unsupported model version %q (this build reads up to %s)", header.Version, Version)
+	}
+}
+
+// Load reads the model at path.
+func Load(path string) (Model, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Model{}, err
+	}
+	defer file.Close()
+
+	model, err := Decode(file)
+	if err != nil {
+		return Model{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return model, nil
+}
+
+func (model Model) validate() error {
+	if model.Agent.Kind == "" {
+		return fmt.Errorf("model has no agent kind")
+	}
+	if len(model.Agent.Tables) == 0 && len(model.Agent.Weights) == 0 {
+		return fmt.Errorf("%s model has neither tables nor weights", model.Agent.Kind)
+	}
+	return nil
+}
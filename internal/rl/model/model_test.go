@@ -0,0 +1,233 @@
+package model
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"textlib-rl-system/internal/logging"
+	"textlib-rl-system/internal/rl"
+)
+
+func trainedSystem(t *testing.T, kind string) *rl.EnhancedRLSystem {
+	t.Helper()
+	config := rl.SystemConfig{
+		AgentType:          kind,
+		MaxEpisodes:        20,
+		MaxStepsPerEpisode: 5,
+		LoggingInterval:    100,
+		CheckpointInterval: 100,
+		HiddenSizes:        []int{8},
+		BatchSize:          4,
+		Seed:               3,
+	}
+	agent, err := rl.NewAgent(config)
+	if err != nil {
+		t.Fatalf("NewAgent(%s) returned error: %v", kind, err)
+	}
+	system := rl.NewEnhancedRLSystem(config)
+	system.SetAgent(agent)
+	system.SetExecutor(rl.NewInstantSimulator(config))
+	system.SetLogger(logging.NewInsightLogger(t.TempDir(), 10, time.Second))
+	system.LoadTrainingData(rl.GetRealisticTrainingData())
+	system.TrainWithLogging()
+	return system
+}
+
+func initialState(example rl.TrainingExample) rl.State {
+	return rl.State{
+		Text:            example.Text,
+		TaskType:        example.TaskType,
+		ActionsUsed:     []string{},
+		CurrentResults:  make(map[string]interface{}),
+		RemainingBudget: 50,
+	}
+}
+
+func bestActions(t *testing.T, system *rl.EnhancedRLSystem) []string {
+	t.Helper()
+	var actions []string
+	for _, example := range rl.GetRealisticTrainingData() {
+		action, ok := system.BestAction(initialState(example))
+		if !ok {
+			t.Fatalf("No action available for %s", example.ID)
+		}
+		actions = append(actions, fmt.Sprintf("%s_%s", action.FunctionName, action.Category))
+	}
+	return actions
+}
+
+func TestModel_RoundTripRebuildsEveryAgentKind(t *testing.T) {
+	for _, kind := range rl.AgentKinds() {
+		t.Run(kind, func(t *testing.T) {
+			system := trainedSystem(t, kind)
+			saved := New(system)
+			if saved.Version != Version || saved.Agent.Kind != kind || saved.Training.Episodes != 20 {
+				t.Fatalf("Unexpected model header: version %s, kind %s, %d episodes",
+					saved.Version, saved.Agent.Kind, saved.Training.Episodes)
+			}
+			if len(saved.Agent.Actions) == 0 || saved.Agent.StateKeys == "" {
+				t.Fatalf("Model is missing its actions or state-key scheme: %+v", saved.Agent)
+			}
+
+			path := filepath.Join(t.TempDir(), "model.json")
+			if err := Save(path, saved, Encoding{}); err != nil {
+				t.Fatalf("Save returned error: %v", err)
+			}
+			loaded, err := Load(path)
+			if err != nil {
+				t.Fatalf("Load returned error: %v", err)
+			}
+			var first, second bytes.Buffer
+			Encode(&first, saved)
+			Encode(&second, loaded)
+			if !bytes.Equal(first.Bytes(), second.Bytes()) {
+				t.Fatal("Model changed across a save and load")
+			}
+
+			rebuilt, err := loaded.NewSystem()
+			if err != nil {
+				t.Fatalf("NewSystem returned error: %v", err)
+			}
+			if want, got := bestActions(t, system), bestActions(t, rebuilt); !reflect.DeepEqual(want, got) {
+				t.Errorf("Rebuilt agent picks %v, trained agent picked %v", got, want)
+			}
+		})
+	}
+}
+
+func TestModel_NewSystemRunsWithoutLatency(t *testing.T) {
+	rebuilt, err := New(trainedSystem(t, rl.AgentKindQLearning)).NewSystem()
+	if err != nil {
+		t.Fatalf("NewSystem returned error: %v", err)
+	}
+	start := time.Now()
+	sequence := rebuilt.GreedySequence(rl.GetRealisticTrainingData()[0])
+	if elapsed := time.Since(start); len(sequence) == 0 || elapsed >= 50*time.Millisecond {
+		t.Errorf("Expected an instant greedy sequence, got %d actions in %v", len(sequence), elapsed)
+	}
+}
+
+func TestModel_MigratesVersion1_0(t *testing.T) {
+	example := rl.GetRealisticTrainingData()[0]
+	state := initialState(example)
+	data := fmt.Sprintf("%s|%s|%d|%d", state.TaskType, state.Text[:100], state.StepCount, state.RemainingBudget)
+	hash := sha256.Sum256([]byte(data))
+	stateKey := hex.EncodeToString(hash[:])[:16]
+
+	legacy, _ := json.Marshal(map[string]interface{}{
+		"q_table": map[string]map[string]float64{
+			stateKey: {"extract_entities_analysis": 1.5, "detect_code_analysis": 4.0},
+		},
+		"config":    map[string]interface{}{"MaxEpisodes": 500, "MaxStepsPerEpisode": 10},
+		"timestamp": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		"version":   "1.0",
+	})
+
+	migrated, err := Decode(bytes.NewReader(legacy))
+	if err != nil {
+		t.Fatalf("Decode returned error: %v", err)
+	}
+	if migrated.Version != Version || migrated.Training.MigratedFrom != "1.0" {
+		t.Errorf("Expected a %s model migrated from 1.0, got %s from %q", Version, migrated.Version, migrated.Training.MigratedFrom)
+	}
+	if migrated.Agent.Kind != rl.AgentKindQLearning || migrated.Agent.StateKeys != rl.StateKeysTextHash {
+		t.Errorf("Expected a qlearning agent on text_hash keys, got %s on %s", migrated.Agent.Kind, migrated.Agent.StateKeys)
+	}
+	if want := rl.DefaultActionSpace().Keys(); !reflect.DeepEqual(migrated.Agent.Actions, want) {
+		t.Errorf("Expected the 1.0 actions %v, got %v", want, migrated.Agent.Actions)
+	}
+	if migrated.Training.Episodes != 500 {
+		t.Errorf("Expected the configured 500 episodes, got %d", migrated.Training.Episodes)
+	}
+
+	system, err := migrated.NewSystem()
+	if err != nil {
+		t.Fatalf("NewSystem returned error: %v", err)
+	}
+	action, ok := system.BestAction(state)
+	if !ok || action.FunctionName != "detect_code" || len(action.Parameters) != 0 {
+		t.Errorf("Expected the migrated table to pick plain detect_code, got %+v", action)
+	}
+
+	// Re-saving keeps the legacy key scheme
+	var resaved bytes.Buffer
+	if err := Encode(&resaved, migrated); err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+	reloaded, err := Decode(&resaved)
+	if err != nil {
+		t.Fatalf("Decode of the migrated model returned error: %v", err)
+	}
+	if agent, err := reloaded.NewAgent(); err != nil {
+		t.Fatalf("NewAgent returned error: %v", err)
+	} else if agent.Snapshot().Options["state_keys"] != rl.StateKeysTextHash {
+		t.Error("Re-saved 1.0 model lost its state-key scheme")
+	}
+}
+
+func TestModel_MigratesVersion1_1(t *testing.T) {
+	system := trainedSystem(t, rl.AgentKindSARSA)
+	legacy, _ := json.Marshal(map[string]interface{}{
+		"agent":     system.Agent.Snapshot(),
+		"config":    system.Config,
+		"seed":      system.Config.Seed,
+		"timestamp": time.Now(),
+		"version":   "1.1",
+	})
+
+	migrated, err := Decode(bytes.NewReader(legacy))
+	if err != nil {
+		t.Fatalf("Decode returned error: %v", err)
+	}
+	if migrated.Training.MigratedFrom != "1.1" || migrated.Training.Seed != 3 {
+		t.Errorf("Expected a seed-3 model migrated from 1.1, got seed %d from %q",
+			migrated.Training.Seed, migrated.Training.MigratedFrom)
+	}
+	if want := rl.NewActionSpace(system.Config).Keys(); !reflect.DeepEqual(migrated.Agent.Actions, want) {
+		t.Errorf("Expected actions rebuilt from the config, got %v", migrated.Agent.Actions)
+	}
+
+	rebuilt, err := migrated.NewSystem()
+	if err != nil {
+		t.Fatalf("NewSystem returned error: %v", err)
+	}
+	if want, got := bestActions(t, system), bestActions(t, rebuilt); !reflect.DeepEqual(want, got) {
+		t.Errorf("Migrated agent picks %v, trained agent picked %v", got, want)
+	}
+}
+
+func TestModel_RejectsUnusableFiles(t *testing.T) {
+	system := trainedSystem(t, rl.AgentKindQLearning)
+	mismatched := New(system)
+	mismatched.Config.ParameterLevels = 2
+
+	var encoded bytes.Buffer
+	Encode(&encoded, mismatched)
+	decoded, err := Decode(&encoded)
+	if err != nil {
+		t.Fatalf("Decode returned error: %v", err)
+	}
+	if _, err := decoded.NewSystem(); err == nil || !strings.Contains(err.Error(), "actions") {
+		t.Errorf("Expected an action mismatch error, got %v", err)
+	}
+
+	for name, file := range map[string]string{
+		"future version": `{"version": "9.0"}`,
+		"no version":     `{"agent": {"kind": "qlearning"}}`,
+		"no agent":       `{"version": "2.0"}`,
+		"no q_table":     `{"version": "1.0", "config": {}}`,
+		"not json":       `version 2.0`,
+	} {
+		if _, err := Decode(strings.NewReader(file)); err == nil {
+			t.Errorf("Expected %s to be rejected", name)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package rl
+
+import (
+	"textlib-rl-system/internal/logging"
+)
+
+const (
+	AgentKindMCFirstVisit = "mc_first_visit"
+	AgentKindMCEveryVisit = "mc_every_visit"
+)
+
+func init() {
+	RegisterAgent(AgentKindMCFirstVisit, func(config SystemConfig) Agent {
+		return NewMonteCarloAgent(newQLearningAgentFromConfig(config), true)
+	})
+	RegisterAgent(AgentKindMCEveryVisit, func(config SystemConfig) Agent {
+		return NewMonteCarloAgent(newQLearningAgentFromConfig(config), false)
+	})
+}
+
+// MonteCarloAgent is on-policy Monte Carlo control. It does not bootstrap:
+// Q(s, a) is the running average of the complete discounted returns that
+// followed (s, a), so the whole call sequence's reward is credited to every
+// call in it. With FirstVisit only the first occurrence of a pair in an
+// episode contributes a return.
+//
+// Q-values live in the shared QTable, so logs and reports read them exactly
+// as they do for Q-learning. Visit counts are kept alongside in Visits.
+type MonteCarloAgent struct {
+	*QLearningAgent
+	FirstVisit bool
+	Visits     map[string]map[string]float64
+
+	episode []monteCarloStep
+}
+
+type monteCarloStep struct {
+	stateKey  string
+	actionKey string
+	reward    float64
+}
+
+func NewMonteCarloAgent(base *QLearningAgent, firstVisit bool) *MonteCarloAgent {
+	return &MonteCarloAgent{
+		QLearningAgent: base,
+		FirstVisit:     firstVisit,
+		Visits:         make(map[string]map[string]float64),
+	}
+}
+
+func (agent *MonteCarloAgent) kind() string {
+	if agent.FirstVisit {
+		return AgentKindMCFirstVisit
+	}
+	return AgentKindMCEveryVisit
+}
+
+// Update only records the step; learning happens in EndEpisode.
+func (agent *MonteCarloAgent) Update(state State, action Action, reward float64, nextState State, done bool) logging.LearningMetrics {
+	agent.episode = append(agent.episode, monteCarloStep{
+		stateKey:  agent.getStateKey(state),
+		actionKey: agent.getActionKey(action),
+		reward:    reward,
+	})
+	agent.Exploration.EndStep()
+
+	return agent.learningMetrics()
+}
+
+func (agent *MonteCarloAgent) EndEpisode(episode logging.EpisodeMetrics) {
+	returns := make([]float64, len(agent.episode))
+	future := 0.0
+	for t := len(agent.episode) - 1; t >= 0; t-- {
+		future = agent.episode[t].reward + agent.DiscountFactor*future
+		returns[t] = future
+	}
+
+	seen := make(map[string]map[string]bool)
+	for t, step := range agent.episode {
+		if agent.FirstVisit {
+			if seen[step.stateKey][step.actionKey] {
+				continue
+			}
+			if seen[step.stateKey] == nil {
+				seen[step.stateKey] = make(map[string]bool)
+			}
+			seen[step.stateKey][step.actionKey] = true
+		}
+		agent.average(step.stateKey, step.actionKey, returns[t])
+	}
+
+	agent.episode = agent.episode[:0]
+	agent.QLearningAgent.EndEpisode(episode)
+}
+
+// average folds one more return into the sample mean for (state, action).
+func (agent *MonteCarloAgent) average(stateKey, actionKey string, sampleReturn float64) {
+	if agent.Visits[stateKey] == nil {
+		agent.Visits[stateKey] = make(map[string]float64)
+	}
+	if agent.QTable[stateKey] == nil {
+		agent.QTable[stateKey] = make(map[string]float64)
+	}
+	agent.Visits[stateKey][actionKey]++
+	count := agent.Visits[stateKey][actionKey]
+	current := agent.QTable[stateKey][actionKey]
+	agent.QTable[stateKey][actionKey] = current + (sampleReturn-current)/count
+}
+
+func (agent *MonteCarloAgent) Snapshot() AgentSnapshot {
+	snapshot := agent.snapshotAs(agent.kind())
+	snapshot.Tables["visits"] = copyQTable(agent.Visits)
+	return snapshot
+}
+
+func (agent *MonteCarloAgent) Restore(snapshot AgentSnapshot) error {
+	if err := agent.restoreAs(agent.kind(), snapshot); err != nil {
+		return err
+	}
+	agent.Visits = copyQTable(snapshot.Tables["visits"])
+	agent.episode = nil
+	return nil
+}
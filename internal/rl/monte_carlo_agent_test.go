@@ -0,0 +1,71 @@
+package rl
+
+import (
+	"math"
+	"testing"
+
+	"textlib-rl-system/internal/logging"
+)
+
+func TestMonteCarloAgent_UpdatesFromCompleteReturns(t *testing.T) {
+	agent := NewMonteCarloAgent(NewQLearningAgent(0.1, 0.5, 0.0, 0.0, 1.0), true)
+	states := stepStates("Monte Carlo return text.", 3)
+	action := getDefaultActions()[0]
+
+	agent.Update(states[0], action, 1.0, states[1], false)
+	agent.Update(states[1], action, 4.0, states[2], true)
+	if got := agent.GetQValue(states[0], action); got != 0.0 {
+		t.Fatalf("Expected no update before the episode ends, got %f", got)
+	}
+	agent.EndEpisode(logging.EpisodeMetrics{})
+
+	// G0 = 1 + 0.5*4 = 3, G1 = 4; first returns are taken as-is
+	if got := agent.GetQValue(states[0], action); math.Abs(got-3.0) > 1e-9 {
+		t.Errorf("Expected Q(s0) = 3.0, got %f", got)
+	}
+	if got := agent.GetQValue(states[1], action); math.Abs(got-4.0) > 1e-9 {
+		t.Errorf("Expected Q(s1) = 4.0, got %f", got)
+	}
+
+	// A second episode with return 1 from s0 averages to 2
+	agent.Update(states[0], action, 1.0, states[1], true)
+	agent.EndEpisode(logging.EpisodeMetrics{})
+	if got := agent.GetQValue(states[0], action); math.Abs(got-2.0) > 1e-9 {
+		t.Errorf("Expected averaged Q(s0) = 2.0, got %f", got)
+	}
+}
+
+func TestMonteCarloAgent_FirstVersusEveryVisit(t *testing.T) {
+	state := testState("Repeated visit text.")
+	action := getDefaultActions()[1]
+
+	run := func(firstVisit bool) *MonteCarloAgent {
+		agent := NewMonteCarloAgent(NewQLearningAgent(0.1, 1.0, 0.0, 0.0, 1.0), firstVisit)
+		// The same pair is visited twice: returns are 3 then 2
+		agent.Update(state, action, 1.0, state, false)
+		agent.Update(state, action, 2.0, state, true)
+		agent.EndEpisode(logging.EpisodeMetrics{})
+		return agent
+	}
+
+	first := run(true)
+	if got := first.GetQValue(state, action); got != 3.0 {
+		t.Errorf("Expected first-visit Q = 3.0, got %f", got)
+	}
+	every := run(false)
+	if got := every.GetQValue(state, action); got != 2.5 {
+		t.Errorf("Expected every-visit Q = 2.5, got %f", got)
+	}
+
+	snapshot := every.Snapshot()
+	if snapshot.Kind != AgentKindMCEveryVisit || snapshot.Tables["visits"][every.getStateKey(state)][every.getActionKey(action)] != 2 {
+		t.Errorf("Expected every-visit snapshot with 2 visits, got %+v", snapshot.Tables["visits"])
+	}
+	restored := NewMonteCarloAgent(NewQLearningAgent(0.1, 1.0, 0.0, 0.0, 1.0), false)
+	if err := restored.Restore(snapshot); err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	if restored.GetQValue(state, action) != 2.5 || restored.Visits[every.getStateKey(state)][every.getActionKey(action)] != 2 {
+		t.Error("Restored Q-values or visit counts do not match")
+	}
+}
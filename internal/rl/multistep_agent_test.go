@@ -0,0 +1,132 @@
+package rl
+
+import (
+	"math"
+	"testing"
+
+	"textlib-rl-system/internal/logging"
+)
+
+func stepStates(text string, count int) []State {
+	states := make([]State, count)
+	for i := range states {
+		states[i] = testState(text)
+		states[i].StepCount = i
+		for j := 0; j < i; j++ {
+			states[i].ActionsUsed = append(states[i].ActionsUsed, getDefaultActions()[j].FunctionName)
+		}
+	}
+	return states
+}
+
+func TestNStepQAgent_BacksUpNStepReturn(t *testing.T) {
+	agent := NewNStepQAgent(NewQLearningAgent(1.0, 0.5, 0.0, 0.0, 1.0), 2)
+	states := stepStates("n-step return text.", 4)
+	action := getDefaultActions()[0]
+
+	agent.Update(states[0], action, 1.0, states[1], false)
+	if got := agent.GetQValue(states[0], action); got != 0.0 {
+		t.Errorf("Expected no update before n transitions, got %f", got)
+	}
+
+	agent.Update(states[1], action, 2.0, states[2], false)
+	// G = 1 + 0.5*2 + 0.25*max Q(s2) = 2
+	if got := agent.GetQValue(states[0], action); math.Abs(got-2.0) > 1e-9 {
+		t.Errorf("Expected 2-step return 2.0, got %f", got)
+	}
+
+	agent.Update(states[2], action, 4.0, states[3], true)
+	// Terminal: remaining transitions flush without bootstrapping
+	if got := agent.GetQValue(states[1], action); math.Abs(got-4.0) > 1e-9 {
+		t.Errorf("Expected flushed return 4.0 for s1, got %f", got)
+	}
+	if got := agent.GetQValue(states[2], action); math.Abs(got-4.0) > 1e-9 {
+		t.Errorf("Expected flushed return 4.0 for s2, got %f", got)
+	}
+	if len(agent.buffer) != 0 {
+		t.Errorf("Expected empty buffer after terminal step, got %d", len(agent.buffer))
+	}
+}
+
+func TestNStepQAgent_EndEpisodeFlushesTruncatedBuffer(t *testing.T) {
+	agent := NewNStepQAgent(NewQLearningAgent(1.0, 1.0, 0.0, 0.0, 1.0), 5)
+	states := stepStates("n-step truncation text.", 3)
+	action := getDefaultActions()[1]
+
+	// Bootstrap from an action that is still available in s2
+	agent.QTable[agent.getStateKey(states[2])] = map[string]float64{agent.getActionKey(getDefaultActions()[2]): 10.0}
+	agent.Update(states[0], action, 1.0, states[1], false)
+	agent.Update(states[1], action, 1.0, states[2], false)
+	agent.EndEpisode(logging.EpisodeMetrics{})
+
+	if got := agent.GetQValue(states[0], action); math.Abs(got-12.0) > 1e-9 {
+		t.Errorf("Expected truncated return 12.0, got %f", got)
+	}
+}
+
+func TestTraceAgent_PropagatesAlongTrace(t *testing.T) {
+	agent := NewTraceAgent(NewQLearningAgent(1.0, 1.0, 0.0, 0.0, 1.0), 0.5, true)
+	states := stepStates("trace propagation text.", 3)
+	action := getDefaultActions()[0]
+
+	agent.Update(states[0], action, 0.0, states[1], false)
+	next, _ := agent.SelectActionWithMetrics(states[1])
+	agent.Update(states[1], next, 2.0, states[2], true)
+
+	// The reward at step 1 reaches step 0 scaled by its trace (gamma*lambda)
+	if got := agent.GetQValue(states[0], action); math.Abs(got-1.0) > 1e-9 {
+		t.Errorf("Expected traced update 1.0 for s0, got %f", got)
+	}
+	if len(agent.traces) != 0 {
+		t.Error("Expected traces cleared after terminal step")
+	}
+}
+
+func TestTraceAgent_WatkinsCutsTracesOnExploration(t *testing.T) {
+	// Always explore: every non-terminal update must cut traces
+	agent := NewTraceAgent(NewQLearningAgent(0.5, 0.9, 1.0, 1.0, 1.0), 0.9, false)
+	states := stepStates("Watkins trace text.", 2)
+
+	agent.Update(states[0], getDefaultActions()[0], 1.0, states[1], false)
+	if len(agent.traces) != 0 {
+		t.Errorf("Expected exploratory next action to cut traces, got %d entries", len(agent.traces))
+	}
+
+	sarsa := NewTraceAgent(NewQLearningAgent(0.5, 0.9, 1.0, 1.0, 1.0), 0.9, true)
+	sarsa.Update(states[0], getDefaultActions()[0], 1.0, states[1], false)
+	if len(sarsa.traces) == 0 {
+		t.Error("Expected SARSA(λ) to keep traces after exploration")
+	}
+
+	sarsa.EndEpisode(logging.EpisodeMetrics{})
+	if len(sarsa.traces) != 0 || sarsa.next.valid {
+		t.Error("Expected EndEpisode to reset traces and pending action")
+	}
+}
+
+func TestMultiStepAgents_FromConfig(t *testing.T) {
+	config := SystemConfig{NSteps: 4, TraceLambda: Float64(0.6)}
+
+	config.AgentType = AgentKindNStepQ
+	agent, err := NewAgent(config)
+	if err != nil {
+		t.Fatalf("NewAgent returned error: %v", err)
+	}
+	if agent.(*NStepQAgent).N != 4 {
+		t.Errorf("Expected n=4, got %d", agent.(*NStepQAgent).N)
+	}
+
+	for _, kind := range []string{AgentKindQLambda, AgentKindSARSALambda} {
+		config.AgentType = kind
+		agent, err := NewAgent(config)
+		if err != nil {
+			t.Fatalf("NewAgent(%s) returned error: %v", kind, err)
+		}
+		if agent.(*TraceAgent).Lambda != 0.6 {
+			t.Errorf("Expected lambda=0.6 for %s", kind)
+		}
+		if agent.Snapshot().Kind != kind {
+			t.Errorf("Expected snapshot kind %s", kind)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package rl
+
+import (
+	"math"
+
+	"textlib-rl-system/internal/logging"
+)
+
+const AgentKindNStepQ = "nstep_q"
+
+func init() {
+	RegisterAgent(AgentKindNStepQ, func(config SystemConfig) Agent {
+		return NewNStepQAgent(newQLearningAgentFromConfig(config), config.NSteps)
+	})
+}
+
+// NStepQAgent backs up n-step returns, bootstrapping from the greedy value n
+// steps later. Sequence bonuses that only pay off on the following call reach
+// the action that set them up in a single update instead of propagating one
+// step per visit.
+type NStepQAgent struct {
+	*QLearningAgent
+	N int
+
+	buffer    []nStepTransition
+	lastState State
+}
+
+type nStepTransition struct {
+	state  State
+	action Action
+	reward float64
+}
+
+func NewNStepQAgent(base *QLearningAgent, n int) *NStepQAgent {
+	if n < 1 {
+		n = 1
+	}
+	return &NStepQAgent{
+		QLearningAgent: base,
+		N:              n,
+	}
+}
+
+func (agent *NStepQAgent) Update(state State, action Action, reward float64, nextState State, done bool) logging.LearningMetrics {
+	agent.buffer = append(agent.buffer, nStepTransition{state: state, action: action, reward: reward})
+	agent.lastState = nextState
+
+	if done {
+		agent.flush(0.0)
+	} else if len(agent.buffer) >= agent.N {
+		agent.backupOldest(agent.getMaxQValue(nextState))
+	}
+
+	agent.Exploration.EndStep()
+
+	return agent.learningMetrics()
+}
+
+// EndEpisode backs up whatever is left in the buffer when an episode is cut
+// off by the step limit, bootstrapping from the last state reached.
+func (agent *NStepQAgent) EndEpisode(episode logging.EpisodeMetrics) {
+	if len(agent.buffer) > 0 {
+		agent.flush(agent.getMaxQValue(agent.lastState))
+	}
+	agent.buffer = agent.buffer[:0]
+	agent.QLearningAgent.EndEpisode(episode)
+}
+
+// backupOldest updates the oldest buffered transition with the discounted
+// rewards in the buffer plus the discounted bootstrap value, then drops it.
+func (agent *NStepQAgent) backupOldest(bootstrap float64) {
+	target := 0.0
+	discount := 1.0
+	for _, transition := range agent.buffer {
+		target += discount * transition.reward
+		discount *= agent.DiscountFactor
+	}
+	target += discount * bootstrap
+
+	oldest := agent.buffer[0]
+	agent.moveQValue(oldest.state, oldest.action, target)
+	agent.buffer = agent.buffer[1:]
+}
+
+func (agent *NStepQAgent) flush(bootstrap float64) {
+	for len(agent.buffer) > 0 {
+		agent.backupOldest(bootstrap)
+	}
+}
+
+func (agent *NStepQAgent) Snapshot() AgentSnapshot {
+	snapshot := agent.snapshotAs(AgentKindNStepQ)
+	snapshot.Hyperparameters["n_steps"] = float64(agent.N)
+	return snapshot
+}
+
+func (agent *NStepQAgent) Restore(snapshot AgentSnapshot) error {
+	if err := agent.restoreAs(AgentKindNStepQ, snapshot); err != nil {
+		return err
+	}
+	if n, exists := snapshot.Hyperparameters["n_steps"]; exists {
+		agent.N = int(math.Max(1, n))
+	}
+	agent.buffer = nil
+	return nil
+}
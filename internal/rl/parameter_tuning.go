@@ -0,0 +1,144 @@
+package rl
+
+// ParameterTuningState is the tuner's persistent state: the best parameters
+// found per function and every generation the GA evaluated.
+type ParameterTuningState struct {
+	BestParameters   map[string]map[string]interface{} `json:"best_parameters"`
+	ParameterHistory map[string][]ParameterGeneration  `json:"parameter_history"`
+}
+
+// ParameterTuner runs the ParameterOptimizer GA for every function that has
+// parameter ranges. A candidate's fitness is its mean enhanced reward as the
+// first call on a fixed sample of training examples, run on the executor
+// training uses. When that is the simulator the tuner runs its own instant
+// copy, so tuning neither waits out simulated latency nor draws from the
+// training simulator's stream.
+type ParameterTuner struct {
+	Optimizer *ParameterOptimizer
+	Rollouts  int
+
+	actions    []EnhancedAction
+	simulator  *ActionSimulator
+	executor   Executor // simulator, or the training executor when that is not the simulator
+	rewardCalc *EnhancedRewardCalculator
+}
+
+func NewParameterTuner(config SystemConfig) *ParameterTuner {
+	optimizer := NewParameterOptimizer()
+	optimizer.Rand = newRand(config.Seed, "tuning")
+	optimizer.generations = config.TuningGenerations
+	if optimizer.generations <= 0 {
+		optimizer.generations = 10
+	}
+	optimizer.populationSize = config.TuningPopulation
+	if optimizer.populationSize <= 0 {
+		optimizer.populationSize = 12
+	}
+	rollouts := config.TuningRollouts
+	if rollouts <= 0 {
+		rollouts = 5
+	}
+
+	simulator := NewActionSimulator()
+	simulator.Instant = true
+	simulator.Rand = newRand(config.Seed, "tuning_simulator")
+	return &ParameterTuner{
+		Optimizer:  optimizer,
+		Rollouts:   rollouts,
+		actions:    GetEnhancedActions(),
+		simulator:  simulator,
+		executor:   simulator,
+		rewardCalc: NewEnhancedRewardCalculator(),
+	}
+}
+
+// useExecutor makes the tuner score candidates on the executor training runs
+// actions on.
+func (tuner *ParameterTuner) useExecutor(executor Executor) {
+	if _, isSimulator := executor.(*ActionSimulator); isSimulator {
+		tuner.executor = tuner.simulator
+		return
+	}
+	tuner.executor = executor
+}
+
+// Tune runs one GA per tunable function and returns the best fitness found
+// for each. Every candidate is scored on the same examples.
+func (tuner *ParameterTuner) Tune(examples []TrainingExample) map[string]float64 {
+	sample := make([]TrainingExample, tuner.Rollouts)
+	for i := range sample {
+		if len(examples) == 0 {
+			sample[i] = TrainingExample{ID: "default", Text: "Sample text for analysis and processing.", TaskType: "comprehensive"}
+		} else {
+			sample[i] = examples[randIntn(tuner.Optimizer.Rand, len(examples))]
+		}
+	}
+
+	best := make(map[string]float64)
+	for _, action := range tuner.actions {
+		if len(action.ParameterRanges) == 0 {
+			continue
+		}
+		fitness := tuner.fitness(action, sample)
+		params := tuner.Optimizer.OptimizeParameters(action.FunctionName, action.ParameterRanges, fitness)
+		best[action.FunctionName] = fitness(params)
+	}
+	return best
+}
+
+func (tuner *ParameterTuner) fitness(action EnhancedAction, examples []TrainingExample) func(map[string]interface{}) float64 {
+	return func(params map[string]interface{}) float64 {
+		candidate := Action{FunctionName: action.FunctionName, Category: action.Category, Cost: action.Cost, Parameters: params}
+		total := 0.0
+		for _, example := range examples {
+			state := State{
+				Text:            example.Text,
+				TaskType:        example.TaskType,
+				ActionsUsed:     []string{},
+				CurrentResults:  make(map[string]interface{}),
+				RemainingBudget: 50,
+			}
+			result := tuner.executor.ExecuteAction(candidate, state.Text, params)
+			total += tuner.rewardCalc.CalculateReward(state, candidate, result, example)
+		}
+		return total / float64(len(examples))
+	}
+}
+
+// Apply attaches the tuned parameters for the action's function. Actions
+// that already carry parameters, or whose function has not been tuned, are
+// returned unchanged.
+func (tuner *ParameterTuner) Apply(action Action) Action {
+	if len(action.Parameters) > 0 {
+		return action
+	}
+	if params, exists := tuner.Optimizer.bestParameters[action.FunctionName]; exists {
+		action.Parameters = copyParams(params)
+	}
+	return action
+}
+
+func (tuner *ParameterTuner) State() ParameterTuningState {
+	state := ParameterTuningState{
+		BestParameters:   make(map[string]map[string]interface{}, len(tuner.Optimizer.bestParameters)),
+		ParameterHistory: make(map[string][]ParameterGeneration, len(tuner.Optimizer.parameterHistory)),
+	}
+	for name, params := range tuner.Optimizer.bestParameters {
+		state.BestParameters[name] = copyParams(params)
+	}
+	for name, history := range tuner.Optimizer.parameterHistory {
+		state.ParameterHistory[name] = append([]ParameterGeneration(nil), history...)
+	}
+	return state
+}
+
+func (tuner *ParameterTuner) LoadState(state ParameterTuningState) {
+	tuner.Optimizer.bestParameters = make(map[string]map[string]interface{}, len(state.BestParameters))
+	for name, params := range state.BestParameters {
+		tuner.Optimizer.bestParameters[name] = copyParams(params)
+	}
+	tuner.Optimizer.parameterHistory = make(map[string][]ParameterGeneration, len(state.ParameterHistory))
+	for name, history := range state.ParameterHistory {
+		tuner.Optimizer.parameterHistory[name] = append([]ParameterGeneration(nil), history...)
+	}
+}
@@ -0,0 +1,130 @@
+package rl
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func newTestTuner() *ParameterTuner {
+	return NewParameterTuner(SystemConfig{TuningGenerations: 3, TuningPopulation: 6, TuningRollouts: 2})
+}
+
+func TestParameterTuner_TunesEveryParameterizedFunction(t *testing.T) {
+	tuner := newTestTuner()
+	examples := []TrainingExample{{ID: "a", Text: "Alice Johnson visited Microsoft in Seattle. It went well.", TaskType: "news_analysis"}}
+
+	best := tuner.Tune(examples)
+	for _, name := range []string{"extract_entities", "analyze_readability", "summarize_text"} {
+		if _, exists := best[name]; !exists {
+			t.Errorf("Expected %s to be tuned, got %v", name, best)
+		}
+		if len(tuner.Optimizer.parameterHistory[name]) != 3 {
+			t.Errorf("Expected 3 generations of history for %s, got %d", name, len(tuner.Optimizer.parameterHistory[name]))
+		}
+	}
+	if _, exists := best["detect_code"]; exists {
+		t.Error("Expected functions without parameter ranges to be skipped")
+	}
+
+	tuner.Tune(examples)
+	history := tuner.Optimizer.parameterHistory["extract_entities"]
+	if len(history) != 6 || history[5].Generation != 5 {
+		t.Errorf("Expected a second run to extend the history to generation 5, got %d entries", len(history))
+	}
+}
+
+func TestParameterTuner_ApplyAndState(t *testing.T) {
+	tuner := newTestTuner()
+	tuner.Optimizer.bestParameters["extract_entities"] = map[string]interface{}{
+		"confidence_threshold": 0.7,
+		"max_entities":         200,
+	}
+
+	plain := Action{FunctionName: "extract_entities", Category: "analysis", Cost: 5}
+	if got := tuner.Apply(plain); got.Parameters["confidence_threshold"] != 0.7 {
+		t.Errorf("Expected tuned parameters attached, got %v", got.Parameters)
+	}
+	chosen := plain
+	chosen.Parameters = map[string]interface{}{"confidence_threshold": 0.1}
+	if got := tuner.Apply(chosen); got.Parameters["confidence_threshold"] != 0.1 {
+		t.Errorf("Expected explicit parameters kept, got %v", got.Parameters)
+	}
+	if got := tuner.Apply(Action{FunctionName: "detect_code"}); got.Parameters != nil {
+		t.Errorf("Expected untuned function unchanged, got %v", got.Parameters)
+	}
+
+	// The state survives the JSON round trip the model file goes through
+	data, err := json.Marshal(tuner.State())
+	if err != nil {
+		t.Fatalf("Marshal returned error: %v", err)
+	}
+	var state ParameterTuningState
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	restored := newTestTuner()
+	restored.LoadState(state)
+	if got := restored.Apply(plain); intParam(got.Parameters, "max_entities", 0) != 200 {
+		t.Errorf("Expected restored max_entities 200, got %v", got.Parameters)
+	}
+}
+
+func TestEnhancedRLSystem_TuningUsesPlainActions(t *testing.T) {
+	config := SystemConfig{ParameterTuningInterval: 10}
+	if space := NewActionSpace(config); len(space.Actions) != len(getDefaultActions()) {
+		t.Errorf("Expected plain actions when tuning, got %d", len(space.Actions))
+	}
+
+	if NewEnhancedRLSystem(SystemConfig{}).ParameterTuning() != nil {
+		t.Error("Expected no tuning state when tuning is disabled")
+	}
+	system := NewEnhancedRLSystem(config)
+	system.RestoreParameterTuning(ParameterTuningState{
+		BestParameters: map[string]map[string]interface{}{"summarize_text": {"delimiter_style": "aggressive"}},
+	})
+	executed := system.applyTunedParameters(Action{FunctionName: "summarize_text"})
+	if executed.Parameters["delimiter_style"] != "aggressive" {
+		t.Errorf("Expected restored parameters applied, got %v", executed.Parameters)
+	}
+}
+
+func TestEnhancedRLSystem_TuningUsesTheTrainingExecutor(t *testing.T) {
+	system := NewEnhancedRLSystem(SystemConfig{ParameterTuningInterval: 10, TuningGenerations: 1, TuningPopulation: 2, TuningRollouts: 1})
+	recorder := &recordingExecutor{}
+	system.SetExecutor(recorder)
+	system.tuner.Tune(GetRealisticTrainingData())
+	if len(recorder.calls) == 0 {
+		t.Error("Expected tuning to score candidates on the training executor")
+	}
+
+	system.SetExecutor(NewActionSimulator())
+	if system.tuner.executor != system.tuner.simulator {
+		t.Error("Expected tuning on the simulator to use the tuner's instant copy")
+	}
+}
+
+func TestParameterTuner_CapsHistory(t *testing.T) {
+	tuner := newTestTuner()
+	for run := 0; run < 10; run++ {
+		tuner.Tune(nil)
+	}
+	history := tuner.Optimizer.parameterHistory["extract_entities"]
+	if len(history) != parameterHistoryLimit || history[len(history)-1].Generation != 29 {
+		t.Errorf("Expected the last %d of 30 generations, got %d ending at %d",
+			parameterHistoryLimit, len(history), history[len(history)-1].Generation)
+	}
+}
+
+func TestActionSimulator_Instant(t *testing.T) {
+	simulator := NewActionSimulator()
+	simulator.Instant = true
+	start := time.Now()
+	result := simulator.ExecuteAction(Action{FunctionName: "summarize_text", Cost: 8}, "Short text.", nil)
+	if elapsed := time.Since(start); elapsed >= 80*time.Millisecond {
+		t.Errorf("Expected no sleep, took %v", elapsed)
+	}
+	if result.Duration < 80*time.Millisecond {
+		t.Errorf("Expected the simulated latency in Duration, got %v", result.Duration)
+	}
+}
@@ -0,0 +1,232 @@
+package rl
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// actionParameterSources maps simulated functions to the textlib functions
+// whose parameter ranges they expose.
+var actionParameterSources = map[string]string{
+	"extract_entities":    "ExtractNamedEntities",
+	"analyze_readability": "CalculateTextStatistics",
+	"summarize_text":      "SplitIntoSentences",
+}
+
+// GetEnhancedActions returns the default actions with the parameter ranges of
+// their textlib counterparts attached. Actions without a counterpart have no
+// ranges and take no parameters.
+func GetEnhancedActions() []EnhancedAction {
+	ranges := GetTextLibParameterRanges()
+	actions := getDefaultActions()
+	enhanced := make([]EnhancedAction, len(actions))
+	for i, action := range actions {
+		enhanced[i] = EnhancedAction{
+			FunctionName:    action.FunctionName,
+			Category:        action.Category,
+			Cost:            action.Cost,
+			ParameterRanges: ranges[actionParameterSources[action.FunctionName]],
+		}
+	}
+	return enhanced
+}
+
+// Variants expands the action into one Action per combination of discretized
+// parameter values, using at most levels values per parameter. With a single
+// level the plain action is returned and every parameter keeps its default.
+func (action EnhancedAction) Variants(levels int) []Action {
+	base := Action{FunctionName: action.FunctionName, Category: action.Category, Cost: action.Cost}
+	if len(action.ParameterRanges) == 0 || levels <= 1 {
+		return []Action{base}
+	}
+
+	names := make([]string, 0, len(action.ParameterRanges))
+	for name := range action.ParameterRanges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	combinations := []map[string]interface{}{{}}
+	for _, name := range names {
+		values := action.ParameterRanges[name].Values(levels)
+		expanded := make([]map[string]interface{}, 0, len(combinations)*len(values))
+		for _, combination := range combinations {
+			for _, value := range values {
+				params := copyParams(combination)
+				params[name] = value
+				expanded = append(expanded, params)
+			}
+		}
+		combinations = expanded
+	}
+
+	variants := make([]Action, len(combinations))
+	for i, params := range combinations {
+		variants[i] = base
+		variants[i].Parameters = params
+	}
+	return variants
+}
+
+// Values discretizes the range into at most levels values that keep both
+// ends. Numeric ranges offer their whole Step grid when it is small enough;
+// otherwise the points are spread evenly across the range, or evenly in log
+// space when it spans an order of magnitude or more, and snapped to the grid.
+// A single level is the default value; enums are thinned evenly.
+func (r ParameterRange) Values(levels int) []interface{} {
+	if levels <= 1 {
+		return []interface{}{r.Default}
+	}
+
+	switch r.Type {
+	case "bool":
+		return []interface{}{false, true}
+	case "enum":
+		return thinValues(r.Options, levels)
+	case "int", "float":
+		min, minOK := numericValue(r.Min)
+		max, maxOK := numericValue(r.Max)
+		step, stepOK := numericValue(r.Step)
+		if !minOK || !maxOK || max < min {
+			return []interface{}{r.Default}
+		}
+		if !stepOK || step <= 0 {
+			step = (max - min) / float64(levels-1)
+		}
+
+		points := make([]float64, 0, levels)
+		if steps := int(math.Floor((max-min)/step + 1e-9)); steps+1 <= levels {
+			for i := 0; i <= steps; i++ {
+				points = append(points, min+float64(i)*step)
+			}
+		} else {
+			logScale := min > 0 && max >= 10*min
+			for i := 0; i < levels; i++ {
+				fraction := float64(i) / float64(levels-1)
+				value := min + fraction*(max-min)
+				if logScale {
+					value = min * math.Pow(max/min, fraction)
+				}
+				value = min + math.Round((value-min)/step)*step
+				points = append(points, math.Min(value, max))
+			}
+		}
+
+		values := make([]interface{}, 0, len(points))
+		for _, point := range points {
+			var value interface{}
+			if r.Type == "int" {
+				value = int(math.Round(point))
+			} else {
+				// Round off accumulated float error so keys stay readable
+				value = math.Round(point*1e6) / 1e6
+			}
+			if len(values) == 0 || values[len(values)-1] != value {
+				values = append(values, value)
+			}
+		}
+		return values
+	default:
+		return []interface{}{r.Default}
+	}
+}
+
+func thinValues(values []interface{}, levels int) []interface{} {
+	if len(values) <= levels {
+		return values
+	}
+	thinned := make([]interface{}, levels)
+	for i := range thinned {
+		thinned[i] = values[int(math.Round(float64(i*(len(values)-1))/float64(levels-1)))]
+	}
+	return thinned
+}
+
+// ParameterizedActionSpace is the default action space with each action
+// replaced by its parameter variants. Preconditions are keyed by function
+// name, so they apply to every variant alike.
+func ParameterizedActionSpace(levels int) *ActionSpace {
+	space := DefaultActionSpace()
+	space.Actions = nil
+	for _, action := range GetEnhancedActions() {
+		space.Actions = append(space.Actions, action.Variants(levels)...)
+	}
+	return space
+}
+
+// NewActionSpace builds the action space config trains over. Parameter
+// variants are opt-in through ParameterLevels and never expanded when the GA
+// tunes parameters, in which case the agent picks plain actions and the
+// tuner supplies their parameters.
+func NewActionSpace(config SystemConfig) *ActionSpace {
+	levels := config.ParameterLevels
+	if levels <= 0 {
+		levels = 1
+	}
+	if config.ParameterTuningInterval > 0 {
+		levels = 1
+	}
+	return ParameterizedActionSpace(levels)
+}
+
+// parameterKey renders parameters as sorted name=value pairs, e.g.
+// "confidence_threshold=0.5;max_entities=510".
+func parameterKey(params map[string]interface{}) string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf("%s=%v", name, params[name])
+	}
+	return strings.Join(pairs, ";")
+}
+
+func numericValue(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// The param helpers read a parameter, falling back to def when it is missing
+// or has the wrong type. JSON-decoded numbers arrive as float64.
+
+func floatParam(params map[string]interface{}, name string, def float64) float64 {
+	if value, ok := numericValue(params[name]); ok {
+		return value
+	}
+	return def
+}
+
+func intParam(params map[string]interface{}, name string, def int) int {
+	if value, ok := numericValue(params[name]); ok {
+		return int(value)
+	}
+	return def
+}
+
+func boolParam(params map[string]interface{}, name string, def bool) bool {
+	if value, ok := params[name].(bool); ok {
+		return value
+	}
+	return def
+}
+
+func stringParam(params map[string]interface{}, name string, def string) string {
+	if value, ok := params[name].(string); ok {
+		return value
+	}
+	return def
+}
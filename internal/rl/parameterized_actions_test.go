@@ -0,0 +1,134 @@
+package rl
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParameterRange_Values(t *testing.T) {
+	ranges := GetTextLibParameterRanges()
+
+	threshold := ranges["ExtractNamedEntities"]["confidence_threshold"]
+	if got := threshold.Values(3); !reflect.DeepEqual(got, []interface{}{0.1, 0.5, 0.9}) {
+		t.Errorf("Expected thinned float grid [0.1 0.5 0.9], got %v", got)
+	}
+	if got := threshold.Values(20); len(got) != 9 || got[2] != 0.3 {
+		t.Errorf("Expected the full 0.1-step grid with rounded values, got %v", got)
+	}
+	if got := threshold.Values(1); !reflect.DeepEqual(got, []interface{}{0.5}) {
+		t.Errorf("Expected the default for one level, got %v", got)
+	}
+
+	// Ranges spanning an order of magnitude are spread in log space
+	minLength := ranges["SplitIntoSentences"]["min_sentence_length"]
+	if got := minLength.Values(3); !reflect.DeepEqual(got, []interface{}{5, 15, 50}) {
+		t.Errorf("Expected int grid [5 15 50], got %v", got)
+	}
+	maxEntities := ranges["ExtractNamedEntities"]["max_entities"]
+	if got := maxEntities.Values(3); !reflect.DeepEqual(got, []interface{}{10, 100, 1000}) {
+		t.Errorf("Expected int grid [10 100 1000], got %v", got)
+	}
+	sampleSize := ranges["CalculateTextStatistics"]["sample_size"]
+	if got := sampleSize.Values(4); !reflect.DeepEqual(got, []interface{}{100, 500, 2200, 10000}) {
+		t.Errorf("Expected int grid [100 500 2200 10000], got %v", got)
+	}
+	style := ranges["SplitIntoSentences"]["delimiter_style"]
+	if got := style.Values(2); !reflect.DeepEqual(got, []interface{}{"standard", "conservative"}) {
+		t.Errorf("Expected thinned enum options, got %v", got)
+	}
+	advanced := ranges["CalculateTextStatistics"]["include_advanced"]
+	if got := advanced.Values(3); !reflect.DeepEqual(got, []interface{}{false, true}) {
+		t.Errorf("Expected both bool values, got %v", got)
+	}
+}
+
+func TestParameterizedActionSpace_ExpandsVariants(t *testing.T) {
+	plain := ParameterizedActionSpace(1)
+	if !reflect.DeepEqual(plain.Actions, getDefaultActions()) {
+		t.Errorf("Expected plain actions with one level, got %v", plain.Actions)
+	}
+
+	space := ParameterizedActionSpace(3)
+	// entities 3x3, readability 2x3, summary 3x3, five plain actions
+	if len(space.Actions) != 9+6+9+5 {
+		t.Fatalf("Expected 29 actions, got %d", len(space.Actions))
+	}
+
+	agent := NewQLearningAgent(0.1, 0.9, 0.0, 0.0, 1.0)
+	agent.ActionSpace = space
+	keys := make(map[string]bool)
+	for _, action := range space.Actions {
+		keys[agent.getActionKey(action)] = true
+	}
+	if len(keys) != len(space.Actions) {
+		t.Errorf("Expected a distinct key per variant, got %d keys", len(keys))
+	}
+	if !keys["extract_entities_analysis[confidence_threshold=0.5;max_entities=100]"] || !keys["detect_code_analysis"] {
+		t.Errorf("Expected parameter values in variant keys and plain keys unchanged, got %v", keys)
+	}
+
+	// Preconditions are per function, so one call masks every variant
+	state := testState("Variant masking text.")
+	state.ActionsUsed = []string{"extract_entities"}
+	for _, action := range agent.getAvailableActions(state) {
+		if action.FunctionName == "extract_entities" {
+			t.Fatalf("Expected all extract_entities variants masked, got %v", action.Parameters)
+		}
+	}
+}
+
+func TestNewAgent_SelectsAndLogsParameters(t *testing.T) {
+	if space := NewActionSpace(SystemConfig{}); !reflect.DeepEqual(space.Actions, getDefaultActions()) {
+		t.Errorf("Expected plain actions unless parameter levels are set, got %d actions", len(space.Actions))
+	}
+
+	created, err := NewAgent(SystemConfig{ExplorationRate: Float64(1.0), MinExploration: Float64(1.0), DecayRate: 1.0, ParameterLevels: 3})
+	if err != nil {
+		t.Fatalf("NewAgent returned error: %v", err)
+	}
+	state := testState("Parameter logging text.")
+	for i := 0; i < 200; i++ {
+		action, metrics := created.SelectActionWithMetrics(state)
+		if action.FunctionName != "extract_entities" {
+			continue
+		}
+		if metrics.Parameters["confidence_threshold"] == nil || metrics.Parameters["max_entities"] == nil {
+			t.Fatalf("Expected parameters in action metrics, got %v", metrics.Parameters)
+		}
+		return
+	}
+	t.Error("Expected random selection to reach an extract_entities variant")
+}
+
+func TestSimulator_ParametersAffectOutcomes(t *testing.T) {
+	text := "Alice Johnson met Robert Smith at Microsoft headquarters in Seattle about project details and budgets."
+
+	count := func(threshold float64, limit int) int {
+		output, _ := simulateEntityExtraction(text, map[string]interface{}{
+			"confidence_threshold": threshold,
+			"max_entities":         limit,
+		})
+		return output.(map[string]interface{})["count"].(int)
+	}
+	if loose, strict := count(0.1, 100), count(0.9, 100); loose <= strict {
+		t.Errorf("Expected a lower threshold to find more entities, got %d vs %d", loose, strict)
+	}
+	if got := count(0.1, 2); got != 2 {
+		t.Errorf("Expected max_entities to cap the result at 2, got %d", got)
+	}
+
+	readability, _ := simulateReadabilityAnalysis(text, map[string]interface{}{"sample_size": 5, "include_advanced": true})
+	output := readability.(map[string]interface{})
+	if output["sampled"] != true || output["total_words"] != 5 || output["advanced"] == nil {
+		t.Errorf("Expected a sampled analysis with advanced statistics, got %v", output)
+	}
+
+	document := "First point here; second point here. Third sentence follows. Last one ends it."
+	sentences := func(style string) interface{} {
+		output, _ := simulateTextSummary(document, map[string]interface{}{"delimiter_style": style, "min_sentence_length": 5})
+		return output.(map[string]interface{})["sentences"]
+	}
+	if standard, aggressive := sentences("standard"), sentences("aggressive"); standard != 3 || aggressive != 4 {
+		t.Errorf("Expected 3 standard and 4 aggressive sentences, got %v and %v", standard, aggressive)
+	}
+}
@@ -0,0 +1,336 @@
+package rl
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"textlib-rl-system/internal/logging"
+)
+
+const (
+	AgentKindREINFORCE   = "reinforce"
+	AgentKindActorCritic = "actor_critic"
+)
+
+// policyWeightPrefix namespaces per-action preference vectors in snapshots.
+const policyWeightPrefix = "policy:"
+
+func init() {
+	RegisterAgent(AgentKindREINFORCE, func(config SystemConfig) Agent {
+		agent := NewPolicyGradientAgent(newQLearningAgentFromConfig(config), policyGradientConfigFrom(config), false)
+		agent.Rand = newRand(config.Seed, "policy")
+		return agent
+	})
+	RegisterAgent(AgentKindActorCritic, func(config SystemConfig) Agent {
+		agent := NewPolicyGradientAgent(newQLearningAgentFromConfig(config), policyGradientConfigFrom(config), true)
+		agent.Rand = newRand(config.Seed, "policy")
+		return agent
+	})
+}
+
+type PolicyGradientConfig struct {
+	PolicyLearningRate float64
+	CriticLearningRate float64
+	EntropyCoefficient float64
+}
+
+func policyGradientConfigFrom(config SystemConfig) PolicyGradientConfig {
+	config = withAgentDefaults(config)
+	return PolicyGradientConfig{
+		PolicyLearningRate: config.PolicyLearningRate,
+		CriticLearningRate: config.CriticLearningRate,
+		EntropyCoefficient: *config.EntropyCoefficient,
+	}
+}
+
+// PolicyGradientAgent learns a stochastic policy directly: a softmax over
+// linear action preferences θ_a·φ(s), with an entropy bonus that keeps the
+// policy from collapsing onto one call sequence too early.
+//
+// Without ActorCritic it is REINFORCE with a learned state-value baseline and
+// updates once per episode from the collected rewards. With ActorCritic it
+// makes a one-step update after every transition, using the baseline as the
+// critic and its TD error as the advantage.
+//
+// The embedded QLearningAgent supplies action enumeration, the featurizer and
+// the discount factor; its QTable and exploration strategy are unused because
+// the policy itself explores.
+type PolicyGradientAgent struct {
+	*QLearningAgent
+	ActorCritic bool
+	Config      PolicyGradientConfig
+
+	Preferences map[string][]float64 // θ per action key
+	Baseline    []float64            // state-value weights
+	Rand        *Random              // samples actions; nil uses math/rand
+
+	vocabulary  *featureVocabulary
+	trajectory  []policyStep
+	discounting float64 // γ^t within the current episode
+	entropy     float64 // normalized entropy of the last policy evaluated
+}
+
+type policyStep struct {
+	features []activeFeature
+	actions  []Action
+	chosen   int
+	reward   float64
+}
+
+func NewPolicyGradientAgent(base *QLearningAgent, config PolicyGradientConfig, actorCritic bool) *PolicyGradientAgent {
+	return &PolicyGradientAgent{
+		QLearningAgent: base,
+		ActorCritic:    actorCritic,
+		Config:         config,
+		Preferences:    make(map[string][]float64),
+		vocabulary:     newFeatureVocabulary(),
+		discounting:    1.0,
+	}
+}
+
+func (agent *PolicyGradientAgent) kind() string {
+	if agent.ActorCritic {
+		return AgentKindActorCritic
+	}
+	return AgentKindREINFORCE
+}
+
+func (agent *PolicyGradientAgent) stateFeatures(state State, grow bool) []activeFeature {
+	return agent.vocabulary.active(namedStateFeatures(agent.featurizer().Featurize(state)), grow)
+}
+
+// probabilities evaluates the softmax policy with the current preferences.
+func (agent *PolicyGradientAgent) probabilities(features []activeFeature, actions []Action) []float64 {
+	preferences := make([]float64, len(actions))
+	for i, action := range actions {
+		preferences[i] = dotFeatures(agent.Preferences[agent.getActionKey(action)], features)
+	}
+	probabilities := softmax(preferences, 1.0)
+	agent.entropy = normalizedEntropy(probabilities)
+	return probabilities
+}
+
+// GetQValue returns the action preference θ_a·φ(s). It is not an action
+// value, but it ranks actions the same way the policy does.
+func (agent *PolicyGradientAgent) GetQValue(state State, action Action) float64 {
+	return dotFeatures(agent.Preferences[agent.getActionKey(action)], agent.stateFeatures(state, false))
+}
+
+// SelectActionWithMetrics samples from the policy. An action counts as
+// exploratory when it is not the policy's most likely one.
+func (agent *PolicyGradientAgent) SelectActionWithMetrics(state State) (Action, logging.ActionMetrics) {
+	actions := agent.getAvailableActions(state)
+	if len(actions) == 0 {
+		action := Action{FunctionName: "no_op", Category: "utility", Cost: 0}
+		return action, agent.actionMetrics(state, action, false, 0)
+	}
+
+	probabilities := agent.probabilities(agent.stateFeatures(state, false), actions)
+	idx := sampleIndex(probabilities, randFloat64(agent.Rand))
+	action := actions[idx]
+	return action, agent.actionMetrics(state, action, idx != argmax(probabilities), agent.GetQValue(state, action))
+}
+
+func (agent *PolicyGradientAgent) Update(state State, action Action, reward float64, nextState State, done bool) logging.LearningMetrics {
+	step := policyStep{
+		features: agent.stateFeatures(state, true),
+		actions:  agent.getAvailableActions(state),
+		chosen:   -1,
+		reward:   reward,
+	}
+	actionKey := agent.getActionKey(action)
+	for i, candidate := range step.actions {
+		if agent.getActionKey(candidate) == actionKey {
+			step.chosen = i
+			break
+		}
+	}
+
+	metrics := logging.LearningMetrics{}
+	if !agent.ActorCritic {
+		agent.trajectory = append(agent.trajectory, step)
+		metrics.ExplorationRate = agent.entropy
+		return metrics
+	}
+
+	nextValue := 0.0
+	if !done {
+		nextValue = dotFeatures(agent.Baseline, agent.stateFeatures(nextState, true))
+	}
+	advantage := reward + agent.DiscountFactor*nextValue - dotFeatures(agent.Baseline, step.features)
+
+	agent.updateBaseline(step.features, advantage)
+	agent.updatePolicy(step, agent.discounting*advantage)
+	agent.discounting *= agent.DiscountFactor
+
+	metrics.ExplorationRate = agent.entropy
+	metrics.TDError = math.Abs(advantage)
+	return metrics
+}
+
+// EndEpisode runs the REINFORCE update over the episode. It uses the rewards
+// the system collected in episode.Rewards when they line up with the recorded
+// steps, and the rewards passed to Update otherwise.
+func (agent *PolicyGradientAgent) EndEpisode(episode logging.EpisodeMetrics) {
+	defer func() {
+		agent.trajectory = agent.trajectory[:0]
+		agent.discounting = 1.0
+	}()
+	if agent.ActorCritic || len(agent.trajectory) == 0 {
+		return
+	}
+
+	rewards := episode.Rewards
+	if len(rewards) != len(agent.trajectory) {
+		rewards = make([]float64, len(agent.trajectory))
+		for t, step := range agent.trajectory {
+			rewards[t] = step.reward
+		}
+	}
+
+	returns := make([]float64, len(rewards))
+	future := 0.0
+	for t := len(rewards) - 1; t >= 0; t-- {
+		future = rewards[t] + agent.DiscountFactor*future
+		returns[t] = future
+	}
+
+	discounting := 1.0
+	for t, step := range agent.trajectory {
+		advantage := returns[t] - dotFeatures(agent.Baseline, step.features)
+		agent.updateBaseline(step.features, advantage)
+		agent.updatePolicy(step, discounting*advantage)
+		discounting *= agent.DiscountFactor
+	}
+}
+
+// updateBaseline moves the state-value estimate by the normalized step used
+// by the linear Q-learner.
+func (agent *PolicyGradientAgent) updateBaseline(features []activeFeature, err float64) {
+	agent.Baseline = agent.vocabulary.fit(agent.Baseline)
+	norm := squaredNorm(features)
+	if norm == 0 {
+		return
+	}
+	step := agent.Config.CriticLearningRate * err / norm
+	for _, feature := range features {
+		agent.Baseline[feature.index] += step * feature.value
+	}
+}
+
+// updatePolicy ascends scale·∇log π(a|s) plus the entropy gradient. For the
+// softmax policy ∂log π(a|s)/∂θ_b = (1[a=b] − π_b)·φ(s) and
+// ∂H/∂θ_b = −π_b·(log π_b + H)·φ(s).
+func (agent *PolicyGradientAgent) updatePolicy(step policyStep, scale float64) {
+	if step.chosen < 0 {
+		return
+	}
+	probabilities := agent.probabilities(step.features, step.actions)
+	entropy := 0.0
+	for _, p := range probabilities {
+		if p > 0 {
+			entropy -= p * math.Log(p)
+		}
+	}
+
+	for i, action := range step.actions {
+		gradient := -probabilities[i]
+		if i == step.chosen {
+			gradient += 1
+		}
+		entropyGradient := 0.0
+		if probabilities[i] > 0 {
+			entropyGradient = -probabilities[i] * (math.Log(probabilities[i]) + entropy)
+		}
+
+		coefficient := agent.Config.PolicyLearningRate * (scale*gradient + agent.Config.EntropyCoefficient*entropyGradient)
+		key := agent.getActionKey(action)
+		weights := agent.vocabulary.fit(agent.Preferences[key])
+		for _, feature := range step.features {
+			weights[feature.index] += coefficient * feature.value
+		}
+		agent.Preferences[key] = weights
+	}
+}
+
+// normalizedEntropy is the policy entropy divided by its maximum, so 1 is
+// uniform and 0 is deterministic. It is reported as the exploration rate.
+func normalizedEntropy(probabilities []float64) float64 {
+	if len(probabilities) < 2 {
+		return 0.0
+	}
+	entropy := 0.0
+	for _, p := range probabilities {
+		if p > 0 {
+			entropy -= p * math.Log(p)
+		}
+	}
+	return entropy / math.Log(float64(len(probabilities)))
+}
+
+func (agent *PolicyGradientAgent) Snapshot() AgentSnapshot {
+	snapshot := AgentSnapshot{
+		Kind: agent.kind(),
+		Hyperparameters: map[string]float64{
+			"discount_factor":      agent.DiscountFactor,
+			"policy_learning_rate": agent.Config.PolicyLearningRate,
+			"critic_learning_rate": agent.Config.CriticLearningRate,
+			"entropy_coefficient":  agent.Config.EntropyCoefficient,
+		},
+		Options: map[string]string{
+			"state_featurizer": agent.featurizer().Name(),
+			"features":         agent.vocabulary.encode(),
+		},
+		Weights: map[string][]float64{
+			"baseline": append([]float64(nil), agent.Baseline...),
+		},
+	}
+	for actionKey, weights := range agent.Preferences {
+		snapshot.Weights[policyWeightPrefix+actionKey] = append([]float64(nil), weights...)
+	}
+	saveRandom(&snapshot, "policy_rng", agent.Rand)
+	return snapshot
+}
+
+func (agent *PolicyGradientAgent) Restore(snapshot AgentSnapshot) error {
+	if snapshot.Kind != agent.kind() {
+		return fmt.Errorf("cannot restore %s snapshot into %s agent", snapshot.Kind, agent.kind())
+	}
+	if name, exists := snapshot.Options["state_featurizer"]; exists && name != agent.featurizer().Name() {
+		return fmt.Errorf("snapshot features come from featurizer %s, agent uses %s", name, agent.featurizer().Name())
+	}
+	vocabulary, err := parseFeatureVocabulary(snapshot.Options["features"])
+	if err != nil {
+		return err
+	}
+	if err := vocabulary.check(snapshot.Weights); err != nil {
+		return err
+	}
+
+	if value, exists := snapshot.Hyperparameters["discount_factor"]; exists {
+		agent.DiscountFactor = value
+	}
+	if value, exists := snapshot.Hyperparameters["policy_learning_rate"]; exists {
+		agent.Config.PolicyLearningRate = value
+	}
+	if value, exists := snapshot.Hyperparameters["critic_learning_rate"]; exists {
+		agent.Config.CriticLearningRate = value
+	}
+	if value, exists := snapshot.Hyperparameters["entropy_coefficient"]; exists {
+		agent.Config.EntropyCoefficient = value
+	}
+
+	agent.vocabulary = vocabulary
+	agent.Rand = loadRandom(snapshot, "policy_rng", agent.Rand)
+	agent.Baseline = append([]float64(nil), snapshot.Weights["baseline"]...)
+	agent.Preferences = make(map[string][]float64)
+	for key, weights := range snapshot.Weights {
+		if strings.HasPrefix(key, policyWeightPrefix) {
+			agent.Preferences[strings.TrimPrefix(key, policyWeightPrefix)] = append([]float64(nil), weights...)
+		}
+	}
+	agent.trajectory = nil
+	agent.discounting = 1.0
+	return nil
+}
@@ -0,0 +1,128 @@
+package rl
+
+import (
+	"math"
+	"testing"
+
+	"textlib-rl-system/internal/logging"
+)
+
+func newTestPolicyAgent(actorCritic bool) *PolicyGradientAgent {
+	return NewPolicyGradientAgent(NewQLearningAgent(0.1, 0.9, 0.0, 0.0, 1.0), PolicyGradientConfig{
+		PolicyLearningRate: 0.1,
+		CriticLearningRate: 0.5,
+		EntropyCoefficient: 0.0,
+	}, actorCritic)
+}
+
+func actionProbability(agent *PolicyGradientAgent, state State, target Action) float64 {
+	actions := agent.getAvailableActions(state)
+	probabilities := agent.probabilities(agent.stateFeatures(state, false), actions)
+	for i, action := range actions {
+		if agent.getActionKey(action) == agent.getActionKey(target) {
+			return probabilities[i]
+		}
+	}
+	return 0
+}
+
+func TestPolicyGradientAgent_LearnsRewardedAction(t *testing.T) {
+	for _, actorCritic := range []bool{false, true} {
+		agent := newTestPolicyAgent(actorCritic)
+		state := testState("Policy gradient bandit text.")
+		best := getDefaultActions()[2]
+
+		for episode := 0; episode < 300; episode++ {
+			action, _ := agent.SelectActionWithMetrics(state)
+			reward := 0.0
+			if action.FunctionName == best.FunctionName {
+				reward = 1.0
+			}
+			agent.Update(state, action, reward, state, true)
+			agent.EndEpisode(logging.EpisodeMetrics{Rewards: []float64{reward}})
+		}
+
+		if p := actionProbability(agent, state, best); p < 0.5 {
+			t.Errorf("%s: expected rewarded action to dominate the policy, got p=%f", agent.kind(), p)
+		}
+	}
+}
+
+func TestPolicyGradientAgent_REINFORCEUsesEpisodeRewards(t *testing.T) {
+	agent := newTestPolicyAgent(false)
+	state := testState("Episode rewards text.")
+	action := getDefaultActions()[0]
+	before := actionProbability(agent, state, action)
+
+	// Update sees no reward; the episode trajectory carries it
+	agent.Update(state, action, 0.0, state, true)
+	if actionProbability(agent, state, action) != before {
+		t.Fatal("Expected REINFORCE to wait for the end of the episode")
+	}
+	agent.EndEpisode(logging.EpisodeMetrics{Rewards: []float64{5.0}})
+
+	if after := actionProbability(agent, state, action); after <= before {
+		t.Errorf("Expected positive return to raise the action's probability, %f -> %f", before, after)
+	}
+	if len(agent.trajectory) != 0 {
+		t.Error("Expected trajectory cleared at episode end")
+	}
+}
+
+func TestPolicyGradientAgent_EntropyRegularization(t *testing.T) {
+	agent := newTestPolicyAgent(true)
+	agent.Config.PolicyLearningRate = 0.5
+	agent.Config.EntropyCoefficient = 1.0
+	state := testState("Entropy text.")
+	action := getDefaultActions()[0]
+
+	// Skew the policy, then apply updates with zero advantage
+	agent.stateFeatures(state, true)
+	preferences := agent.vocabulary.fit(nil)
+	for i := range preferences {
+		preferences[i] = 1.0
+	}
+	agent.Preferences[agent.getActionKey(action)] = preferences
+	skewed := actionProbability(agent, state, action)
+
+	for i := 0; i < 20; i++ {
+		agent.updatePolicy(policyStep{
+			features: agent.stateFeatures(state, false),
+			actions:  agent.getAvailableActions(state),
+			chosen:   0,
+		}, 0.0)
+	}
+	if after := actionProbability(agent, state, action); after >= skewed {
+		t.Errorf("Expected entropy bonus to flatten the policy, %f -> %f", skewed, after)
+	}
+}
+
+func TestPolicyGradientAgent_SnapshotRestore(t *testing.T) {
+	created, err := NewAgent(SystemConfig{AgentType: AgentKindActorCritic})
+	if err != nil {
+		t.Fatalf("NewAgent returned error: %v", err)
+	}
+	agent := created.(*PolicyGradientAgent)
+	if agent.Config.EntropyCoefficient != 0.01 {
+		t.Errorf("Expected default entropy coefficient, got %f", agent.Config.EntropyCoefficient)
+	}
+
+	state := testState("Policy snapshot text.")
+	action := getDefaultActions()[1]
+	agent.Update(state, action, 1.0, state, true)
+
+	restored := newTestPolicyAgent(true)
+	if err := restored.Restore(agent.Snapshot()); err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	if math.Abs(restored.GetQValue(state, action)-agent.GetQValue(state, action)) > 1e-12 {
+		t.Error("Restored preferences do not match original")
+	}
+	if restored.Config != agent.Config {
+		t.Errorf("Expected restored config %+v, got %+v", agent.Config, restored.Config)
+	}
+
+	if err := newTestPolicyAgent(false).Restore(agent.Snapshot()); err == nil {
+		t.Error("Expected error restoring actor-critic snapshot into REINFORCE agent")
+	}
+}
@@ -0,0 +1,112 @@
+package rl
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+)
+
+// Random is a seeded random stream. Its whole state is one word, unlike
+// math/rand's default source, so checkpoints can record where a stream is and
+// a resumed run continues it from exactly there.
+type Random struct {
+	*rand.Rand
+	source *splitMix64
+}
+
+// NewRandom returns a stream seeded with seed.
+func NewRandom(seed int64) *Random {
+	source := &splitMix64{state: uint64(seed)}
+	return &Random{Rand: rand.New(source), source: source}
+}
+
+// State is the stream's position.
+func (random *Random) State() uint64 {
+	return random.source.state
+}
+
+// SetState moves the stream to a position returned by State.
+func (random *Random) SetState(state uint64) {
+	random.source.state = state
+}
+
+// splitMix64 is Steele, Lea and Flood's SplitMix64 generator.
+type splitMix64 struct {
+	state uint64
+}
+
+func (source *splitMix64) Uint64() uint64 {
+	source.state += 0x9e3779b97f4a7c15
+	z := source.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+func (source *splitMix64) Int63() int64 {
+	return int64(source.Uint64() >> 1)
+}
+
+func (source *splitMix64) Seed(seed int64) {
+	source.state = uint64(seed)
+}
+
+// newRand returns the random stream for one consumer of config.Seed. Every
+// consumer draws from its own stream, derived from the seed and the stream
+// name, so extra draws in one part of the system do not shift the numbers
+// any other part sees.
+func newRand(seed int64, stream string) *Random {
+	hash := fnv.New64a()
+	hash.Write([]byte(stream))
+	return NewRandom(seed ^ int64(hash.Sum64()))
+}
+
+// randFloat64, randIntn and randNormFloat64 draw from rng, or from the
+// math/rand global source for values built without one.
+func randFloat64(rng *Random) float64 {
+	if rng != nil {
+		return rng.Float64()
+	}
+	return rand.Float64()
+}
+
+func randIntn(rng *Random, n int) int {
+	if rng != nil {
+		return rng.Intn(n)
+	}
+	return rand.Intn(n)
+}
+
+func randNormFloat64(rng *Random) float64 {
+	if rng != nil {
+		return rng.NormFloat64()
+	}
+	return rand.NormFloat64()
+}
+
+// saveRandom records the position of rng in a snapshot option, so a restored
+// agent continues its stream instead of restarting it.
+func saveRandom(snapshot *AgentSnapshot, option string, rng *Random) {
+	if rng != nil {
+		snapshot.Options[option] = strconv.FormatUint(rng.State(), 10)
+	}
+}
+
+// loadRandom restores a position saved by saveRandom into rng, creating the
+// stream if the agent was built without one. Snapshots without the option
+// leave rng as it is.
+func loadRandom(snapshot AgentSnapshot, option string, rng *Random) *Random {
+	value, exists := snapshot.Options[option]
+	if !exists {
+		return rng
+	}
+	state, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return rng
+	}
+	if rng == nil {
+		rng = NewRandom(0)
+	}
+	rng.SetState(state)
+	return rng
+}
@@ -0,0 +1,58 @@
+package rl
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// trainSeeded runs a short training session with every random consumer
+// switched on and returns the trained agent's snapshot as JSON.
+func trainSeeded(t *testing.T, kind string, seed int64) []byte {
+	t.Helper()
+	config := SystemConfig{
+		AgentType:               kind,
+		MaxEpisodes:             30,
+		MaxStepsPerEpisode:      5,
+		LoggingInterval:         100,
+		CheckpointInterval:      100,
+		ParameterTuningInterval: 15,
+		TuningGenerations:       2,
+		TuningPopulation:        4,
+		TuningRollouts:          2,
+		Curriculum:              CurriculumEasyToHard,
+		HiddenSizes:             []int{8},
+		BatchSize:               4,
+		Seed:                    seed,
+	}
+	system := trainingFixture(t, config)
+	system.TrainWithLogging()
+
+	data, err := json.Marshal(system.Agent.Snapshot())
+	if err != nil {
+		t.Fatalf("Failed to encode %s snapshot: %v", kind, err)
+	}
+	return data
+}
+
+func TestTraining_SameSeedSameQTable(t *testing.T) {
+	for _, kind := range AgentKinds() {
+		first, second := trainSeeded(t, kind, 42), trainSeeded(t, kind, 42)
+		if !bytes.Equal(first, second) {
+			t.Errorf("%s: expected identical snapshots for the same seed", kind)
+		}
+	}
+
+	if bytes.Equal(trainSeeded(t, AgentKindQLearning, 7), trainSeeded(t, AgentKindQLearning, 8)) {
+		t.Error("Expected different seeds to train different Q-tables")
+	}
+}
+
+func TestNewRand_IndependentStreams(t *testing.T) {
+	if newRand(1, "exploration").Int63() != newRand(1, "exploration").Int63() {
+		t.Error("Expected the same seed and stream to repeat")
+	}
+	if newRand(1, "exploration").Int63() == newRand(1, "simulator").Int63() {
+		t.Error("Expected different streams of one seed to differ")
+	}
+}
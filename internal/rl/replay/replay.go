@@ -0,0 +1,471 @@
+// Package replay provides a fixed-capacity experience replay buffer with
+// uniform, proportional and rank-based prioritized sampling.
+//
+// Prioritized sampling follows Schaul et al. (2015): transitions are drawn
+// with probability P(i) = p_i^α / Σ p_k^α, where p_i is |TD error| + ε for
+// proportional sampling or 1/rank(i) for rank-based sampling, and each draw
+// carries an importance-sampling weight (N·P(i))^-β, normalized so the largest
+// weight in the batch is 1, which corrects the bias non-uniform sampling
+// introduces into the gradient. As in the paper, rank-based sampling takes a
+// transition's position in a binary heap as its rank, so neither adding nor
+// reprioritizing needs a full sort.
+package replay
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+const (
+	Uniform      = "uniform"
+	Proportional = "proportional"
+	Rank         = "rank"
+)
+
+type Config struct {
+	Capacity int
+	Sampling string
+
+	// Alpha controls how strongly priorities skew sampling (0 is uniform).
+	Alpha float64
+	// Beta is the initial importance-sampling exponent; it is annealed towards
+	// 1 by BetaIncrement on every Sample call.
+	Beta          float64
+	BetaIncrement float64
+	// Epsilon keeps transitions with zero TD error sampleable.
+	Epsilon float64
+
+	// Rand is the random source for sampling; nil uses math/rand.
+	Rand *rand.Rand
+}
+
+// DefaultConfig returns the settings from the prioritized replay paper.
+func DefaultConfig(capacity int) Config {
+	return Config{
+		Capacity:      capacity,
+		Sampling:      Uniform,
+		Alpha:         0.6,
+		Beta:          0.4,
+		BetaIncrement: 0.001,
+		Epsilon:       0.01,
+	}
+}
+
+// Buffer stores up to Capacity items, overwriting the oldest when full.
+type Buffer[T any] struct {
+	config Config
+	items  []T
+	next   int
+
+	priorities  []float64 // raw priority p_i per slot, before alpha
+	maxPriority float64
+	tree        *sumTree   // proportional only, holds p_i^alpha
+	rank        *rankOrder // rank only
+}
+
+// Batch is the result of Sample. Indices identify the sampled slots for
+// UpdatePriorities; Weights are the importance-sampling weights (all 1 for
+// uniform sampling).
+type Batch[T any] struct {
+	Items   []T
+	Indices []int
+	Weights []float64
+}
+
+// State is a buffer's contents and sampling progress, for checkpoints.
+type State[T any] struct {
+	Items       []T       `json:"items"`
+	Priorities  []float64 `json:"priorities"`
+	Next        int       `json:"next"`
+	MaxPriority float64   `json:"max_priority"`
+	Beta        float64   `json:"beta"`
+	// Rank is the rank-based heap order and RankChanges the updates since it
+	// was last sorted; both are empty for other sampling modes.
+	Rank        []int `json:"rank,omitempty"`
+	RankChanges int   `json:"rank_changes,omitempty"`
+}
+
+// Validate reports whether New would accept config.
+func Validate(config Config) error {
+	if config.Capacity < 1 {
+		return fmt.Errorf("replay capacity must be positive, got %d", config.Capacity)
+	}
+	switch config.Sampling {
+	case "", Uniform, Proportional, Rank:
+	default:
+		return fmt.Errorf("unknown replay sampling %q", config.Sampling)
+	}
+	if config.Alpha < 0 || config.Beta < 0 || config.Beta > 1 {
+		return fmt.Errorf("replay alpha must be >= 0 and beta in [0, 1], got %f/%f", config.Alpha, config.Beta)
+	}
+	return nil
+}
+
+func New[T any](config Config) (*Buffer[T], error) {
+	if err := Validate(config); err != nil {
+		return nil, err
+	}
+	if config.Sampling == "" {
+		config.Sampling = Uniform
+	}
+
+	buffer := &Buffer[T]{
+		config:      config,
+		priorities:  make([]float64, 0, config.Capacity),
+		maxPriority: 1.0,
+	}
+	switch config.Sampling {
+	case Proportional:
+		buffer.tree = newSumTree(config.Capacity)
+	case Rank:
+		buffer.rank = buffer.newRankOrder()
+	}
+	return buffer, nil
+}
+
+func (b *Buffer[T]) Len() int {
+	return len(b.items)
+}
+
+func (b *Buffer[T]) Capacity() int {
+	return b.config.Capacity
+}
+
+func (b *Buffer[T]) Sampling() string {
+	return b.config.Sampling
+}
+
+// Beta returns the current, annealed importance-sampling exponent.
+func (b *Buffer[T]) Beta() float64 {
+	return b.config.Beta
+}
+
+// Add stores item with the highest priority seen so far, so every transition
+// is sampled at least once with high probability before its priority is known.
+func (b *Buffer[T]) Add(item T) int {
+	slot := len(b.items)
+	if slot < b.config.Capacity {
+		b.items = append(b.items, item)
+		b.priorities = append(b.priorities, 0)
+	} else {
+		slot = b.next
+		b.items[slot] = item
+		b.next = (b.next + 1) % b.config.Capacity
+	}
+	b.setPriority(slot, b.maxPriority)
+	return slot
+}
+
+// State returns a copy of the buffer's contents and sampling progress.
+func (b *Buffer[T]) State() State[T] {
+	state := State[T]{
+		Items:       append([]T(nil), b.items...),
+		Priorities:  append([]float64(nil), b.priorities...),
+		Next:        b.next,
+		MaxPriority: b.maxPriority,
+		Beta:        b.config.Beta,
+	}
+	if b.rank != nil {
+		state.Rank = append([]int(nil), b.rank.heap...)
+		state.RankChanges = b.rank.changes
+	}
+	return state
+}
+
+// Restore replaces the buffer's contents with a State taken from a buffer of
+// the same capacity.
+func (b *Buffer[T]) Restore(state State[T]) error {
+	if len(state.Items) > b.config.Capacity || len(state.Priorities) != len(state.Items) {
+		return fmt.Errorf("replay state holds %d items and %d priorities, buffer capacity is %d",
+			len(state.Items), len(state.Priorities), b.config.Capacity)
+	}
+	if state.Next < 0 || state.Next >= b.config.Capacity {
+		return fmt.Errorf("replay state position %d out of range", state.Next)
+	}
+
+	b.items = append(make([]T, 0, b.config.Capacity), state.Items...)
+	b.priorities = make([]float64, len(state.Items), b.config.Capacity)
+	b.next = state.Next
+	b.maxPriority = state.MaxPriority
+	b.config.Beta = state.Beta
+	if b.tree != nil {
+		b.tree = newSumTree(b.config.Capacity)
+	}
+	if b.rank != nil {
+		b.rank = b.newRankOrder()
+	}
+	for slot, priority := range state.Priorities {
+		b.setPriority(slot, priority)
+	}
+	if b.rank != nil && len(state.Rank) > 0 {
+		return b.rank.restore(state.Rank, state.RankChanges)
+	}
+	return nil
+}
+
+// UpdatePriorities sets the priority of sampled slots from their new absolute
+// TD errors. It is a no-op for uniform buffers.
+func (b *Buffer[T]) UpdatePriorities(indices []int, tdErrors []float64) {
+	if b.config.Sampling == Uniform {
+		return
+	}
+	for i, slot := range indices {
+		if slot < 0 || slot >= len(b.items) {
+			continue
+		}
+		priority := math.Abs(tdErrors[i]) + b.config.Epsilon
+		b.setPriority(slot, priority)
+		if priority > b.maxPriority {
+			b.maxPriority = priority
+		}
+	}
+}
+
+func (b *Buffer[T]) setPriority(slot int, priority float64) {
+	b.priorities[slot] = priority
+	if b.tree != nil {
+		b.tree.set(slot, math.Pow(priority, b.config.Alpha))
+	}
+	if b.rank != nil {
+		b.rank.update(slot)
+	}
+}
+
+// Sample draws n items with replacement. It returns an empty batch when the
+// buffer is empty.
+func (b *Buffer[T]) Sample(n int) Batch[T] {
+	batch := Batch[T]{}
+	if len(b.items) == 0 || n < 1 {
+		return batch
+	}
+
+	var probabilities func(slot int) float64
+	switch b.config.Sampling {
+	case Proportional:
+		batch.Indices = b.sampleProportional(n)
+		total := b.tree.total()
+		probabilities = func(slot int) float64 { return b.tree.get(slot) / total }
+	case Rank:
+		batch.Indices = b.sampleRank(n)
+		probabilities = b.rank.probability
+	default:
+		batch.Indices = make([]int, n)
+		for i := range batch.Indices {
+			batch.Indices[i] = b.intn(len(b.items))
+		}
+	}
+
+	batch.Items = make([]T, n)
+	batch.Weights = make([]float64, n)
+	maxWeight := 0.0
+	for i, slot := range batch.Indices {
+		batch.Items[i] = b.items[slot]
+		weight := 1.0
+		if probabilities != nil {
+			weight = math.Pow(float64(len(b.items))*probabilities(slot), -b.config.Beta)
+		}
+		batch.Weights[i] = weight
+		maxWeight = math.Max(maxWeight, weight)
+	}
+	for i := range batch.Weights {
+		batch.Weights[i] /= maxWeight
+	}
+
+	if b.config.Sampling != Uniform {
+		b.config.Beta = math.Min(1.0, b.config.Beta+b.config.BetaIncrement)
+	}
+	return batch
+}
+
+// sampleProportional uses stratified sampling over the priority mass: the
+// total is split into n equal segments and one slot is drawn from each.
+func (b *Buffer[T]) sampleProportional(n int) []int {
+	indices := make([]int, n)
+	segment := b.tree.total() / float64(n)
+	for i := range indices {
+		mass := segment * (float64(i) + b.float64())
+		indices[i] = b.tree.find(mass, len(b.items))
+	}
+	return indices
+}
+
+// sampleRank samples with P(rank) ∝ rank^-α over the heap order.
+func (b *Buffer[T]) sampleRank(n int) []int {
+	cumulative := b.rank.cumulative[:len(b.items)]
+	total := cumulative[len(cumulative)-1]
+	indices := make([]int, n)
+	for i := range indices {
+		rank := sort.SearchFloat64s(cumulative, b.float64()*total)
+		if rank >= len(cumulative) {
+			rank = len(cumulative) - 1
+		}
+		indices[i] = b.rank.heap[rank]
+	}
+	return indices
+}
+
+func (b *Buffer[T]) float64() float64 {
+	if b.config.Rand != nil {
+		return b.config.Rand.Float64()
+	}
+	return rand.Float64()
+}
+
+func (b *Buffer[T]) intn(n int) int {
+	if b.config.Rand != nil {
+		return b.config.Rand.Intn(n)
+	}
+	return rand.Intn(n)
+}
+
+// rankOrder keeps the filled slots in a binary max-heap by priority and takes
+// a slot's index in the heap array as its rank. Adding a slot or changing its
+// priority is O(log n). A heap is only partially ordered, so the array is
+// sorted outright once the changes since the last sort reach the number of
+// slots, which keeps ranks close to exact at O(log n) amortized cost.
+type rankOrder struct {
+	priorities func() []float64
+	alpha      float64
+	heap       []int     // slots, highest priority first
+	position   []int     // heap index by slot
+	cumulative []float64 // Σ (1/r)^α over ranks 1..i+1, by heap index
+	changes    int
+}
+
+func (b *Buffer[T]) newRankOrder() *rankOrder {
+	return &rankOrder{priorities: func() []float64 { return b.priorities }, alpha: b.config.Alpha}
+}
+
+// update places slot after its priority was set. New slots are always the
+// next unfilled one.
+func (r *rankOrder) update(slot int) {
+	if slot == len(r.heap) {
+		heap.Push(r, slot)
+		total := 0.0
+		if slot > 0 {
+			total = r.cumulative[slot-1]
+		}
+		r.cumulative = append(r.cumulative, total+math.Pow(1.0/float64(slot+1), r.alpha))
+	} else {
+		heap.Fix(r, r.position[slot])
+	}
+
+	r.changes++
+	if r.changes >= len(r.heap) {
+		priorities := r.priorities()
+		sort.SliceStable(r.heap, func(i, j int) bool { return priorities[r.heap[i]] > priorities[r.heap[j]] })
+		for index, slot := range r.heap {
+			r.position[slot] = index
+		}
+		r.changes = 0
+	}
+}
+
+// restore replaces the heap order with a saved one.
+func (r *rankOrder) restore(order []int, changes int) error {
+	if len(order) != len(r.heap) {
+		return fmt.Errorf("rank order holds %d slots, buffer holds %d", len(order), len(r.heap))
+	}
+	seen := make([]bool, len(order))
+	for _, slot := range order {
+		if slot < 0 || slot >= len(order) || seen[slot] {
+			return fmt.Errorf("rank order is not a permutation of the slots")
+		}
+		seen[slot] = true
+	}
+	copy(r.heap, order)
+	for index, slot := range r.heap {
+		r.position[slot] = index
+	}
+	r.changes = changes
+	return nil
+}
+
+// probability is P(i) for the slot's current rank.
+func (r *rankOrder) probability(slot int) float64 {
+	rank := r.position[slot]
+	previous := 0.0
+	if rank > 0 {
+		previous = r.cumulative[rank-1]
+	}
+	return (r.cumulative[rank] - previous) / r.cumulative[len(r.heap)-1]
+}
+
+// heap.Interface over the heap array, ordered by descending priority.
+func (r *rankOrder) Len() int { return len(r.heap) }
+
+func (r *rankOrder) Less(i, j int) bool {
+	priorities := r.priorities()
+	return priorities[r.heap[i]] > priorities[r.heap[j]]
+}
+
+func (r *rankOrder) Swap(i, j int) {
+	r.heap[i], r.heap[j] = r.heap[j], r.heap[i]
+	r.position[r.heap[i]] = i
+	r.position[r.heap[j]] = j
+}
+
+func (r *rankOrder) Push(x any) {
+	r.position = append(r.position, len(r.heap))
+	r.heap = append(r.heap, x.(int))
+}
+
+// Pop is never called: slots are overwritten, not removed.
+func (r *rankOrder) Pop() any {
+	panic("replay: rank order does not remove slots")
+}
+
+// sumTree is a binary tree over slot values where every inner node holds the
+// sum of its children, giving O(log n) updates and prefix-sum lookups.
+type sumTree struct {
+	leaves int
+	nodes  []float64
+}
+
+func newSumTree(capacity int) *sumTree {
+	leaves := 1
+	for leaves < capacity {
+		leaves *= 2
+	}
+	return &sumTree{leaves: leaves, nodes: make([]float64, 2*leaves)}
+}
+
+func (t *sumTree) set(slot int, value float64) {
+	node := slot + t.leaves
+	t.nodes[node] = value
+	for node > 1 {
+		node /= 2
+		t.nodes[node] = t.nodes[2*node] + t.nodes[2*node+1]
+	}
+}
+
+func (t *sumTree) get(slot int) float64 {
+	return t.nodes[slot+t.leaves]
+}
+
+func (t *sumTree) total() float64 {
+	return t.nodes[1]
+}
+
+// find returns the slot whose cumulative range contains mass, clamped to the
+// filled slots to guard against floating point drift at the top end.
+func (t *sumTree) find(mass float64, filled int) int {
+	node := 1
+	for node < t.leaves {
+		left := 2 * node
+		if mass < t.nodes[left] || t.nodes[left+1] == 0 {
+			node = left
+		} else {
+			mass -= t.nodes[left]
+			node = left + 1
+		}
+	}
+	slot := node - t.leaves
+	if slot >= filled {
+		slot = filled - 1
+	}
+	return slot
+}
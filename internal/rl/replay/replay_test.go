@@ -0,0 +1,235 @@
+package replay
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func newTestBuffer(t *testing.T, sampling string, capacity int) *Buffer[int] {
+	config := DefaultConfig(capacity)
+	config.Sampling = sampling
+	config.Rand = rand.New(rand.NewSource(1))
+	buffer, err := New[int](config)
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	return buffer
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"default", DefaultConfig(10), false},
+		{"zero capacity", DefaultConfig(0), true},
+		{"unknown sampling", Config{Capacity: 10, Sampling: "lifo"}, true},
+		{"beta above one", Config{Capacity: 10, Beta: 1.5}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.config); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuffer_CapacityOverwritesOldest(t *testing.T) {
+	buffer := newTestBuffer(t, Uniform, 3)
+	for i := 0; i < 5; i++ {
+		buffer.Add(i)
+	}
+
+	if buffer.Len() != 3 {
+		t.Fatalf("Expected length 3, got %d", buffer.Len())
+	}
+	seen := make(map[int]bool)
+	for _, item := range buffer.Sample(100).Items {
+		seen[item] = true
+	}
+	if seen[0] || seen[1] {
+		t.Errorf("Expected oldest items evicted, sampled %v", seen)
+	}
+}
+
+func TestBuffer_UniformWeights(t *testing.T) {
+	buffer := newTestBuffer(t, Uniform, 10)
+	for i := 0; i < 10; i++ {
+		buffer.Add(i)
+	}
+	buffer.UpdatePriorities([]int{0}, []float64{100})
+
+	batch := buffer.Sample(20)
+	for _, weight := range batch.Weights {
+		if weight != 1.0 {
+			t.Fatalf("Expected unit weights for uniform sampling, got %f", weight)
+		}
+	}
+	if buffer.Beta() != DefaultConfig(10).Beta {
+		t.Error("Expected beta to stay fixed for uniform sampling")
+	}
+}
+
+func TestBuffer_PrioritizedSampling(t *testing.T) {
+	for _, sampling := range []string{Proportional, Rank} {
+		t.Run(sampling, func(t *testing.T) {
+			buffer := newTestBuffer(t, sampling, 16)
+			indices := make([]int, 10)
+			errors := make([]float64, 10)
+			for i := 0; i < 10; i++ {
+				indices[i] = buffer.Add(i)
+				errors[i] = 0.1
+			}
+			errors[7] = 10.0
+			buffer.UpdatePriorities(indices, errors)
+
+			counts := make(map[int]int)
+			var rareWeight, commonWeight float64
+			for round := 0; round < 200; round++ {
+				batch := buffer.Sample(8)
+				for i, item := range batch.Items {
+					counts[item]++
+					if batch.Weights[i] > 1.0+1e-12 {
+						t.Fatalf("Weights must be normalized to at most 1, got %f", batch.Weights[i])
+					}
+					if item == 7 {
+						rareWeight = batch.Weights[i]
+					} else {
+						commonWeight = math.Max(commonWeight, batch.Weights[i])
+					}
+				}
+			}
+
+			others := 0
+			for item, count := range counts {
+				if item != 7 {
+					others += count
+				}
+			}
+			if average := others / 9; counts[7] <= average*2 {
+				t.Errorf("Expected high-priority item sampled far more often, got %d vs %d on average", counts[7], average)
+			}
+			if rareWeight >= commonWeight {
+				t.Errorf("Expected smaller IS weight for the high-priority item, got %f vs %f", rareWeight, commonWeight)
+			}
+			if buffer.Beta() <= DefaultConfig(16).Beta {
+				t.Error("Expected beta to anneal towards 1")
+			}
+		})
+	}
+}
+
+func TestBuffer_NewItemsGetMaxPriority(t *testing.T) {
+	buffer := newTestBuffer(t, Proportional, 4)
+	first := buffer.Add(1)
+	buffer.UpdatePriorities([]int{first}, []float64{5.0})
+	second := buffer.Add(2)
+
+	if buffer.priorities[second] != buffer.priorities[first] {
+		t.Errorf("Expected new item to get max priority %f, got %f", buffer.priorities[first], buffer.priorities[second])
+	}
+}
+
+func TestBuffer_RankOrderTracksPriorities(t *testing.T) {
+	buffer := newTestBuffer(t, Rank, 64)
+	random := rand.New(rand.NewSource(3))
+	for i := 0; i < 200; i++ {
+		slot := buffer.Add(i)
+		buffer.UpdatePriorities([]int{slot, random.Intn(buffer.Len())}, []float64{random.Float64(), random.Float64()})
+	}
+
+	order := buffer.rank
+	total := 0.0
+	for index, slot := range order.heap {
+		if order.position[slot] != index {
+			t.Fatalf("Slot %d is at heap index %d but recorded at %d", slot, index, order.position[slot])
+		}
+		for _, child := range []int{2*index + 1, 2*index + 2} {
+			if child < len(order.heap) && buffer.priorities[order.heap[child]] > buffer.priorities[slot] {
+				t.Fatalf("Heap property violated at index %d", index)
+			}
+		}
+		total += order.probability(slot)
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("Expected rank probabilities to sum to 1, got %f", total)
+	}
+}
+
+func BenchmarkBuffer_RankSample(b *testing.B) {
+	config := DefaultConfig(10000)
+	config.Sampling = Rank
+	config.Rand = rand.New(rand.NewSource(1))
+	buffer, _ := New[int](config)
+	for i := 0; i < config.Capacity; i++ {
+		buffer.Add(i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		batch := buffer.Sample(32)
+		errors := make([]float64, len(batch.Indices))
+		for j := range errors {
+			errors[j] = config.Rand.Float64()
+		}
+		buffer.UpdatePriorities(batch.Indices, errors)
+	}
+}
+
+func TestBuffer_StateRoundTrip(t *testing.T) {
+	for _, sampling := range []string{Uniform, Proportional, Rank} {
+		t.Run(sampling, func(t *testing.T) {
+			buffer := newTestBuffer(t, sampling, 4)
+			for i := 0; i < 6; i++ {
+				slot := buffer.Add(i)
+				buffer.UpdatePriorities([]int{slot}, []float64{float64(i)})
+			}
+			buffer.Sample(3)
+
+			restored := newTestBuffer(t, sampling, 4)
+			if err := restored.Restore(buffer.State()); err != nil {
+				t.Fatalf("Restore returned error: %v", err)
+			}
+			buffer.config.Rand = rand.New(rand.NewSource(2))
+			restored.config.Rand = rand.New(rand.NewSource(2))
+			buffer.Add(6)
+			restored.Add(6)
+			want, got := buffer.Sample(5), restored.Sample(5)
+			for i := range want.Items {
+				if want.Items[i] != got.Items[i] || want.Weights[i] != got.Weights[i] {
+					t.Fatalf("Restored buffer sampled %v %v, original %v %v", got.Items, got.Weights, want.Items, want.Weights)
+				}
+			}
+
+			if err := restored.Restore(State[int]{Items: []int{1, 2, 3, 4, 5}, Priorities: make([]float64, 5)}); err == nil {
+				t.Error("Expected a state larger than the capacity to be rejected")
+			}
+		})
+	}
+}
+
+func TestSumTree(t *testing.T) {
+	tree := newSumTree(5)
+	values := []float64{1, 2, 3, 4, 0}
+	for slot, value := range values {
+		tree.set(slot, value)
+	}
+	if tree.total() != 10 {
+		t.Fatalf("Expected total 10, got %f", tree.total())
+	}
+
+	tests := []struct {
+		mass     float64
+		expected int
+	}{
+		{0.5, 0}, {1.0, 1}, {2.9, 1}, {3.0, 2}, {5.99, 2}, {6.0, 3}, {9.99, 3}, {10.0, 3},
+	}
+	for _, tt := range tests {
+		if got := tree.find(tt.mass, len(values)); got != tt.expected {
+			t.Errorf("find(%f) = %d, expected %d", tt.mass, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package rl
+
+import (
+	"textlib-rl-system/internal/logging"
+)
+
+const (
+	AgentKindSARSA         = "sarsa"
+	AgentKindExpectedSARSA = "expected_sarsa"
+)
+
+func init() {
+	RegisterAgent(AgentKindSARSA, func(config SystemConfig) Agent {
+		return NewSARSAAgent(newQLearningAgentFromConfig(config), false)
+	})
+	RegisterAgent(AgentKindExpectedSARSA, func(config SystemConfig) Agent {
+		return NewSARSAAgent(newQLearningAgentFromConfig(config), true)
+	})
+}
+
+// SARSAAgent is an on-policy tabular learner that shares QLearningAgent's
+// Q-table layout and state/action keys. Plain SARSA backs up the value of the
+// next action the policy actually takes; Expected SARSA backs up the
+// expectation of Q under the agent's exploration policy.
+type SARSAAgent struct {
+	*QLearningAgent
+	Expected bool
+
+	next pendingAction
+}
+
+// pendingAction holds an action chosen during an update so the following
+// selection in the same state replays it, keeping the backup on-policy.
+type pendingAction struct {
+	stateKey   string
+	action     Action
+	isExplored bool
+	valid      bool
+}
+
+func (p *pendingAction) set(stateKey string, action Action, isExplored bool) {
+	p.stateKey = stateKey
+	p.action = action
+	p.isExplored = isExplored
+	p.valid = true
+}
+
+func (p *pendingAction) take(stateKey string) (Action, bool, bool) {
+	if !p.valid || p.stateKey != stateKey {
+		p.valid = false
+		return Action{}, false, false
+	}
+	p.valid = false
+	return p.action, p.isExplored, true
+}
+
+func NewSARSAAgent(base *QLearningAgent, expected bool) *SARSAAgent {
+	return &SARSAAgent{
+		QLearningAgent: base,
+		Expected:       expected,
+	}
+}
+
+func (agent *SARSAAgent) kind() string {
+	if agent.Expected {
+		return AgentKindExpectedSARSA
+	}
+	return AgentKindSARSA
+}
+
+func (agent *SARSAAgent) SelectActionWithMetrics(state State) (Action, logging.ActionMetrics) {
+	action, isExploration, ok := agent.next.take(agent.getStateKey(state))
+	if !ok {
+		action, isExploration = agent.chooseAction(state)
+	}
+	return action, agent.actionMetrics(state, action, isExploration, agent.GetQValue(state, action))
+}
+
+func (agent *SARSAAgent) Update(state State, action Action, reward float64, nextState State, done bool) logging.LearningMetrics {
+	nextValue := 0.0
+	if !done {
+		if agent.Expected {
+			nextValue = agent.expectedQValue(nextState)
+		} else {
+			nextAction, isExploration := agent.chooseAction(nextState)
+			agent.next.set(agent.getStateKey(nextState), nextAction, isExploration)
+			nextValue = agent.GetQValue(nextState, nextAction)
+		}
+	}
+
+	agent.moveQValue(state, action, reward+agent.DiscountFactor*nextValue)
+	agent.Exploration.EndStep()
+
+	return agent.learningMetrics()
+}
+
+func (agent *SARSAAgent) expectedQValue(state State) float64 {
+	actions, probabilities := agent.policyProbabilities(state)
+	expected := 0.0
+	for i, action := range actions {
+		expected += probabilities[i] * agent.GetQValue(state, action)
+	}
+	return expected
+}
+
+func (agent *SARSAAgent) EndEpisode(episode logging.EpisodeMetrics) {
+	agent.next = pendingAction{}
+	agent.QLearningAgent.EndEpisode(episode)
+}
+
+func (agent *SARSAAgent) Snapshot() AgentSnapshot {
+	return agent.snapshotAs(agent.kind())
+}
+
+func (agent *SARSAAgent) Restore(snapshot AgentSnapshot) error {
+	return agent.restoreAs(agent.kind(), snapshot)
+}
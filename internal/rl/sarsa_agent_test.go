@@ -0,0 +1,92 @@
+package rl
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSARSAAgent_ReplaysNextAction(t *testing.T) {
+	agent := NewSARSAAgent(NewQLearningAgent(0.5, 0.9, 1.0, 1.0, 1.0), false)
+	state := testState("On-policy replay text.")
+	nextState := state
+	nextState.StepCount = 1
+	nextState.ActionsUsed = []string{"extract_entities"}
+
+	action, _ := agent.SelectActionWithMetrics(state)
+	agent.Update(state, action, 1.0, nextState, false)
+
+	pending := agent.next.action
+	if !agent.next.valid {
+		t.Fatal("Expected SARSA update to record the next action")
+	}
+
+	selected, _ := agent.SelectActionWithMetrics(nextState)
+	if selected.FunctionName != pending.FunctionName {
+		t.Errorf("Expected replayed action %s, got %s", pending.FunctionName, selected.FunctionName)
+	}
+	if agent.next.valid {
+		t.Error("Pending action should be consumed after selection")
+	}
+}
+
+func TestSARSAAgent_UsesSampledNextValue(t *testing.T) {
+	// Greedy policy: the next action is the argmax, so SARSA matches Q-learning
+	agent := NewSARSAAgent(NewQLearningAgent(1.0, 0.5, 0.0, 0.0, 1.0), false)
+	state := testState("Greedy SARSA text.")
+	nextState := state
+	nextState.StepCount = 1
+	nextState.ActionsUsed = []string{"extract_entities"}
+	actions := getDefaultActions()
+	agent.QTable[agent.getStateKey(nextState)] = map[string]float64{agent.getActionKey(actions[2]): 4.0}
+
+	agent.Update(state, actions[0], 1.0, nextState, false)
+
+	if got := agent.GetQValue(state, actions[0]); math.Abs(got-3.0) > 1e-9 {
+		t.Errorf("Expected Q=3.0, got %f", got)
+	}
+	if agent.next.action.FunctionName != actions[2].FunctionName {
+		t.Errorf("Expected next action %s, got %s", actions[2].FunctionName, agent.next.action.FunctionName)
+	}
+}
+
+func TestExpectedSARSAAgent_Update(t *testing.T) {
+	agent := NewSARSAAgent(NewQLearningAgent(1.0, 1.0, 0.5, 0.5, 1.0), true)
+	state := testState("Expected SARSA text.")
+	nextState := state
+	nextState.StepCount = 1
+	nextState.ActionsUsed = []string{"extract_entities"}
+	actions := getDefaultActions()
+	agent.QTable[agent.getStateKey(nextState)] = map[string]float64{agent.getActionKey(actions[3]): 8.0}
+
+	agent.Update(state, actions[0], 0.0, nextState, false)
+
+	// Greedy action gets 1-eps+eps/|A|, the rest eps/|A| of zero value, where
+	// A is the set of actions still available in the next state
+	expected := 8.0 * (0.5 + 0.5/float64(len(agent.getAvailableActions(nextState))))
+	if got := agent.GetQValue(state, actions[0]); math.Abs(got-expected) > 1e-9 {
+		t.Errorf("Expected Q=%f, got %f", expected, got)
+	}
+	if agent.next.valid {
+		t.Error("Expected SARSA should not pin the next action")
+	}
+}
+
+func TestSARSAAgent_SnapshotKind(t *testing.T) {
+	for _, kind := range []string{AgentKindSARSA, AgentKindExpectedSARSA} {
+		agent, err := NewAgent(SystemConfig{AgentType: kind})
+		if err != nil {
+			t.Fatalf("NewAgent(%s) returned error: %v", kind, err)
+		}
+
+		snapshot := agent.Snapshot()
+		if snapshot.Kind != kind {
+			t.Errorf("Expected snapshot kind %s, got %s", kind, snapshot.Kind)
+		}
+		if err := agent.Restore(snapshot); err != nil {
+			t.Errorf("Restore(%s) returned error: %v", kind, err)
+		}
+		if err := agent.Restore(AgentSnapshot{Kind: AgentKindQLearning}); err == nil {
+			t.Errorf("Expected %s agent to reject Q-learning snapshot", kind)
+		}
+	}
+}
@@ -69,26 +69,51 @@ func NewActionSimulator() *ActionSimulator {
 	}
 }
 
+// newSimulatorFromConfig builds a simulator whose failures follow config.Seed.
+func newSimulatorFromConfig(config SystemConfig) *ActionSimulator {
+	simulator := NewActionSimulator()
+	simulator.Rand = newRand(config.Seed, "simulator")
+	return simulator
+}
+
+// NewInstantSimulator is newSimulatorFromConfig without the simulated
+// latency, for runs where only the chosen actions matter.
+func NewInstantSimulator(config SystemConfig) *ActionSimulator {
+	simulator := newSimulatorFromConfig(config)
+	simulator.Instant = true
+	return simulator
+}
+
+// newEvaluationSimulator is the instant simulator held-out evaluation runs on.
+// It draws from its own stream so evaluating does not change which calls
+// fail during training.
+func newEvaluationSimulator(config SystemConfig) *ActionSimulator {
+	simulator := NewActionSimulator()
+	simulator.Instant = true
+	simulator.Rand = newRand(config.Seed, "evaluation")
+	return simulator
+}
+
 func (sim *ActionSimulator) ExecuteAction(action Action, input string, params map[string]interface{}) ActionResult {
-	startTime := time.Now()
-	
 	function, exists := sim.Functions[action.FunctionName]
 	if !exists {
 		return ActionResult{
 			Success:    false,
 			Output:     nil,
 			Error:      fmt.Sprintf("unknown function: %s", action.FunctionName),
-			Duration:   time.Since(startTime),
 			MemoryUsed: 1024,
 		}
 	}
 	
 	// Simulate execution time based on input size and function complexity
 	executionTime := time.Duration(len(input)/100+action.Cost*10) * time.Millisecond
-	time.Sleep(executionTime)
+	executionTime += parameterLatency(action.FunctionName, params)
+	if !sim.Instant {
+		time.Sleep(executionTime)
+	}
 	
 	// Determine success based on base success rate
-	success := simulateSuccess(function.BaseSuccessRate)
+	success := simulateSuccess(function.BaseSuccessRate, randFloat64(sim.Rand))
 	
 	var output interface{}
 	var errorMsg string
@@ -108,40 +133,106 @@ func (sim *ActionSimulator) ExecuteAction(action Action, input string, params ma
 		Success:    success,
 		Output:     output,
 		Error:      errorMsg,
-		Duration:   time.Since(startTime),
+		// Report the simulated latency, not the wall clock, so rewards
+		// do not depend on how busy the machine is
+		Duration:   executionTime,
 		MemoryUsed: int64(len(input) * 2), // Simplified memory calculation
 	}
 }
 
-func simulateSuccess(baseRate float64) bool {
+func simulateSuccess(baseRate float64, noise float64) bool {
 	// Add some randomness to success rate
-	return (baseRate + (0.1 * (0.5 - float64(time.Now().UnixNano()%1000)/1000.0))) > 0.5
+	return (baseRate + (0.1 * (0.5 - noise))) > 0.5
+}
+
+// parameterLatency is the extra simulated time parameter choices cost: larger
+// entity caps and samples and advanced statistics are slower.
+func parameterLatency(functionName string, params map[string]interface{}) time.Duration {
+	switch functionName {
+	case "extract_entities":
+		return time.Duration(intParam(params, "max_entities", 100)/100) * time.Millisecond
+	case "analyze_readability":
+		latency := time.Duration(intParam(params, "sample_size", 1000)/1000) * time.Millisecond
+		if boolParam(params, "include_advanced", false) {
+			latency += 5 * time.Millisecond
+		}
+		return latency
+	}
+	return 0
 }
 
+// simulateEntityExtraction scores candidate words and keeps those at or above
+// confidence_threshold, up to max_entities. Low thresholds find more entities
+// but let weak candidates through.
 func simulateEntityExtraction(input string, params map[string]interface{}) (interface{}, error) {
+	threshold := floatParam(params, "confidence_threshold", 0.5)
+	maxEntities := intParam(params, "max_entities", 100)
+
 	words := strings.Fields(input)
 	entities := []map[string]interface{}{}
 	
 	for i, word := range words {
-		if len(word) > 5 && i%3 == 0 { // Simulate entity detection
-			entities = append(entities, map[string]interface{}{
-				"text":  word,
-				"type":  "ENTITY",
-				"start": i,
-				"end":   i + len(word),
-			})
+		if len(entities) >= maxEntities {
+			break
 		}
+		trimmed := strings.Trim(word, ".,;:!?()\"'")
+		if len(trimmed) < 4 || isCommonWord(trimmed) {
+			continue
+		}
+		confidence := entityConfidence(trimmed)
+		if confidence < threshold {
+			continue
+		}
+		entities = append(entities, map[string]interface{}{
+			"text":       trimmed,
+			"type":       "ENTITY",
+			"start":      i,
+			"end":        i + len(trimmed),
+			"confidence": confidence,
+		})
 	}
 	
 	return map[string]interface{}{
-		"entities": entities,
-		"count":    len(entities),
+		"entities":  entities,
+		"count":     len(entities),
+		"threshold": threshold,
 	}, nil
 }
 
+// entityConfidence is a crude score for how entity-like a word is.
+func entityConfidence(word string) float64 {
+	confidence := 0.3
+	if word[0] >= 'A' && word[0] <= 'Z' {
+		confidence += 0.4
+	}
+	if len(word) > 7 {
+		confidence += 0.15
+	}
+	if strings.ContainsAny(word, "0123456789") {
+		confidence += 0.1
+	}
+	if confidence > 0.95 {
+		confidence = 0.95
+	}
+	return confidence
+}
+
+// simulateReadabilityAnalysis scores at most sample_size words; longer texts
+// are scored on their opening only. include_advanced adds word-level
+// statistics.
 func simulateReadabilityAnalysis(input string, params map[string]interface{}) (interface{}, error) {
-	words := len(strings.Fields(input))
-	sentences := strings.Count(input, ".") + strings.Count(input, "!") + strings.Count(input, "?")
+	sampleSize := intParam(params, "sample_size", 1000)
+	includeAdvanced := boolParam(params, "include_advanced", false)
+
+	allWords := strings.Fields(input)
+	sampled := sampleSize > 0 && len(allWords) > sampleSize
+	sample := input
+	if sampled {
+		sample = strings.Join(allWords[:sampleSize], " ")
+	}
+
+	words := len(strings.Fields(sample))
+	sentences := strings.Count(sample, ".") + strings.Count(sample, "!") + strings.Count(sample, "?")
 	if sentences == 0 {
 		sentences = 1
 	}
@@ -156,13 +247,30 @@ func simulateReadabilityAnalysis(input string, params map[string]interface{}) (i
 		readabilityScore = 100
 	}
 	
-	return map[string]interface{}{
+	output := map[string]interface{}{
 		"readability_score":      readabilityScore,
 		"avg_words_per_sentence": avgWordsPerSentence,
 		"total_words":           words,
 		"total_sentences":       sentences,
 		"level":                 getReadabilityLevel(readabilityScore),
-	}, nil
+		"sampled":               sampled,
+	}
+	if includeAdvanced {
+		letters, longWords := 0, 0
+		for _, word := range strings.Fields(sample) {
+			letters += len(word)
+			if len(word) > 6 {
+				longWords++
+			}
+		}
+		advanced := map[string]interface{}{"avg_word_length": 0.0, "long_word_ratio": 0.0}
+		if words > 0 {
+			advanced["avg_word_length"] = float64(letters) / float64(words)
+			advanced["long_word_ratio"] = float64(longWords) / float64(words)
+		}
+		output["advanced"] = advanced
+	}
+	return output, nil
 }
 
 func simulateCodeDetection(input string, params map[string]interface{}) (interface{}, error) {
@@ -245,23 +353,41 @@ func simulateSentimentAnalysis(input string, params map[string]interface{}) (int
 	}, nil
 }
 
+// simulateTextSummary keeps the first and last sentence. delimiter_style
+// decides what ends a sentence and sentences shorter than
+// min_sentence_length characters are skipped.
 func simulateTextSummary(input string, params map[string]interface{}) (interface{}, error) {
-	sentences := strings.Split(input, ".")
+	minLength := intParam(params, "min_sentence_length", 10)
+	delimiters := ".!?"
+	switch stringParam(params, "delimiter_style", "standard") {
+	case "aggressive":
+		delimiters = ".!?;:\n"
+	case "conservative":
+		delimiters = "."
+	}
+
+	sentences := []string{}
+	for _, sentence := range strings.FieldsFunc(input, func(r rune) bool { return strings.ContainsRune(delimiters, r) }) {
+		if trimmed := strings.TrimSpace(sentence); len(trimmed) >= minLength {
+			sentences = append(sentences, trimmed)
+		}
+	}
 	if len(sentences) <= 2 {
 		return map[string]interface{}{
-			"summary": input,
-			"ratio":   1.0,
+			"summary":           input,
+			"ratio":             1.0,
+			"compression_ratio": 1.0,
 		}, nil
 	}
 	
-	// Take first and last sentence as a simple summary
-	summary := strings.TrimSpace(sentences[0]) + ". " + strings.TrimSpace(sentences[len(sentences)-2]) + "."
+	summary := sentences[0] + ". " + sentences[len(sentences)-1] + "."
 	
 	return map[string]interface{}{
 		"summary":          summary,
 		"original_length":  len(input),
 		"summary_length":   len(summary),
 		"compression_ratio": float64(len(summary)) / float64(len(input)),
+		"sentences":        len(sentences),
 	}, nil
 }
 
@@ -0,0 +1,176 @@
+package rl
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+const (
+	SplitRandom     = "random"
+	SplitStratified = "stratified"
+)
+
+// SplitConfig describes a train/validation/test split. The fractions are of
+// the whole dataset; whatever is left over is the training split. The same
+// seed always yields the same split of the same examples.
+type SplitConfig struct {
+	Strategy           string // random (default) or stratified by TaskType
+	ValidationFraction float64
+	TestFraction       float64
+	Seed               int64
+}
+
+// SplitConfigFrom reads the split settings from the system configuration.
+func SplitConfigFrom(config SystemConfig) SplitConfig {
+	return SplitConfig{
+		Strategy:           config.SplitStrategy,
+		ValidationFraction: config.ValidationFraction,
+		TestFraction:       config.TestFraction,
+		Seed:               config.SplitSeed,
+	}
+}
+
+// Enabled reports whether the split holds anything out.
+func (config SplitConfig) Enabled() bool {
+	return config.ValidationFraction > 0 || config.TestFraction > 0
+}
+
+func (config SplitConfig) validate() error {
+	switch config.Strategy {
+	case "", SplitRandom, SplitStratified:
+	default:
+		return fmt.Errorf("unknown split strategy %q (want %s or %s)", config.Strategy, SplitRandom, SplitStratified)
+	}
+	if config.ValidationFraction < 0 || config.TestFraction < 0 || config.ValidationFraction+config.TestFraction >= 1 {
+		return fmt.Errorf("validation (%g) and test (%g) fractions must be non-negative and leave examples to train on",
+			config.ValidationFraction, config.TestFraction)
+	}
+	return nil
+}
+
+// DatasetSplits holds the three disjoint parts of a dataset.
+type DatasetSplits struct {
+	Train      []TrainingExample
+	Validation []TrainingExample
+	Test       []TrainingExample
+}
+
+// SplitExamples partitions examples according to config. Split sizes are the
+// fractions of the whole dataset, rounded, and at least one example is always
+// kept for training. The stratified strategy gives every TaskType the same
+// proportions as far as whole examples allow, using largest remainders to
+// decide which task types give up an example when the shares do not divide
+// evenly.
+func SplitExamples(examples []TrainingExample, config SplitConfig) (DatasetSplits, error) {
+	if err := config.validate(); err != nil {
+		return DatasetSplits{}, err
+	}
+	rng := rand.New(rand.NewSource(config.Seed))
+
+	total := len(examples)
+	testSize := int(math.Round(float64(total) * config.TestFraction))
+	validationSize := int(math.Round(float64(total) * config.ValidationFraction))
+	for total > 0 && testSize+validationSize >= total {
+		if validationSize >= testSize {
+			validationSize--
+		} else {
+			testSize--
+		}
+	}
+
+	// Sort first so the split depends on the seed and not the input order
+	sorted := append([]TrainingExample(nil), examples...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	var groups [][]TrainingExample
+	if config.Strategy == SplitStratified {
+		groups = groupByTaskType(sorted)
+	} else {
+		groups = [][]TrainingExample{sorted}
+	}
+	for _, group := range groups {
+		rng.Shuffle(len(group), func(i, j int) { group[i], group[j] = group[j], group[i] })
+	}
+	// Shuffling the group order breaks remainder ties differently per seed
+	rng.Shuffle(len(groups), func(i, j int) { groups[i], groups[j] = groups[j], groups[i] })
+
+	sizes := make([]int, len(groups))
+	for i, group := range groups {
+		sizes[i] = len(group)
+	}
+	testCounts := apportion(sizes, sizes, config.TestFraction, testSize)
+	remaining := make([]int, len(groups))
+	for i := range groups {
+		remaining[i] = sizes[i] - testCounts[i]
+	}
+	validationCounts := apportion(sizes, remaining, config.ValidationFraction, validationSize)
+
+	var splits DatasetSplits
+	for i, group := range groups {
+		test, validation := testCounts[i], testCounts[i]+validationCounts[i]
+		splits.Test = append(splits.Test, group[:test]...)
+		splits.Validation = append(splits.Validation, group[test:validation]...)
+		splits.Train = append(splits.Train, group[validation:]...)
+	}
+	return splits, nil
+}
+
+// apportion hands out target slots across groups in proportion to their
+// sizes: every group gets the floor of its share, then the groups with the
+// largest remainders get one more until the target is met. No group gets
+// more than its capacity.
+func apportion(sizes, capacity []int, fraction float64, target int) []int {
+	counts := make([]int, len(sizes))
+	remainders := make([]float64, len(sizes))
+	assigned := 0
+	for i, size := range sizes {
+		share := float64(size) * fraction
+		counts[i] = int(math.Min(math.Floor(share), float64(capacity[i])))
+		remainders[i] = share - float64(counts[i])
+		assigned += counts[i]
+	}
+
+	order := make([]int, len(sizes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return remainders[order[a]] > remainders[order[b]] })
+	for assigned < target {
+		progressed := false
+		for _, i := range order {
+			if assigned >= target {
+				break
+			}
+			if counts[i] < capacity[i] {
+				counts[i]++
+				assigned++
+				progressed = true
+			}
+		}
+		if !progressed {
+			break
+		}
+	}
+	return counts
+}
+
+// groupByTaskType groups the examples by TaskType, in task order.
+func groupByTaskType(examples []TrainingExample) [][]TrainingExample {
+	byTask := make(map[string][]TrainingExample)
+	for _, example := range examples {
+		byTask[example.TaskType] = append(byTask[example.TaskType], example)
+	}
+	tasks := make([]string, 0, len(byTask))
+	for task := range byTask {
+		tasks = append(tasks, task)
+	}
+	sort.Strings(tasks)
+
+	groups := make([][]TrainingExample, len(tasks))
+	for i, task := range tasks {
+		groups[i] = byTask[task]
+	}
+	return groups
+}
@@ -0,0 +1,102 @@
+package rl
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func splitFixture() []TrainingExample {
+	var examples []TrainingExample
+	for task, count := range map[string]int{"code_analysis": 10, "news_analysis": 6, "legal_analysis": 4} {
+		for i := 0; i < count; i++ {
+			examples = append(examples, TrainingExample{ID: fmt.Sprintf("%s_%d", task, i), Text: "text", TaskType: task})
+		}
+	}
+	return examples
+}
+
+func ids(examples []TrainingExample) []string {
+	result := make([]string, len(examples))
+	for i, example := range examples {
+		result[i] = example.ID
+	}
+	return result
+}
+
+func TestSplitExamples_Deterministic(t *testing.T) {
+	config := SplitConfig{ValidationFraction: 0.2, TestFraction: 0.1, Seed: 7}
+	first, err := SplitExamples(splitFixture(), config)
+	if err != nil {
+		t.Fatalf("SplitExamples returned error: %v", err)
+	}
+	if len(first.Train) != 14 || len(first.Validation) != 4 || len(first.Test) != 2 {
+		t.Fatalf("Expected 14/4/2, got %d/%d/%d", len(first.Train), len(first.Validation), len(first.Test))
+	}
+
+	// Input order and map iteration must not matter, only the seed
+	second, _ := SplitExamples(splitFixture(), config)
+	if !reflect.DeepEqual(ids(first.Validation), ids(second.Validation)) {
+		t.Errorf("Expected the same seed to give the same split, got %v and %v", ids(first.Validation), ids(second.Validation))
+	}
+	config.Seed = 8
+	other, _ := SplitExamples(splitFixture(), config)
+	if reflect.DeepEqual(ids(first.Validation), ids(other.Validation)) && reflect.DeepEqual(ids(first.Test), ids(other.Test)) {
+		t.Error("Expected a different seed to give a different split")
+	}
+
+	seen := make(map[string]bool)
+	for _, part := range [][]TrainingExample{first.Train, first.Validation, first.Test} {
+		for _, id := range ids(part) {
+			if seen[id] {
+				t.Fatalf("Expected disjoint splits, %s appears twice", id)
+			}
+			seen[id] = true
+		}
+	}
+}
+
+func TestSplitExamples_Stratified(t *testing.T) {
+	splits, err := SplitExamples(splitFixture(), SplitConfig{Strategy: SplitStratified, ValidationFraction: 0.25, TestFraction: 0.25, Seed: 1})
+	if err != nil {
+		t.Fatalf("SplitExamples returned error: %v", err)
+	}
+	count := func(examples []TrainingExample) map[string]int {
+		counts := make(map[string]int)
+		for _, example := range examples {
+			counts[example.TaskType]++
+		}
+		return counts
+	}
+	// 20 examples: 5 validation, 5 test, shared out by task size
+	validation, test := count(splits.Validation), count(splits.Test)
+	if len(splits.Validation) != 5 || len(splits.Test) != 5 {
+		t.Fatalf("Expected 5 validation and 5 test examples, got %d and %d", len(splits.Validation), len(splits.Test))
+	}
+	if validation["legal_analysis"] != 1 || test["legal_analysis"] != 1 || validation["code_analysis"] < 2 || test["code_analysis"] < 2 {
+		t.Errorf("Expected every task represented in proportion, got validation %v test %v", validation, test)
+	}
+
+	// Singleton task types still yield the requested totals
+	singletons := GetRealisticTrainingData()
+	splits, _ = SplitExamples(singletons, SplitConfig{Strategy: SplitStratified, ValidationFraction: 0.2, TestFraction: 0.2})
+	if len(splits.Validation) != 2 || len(splits.Test) != 2 || len(splits.Train) != len(singletons)-4 {
+		t.Errorf("Expected 2/2 held out of %d, got %d/%d", len(singletons), len(splits.Validation), len(splits.Test))
+	}
+}
+
+func TestSplitExamples_Validation(t *testing.T) {
+	if _, err := SplitExamples(nil, SplitConfig{Strategy: "by_length"}); err == nil {
+		t.Error("Expected an unknown strategy to be rejected")
+	}
+	if _, err := SplitExamples(nil, SplitConfig{ValidationFraction: 0.5, TestFraction: 0.5}); err == nil {
+		t.Error("Expected fractions leaving nothing to train on to be rejected")
+	}
+	splits, err := SplitExamples(splitFixture()[:2], SplitConfig{ValidationFraction: 0.45, TestFraction: 0.45})
+	if err != nil || len(splits.Train) != 1 {
+		t.Errorf("Expected one example kept for training, got %d (%v)", len(splits.Train), err)
+	}
+	if splits, _ := SplitExamples(splitFixture(), SplitConfig{}); len(splits.Train) != 20 {
+		t.Errorf("Expected no split to train on everything, got %d", len(splits.Train))
+	}
+}
@@ -12,8 +12,13 @@ import (
 )
 
 func NewEnhancedRLSystem(config SystemConfig) *EnhancedRLSystem {
+	var tuner *ParameterTuner
+	if config.ParameterTuningInterval > 0 {
+		tuner = NewParameterTuner(config)
+	}
+
 	return &EnhancedRLSystem{
-		Agent: NewQLearningAgent(0.1, 0.95, 1.0, 0.01, 0.995),
+		Agent: newQLearningAgentFromConfig(config),
 		RewardCalc: &RewardCalculator{
 			TaskWeights: map[string]float64{
 				"entity_extraction":    1.0,
@@ -23,8 +28,23 @@ func NewEnhancedRLSystem(config SystemConfig) *EnhancedRLSystem {
 			},
 		},
 		Config:           config,
-		availableActions: getDefaultActions(),
-		simulator:        NewActionSimulator(),
+		actionSpace:      NewActionSpace(config),
+		executor:         newSimulatorFromConfig(config),
+		evaluator:        newEvaluationSimulator(config),
+		tuner:            tuner,
+	}
+}
+
+func (system *EnhancedRLSystem) SetAgent(agent Agent) {
+	system.Agent = agent
+}
+
+// SetExecutor replaces the simulator that runs the chosen actions, and that
+// parameter tuning scores candidates on.
+func (system *EnhancedRLSystem) SetExecutor(executor Executor) {
+	system.executor = executor
+	if system.tuner != nil {
+		system.tuner.useExecutor(executor)
 	}
 }
 
@@ -38,6 +58,7 @@ func (system *EnhancedRLSystem) SetTelemetry(telemetry *telemetry.TelemetryClien
 
 func (system *EnhancedRLSystem) LoadTrainingData(data []TrainingExample) {
 	system.TrainingData = data
+	system.curriculum = newCurriculumFromConfig(system.Config, data)
 }
 
 func (system *EnhancedRLSystem) TrainWithLogging() {
@@ -47,29 +68,59 @@ func (system *EnhancedRLSystem) TrainWithLogging() {
 	defer system.Logger.EndSession()
 	defer system.SaveFinalModel()
 
-	for episode := 0; episode < system.Config.MaxEpisodes; episode++ {
+	for episode := system.episode; episode < system.Config.MaxEpisodes; episode++ {
+		if system.tuner != nil && episode%system.Config.ParameterTuningInterval == 0 {
+			system.tuneParameters(episode)
+		}
+
 		episodeID := fmt.Sprintf("%s-ep%d", sessionID, episode)
-		episodeMetrics := system.runEpisodeWithLogging(episodeID)
+		example := system.selectTrainingExample(episode)
+		episodeMetrics := system.runEpisodeWithLogging(episodeID, example)
+		if system.curriculum != nil {
+			episodeMetrics.DifficultyCeiling = system.curriculum.Ceiling()
+			system.curriculum.Observe(example, episodeMetrics)
+		}
 
 		system.Logger.LogEpisodeSummary(episodeMetrics)
 
-		if episode%system.Config.CheckpointInterval == 0 {
-			system.checkpointModel(episode)
-		}
-
 		if episode%system.Config.LoggingInterval == 0 {
+			system.validate(episode)
+			if system.curriculum != nil {
+				log.Printf("Episode %d curriculum: difficulty ceiling %.2f, %d/%d examples available",
+					episode, system.curriculum.Ceiling(), system.curriculum.Available(), len(system.TrainingData))
+			}
 			insights := system.analyzeProgress()
 			system.Logger.LogInsights(insights)
 		}
+
+		// Checkpoint last, so a resumed run starts from the state the next
+		// episode would have seen
+		system.episode = episode + 1
+		if system.Config.CheckpointInterval > 0 && system.episode%system.Config.CheckpointInterval == 0 {
+			system.checkpointModel()
+		}
+	}
+}
+
+// validate evaluates the validation split and logs the result.
+func (system *EnhancedRLSystem) validate(episode int) {
+	if len(system.ValidationData) == 0 {
+		return
 	}
+	result := system.Evaluate("validation", system.ValidationData)
+	result.Episode = episode
+	system.validationHistory = append(system.validationHistory, result)
+	log.Printf("Episode %d validation: reward %.3f, quality %.3f, success %.1f%% over %d examples",
+		episode, result.AverageReward, result.AverageQuality, result.SuccessRate*100, result.Examples)
 }
 
-func (system *EnhancedRLSystem) runEpisodeWithLogging(episodeID string) logging.EpisodeMetrics {
-	example := system.selectTrainingExample()
+func (system *EnhancedRLSystem) runEpisodeWithLogging(episodeID string, example TrainingExample) logging.EpisodeMetrics {
 	state := system.createInitialState(example)
 
 	episodeMetrics := logging.EpisodeMetrics{
-		EpisodeID: episodeID,
+		EpisodeID:  episodeID,
+		ExampleID:  example.ID,
+		Difficulty: example.Difficulty,
 		StartTime: time.Now(),
 		Actions:   []logging.ActionMetrics{},
 		Rewards:   []float64{},
@@ -89,6 +140,10 @@ func (system *EnhancedRLSystem) runEpisodeWithLogging(episodeID string) logging.
 		})
 
 		action, actionMetrics := system.Agent.SelectActionWithMetrics(state)
+		// The agent learns about the action it chose; the call that runs
+		// carries the tuned parameters, if any
+		executed := system.applyTunedParameters(action)
+		actionMetrics.Parameters = executed.Parameters
 
 		system.Logger.LogEvent(logging.LogEvent{
 			Timestamp:   time.Now(),
@@ -98,44 +153,48 @@ func (system *EnhancedRLSystem) runEpisodeWithLogging(episodeID string) logging.
 			ActionTaken: actionMetrics,
 		})
 
-		result := system.simulateAction(state, action, example)
+		result := system.simulateAction(state, executed, example)
 
 		// Use enhanced reward calculation
 		enhancedCalc := NewEnhancedRewardCalculator()
-		reward := enhancedCalc.CalculateReward(state, action, result, example)
+		reward := enhancedCalc.CalculateReward(state, executed, result, example)
 
 		system.Logger.LogEvent(logging.LogEvent{
 			Timestamp:     time.Now(),
 			EpisodeID:     episodeID,
 			StepNumber:    step,
 			EventType:     "reward_calculated",
-			ResultMetrics: system.extractResultMetrics(result),
+			ResultMetrics: system.extractResultMetrics(executed, result, example),
 			Performance: logging.PerformanceMetrics{
 				CumulativeReward: reward,
 			},
 		})
 
 		nextState := system.updateState(state, action, result)
+		done := system.isTaskComplete(nextState)
 
 		oldQValue := system.Agent.GetQValue(state, action)
-		system.Agent.UpdateQValue(state, action, reward, nextState)
+		learningMetrics := system.Agent.Update(state, action, reward, nextState, done)
 		newQValue := system.Agent.GetQValue(state, action)
+		learningMetrics.QValueConvergence = math.Abs(newQValue - oldQValue)
+		system.explorationRate = learningMetrics.ExplorationRate
 
 		system.Logger.LogEvent(logging.LogEvent{
-			Timestamp:  time.Now(),
-			EpisodeID:  episodeID,
-			StepNumber: step,
-			EventType:  "q_value_updated",
-			LearningMetrics: logging.LearningMetrics{
-				QValueConvergence: math.Abs(newQValue - oldQValue),
-			},
+			Timestamp:       time.Now(),
+			EpisodeID:       episodeID,
+			StepNumber:      step,
+			EventType:       "q_value_updated",
+			LearningMetrics: learningMetrics,
 		})
+		if system.Telemetry != nil {
+			system.Telemetry.RecordLearningMetrics(learningMetrics)
+		}
 
 		episodeMetrics.Actions = append(episodeMetrics.Actions, actionMetrics)
 		episodeMetrics.Rewards = append(episodeMetrics.Rewards, reward)
 		episodeMetrics.States = append(episodeMetrics.States, stateMetrics)
 
-		if system.isTaskComplete(nextState) {
+		if done {
 			break
 		}
 
@@ -145,10 +204,14 @@ func (system *EnhancedRLSystem) runEpisodeWithLogging(episodeID string) logging.
 	episodeMetrics.EndTime = time.Now()
 	episodeMetrics.TotalReward = sum(episodeMetrics.Rewards)
 
+	// Agents flush multi-step returns and reset eligibility traces here
+	system.Agent.EndEpisode(episodeMetrics)
+
 	return episodeMetrics
 }
 
-func (system *EnhancedRLSystem) selectTrainingExample() TrainingExample {
+// selectTrainingExample draws the example for episode from the curriculum.
+func (system *EnhancedRLSystem) selectTrainingExample(episode int) TrainingExample {
 	if len(system.TrainingData) == 0 {
 		return TrainingExample{
 			ID:       "default",
@@ -161,9 +224,10 @@ func (system *EnhancedRLSystem) selectTrainingExample() TrainingExample {
 			Difficulty: 0.5,
 		}
 	}
-	// Randomly select from training data for variety
-	idx := int(time.Now().UnixNano()) % len(system.TrainingData)
-	return system.TrainingData[idx]
+	if system.curriculum == nil {
+		system.curriculum = newCurriculumFromConfig(system.Config, system.TrainingData)
+	}
+	return system.curriculum.Sample(episode)
 }
 
 func (system *EnhancedRLSystem) createInitialState(example TrainingExample) State {
@@ -194,13 +258,54 @@ func (system *EnhancedRLSystem) extractStateMetrics(state State) logging.StateMe
 }
 
 func (system *EnhancedRLSystem) simulateAction(state State, action Action, example TrainingExample) ActionResult {
-	return system.simulator.ExecuteAction(action, state.Text, action.Parameters)
+	return system.executor.ExecuteAction(action, state.Text, action.Parameters)
+}
+
+func (system *EnhancedRLSystem) applyTunedParameters(action Action) Action {
+	if system.tuner == nil {
+		return action
+	}
+	return system.tuner.Apply(action)
 }
 
-func (system *EnhancedRLSystem) extractResultMetrics(result ActionResult) logging.ResultMetrics {
+// tuneParameters runs the GA for every tunable function on executor rollouts.
+func (system *EnhancedRLSystem) tuneParameters(episode int) {
+	for functionName, fitness := range system.tuner.Tune(system.TrainingData) {
+		log.Printf("Episode %d: tuned %s parameters %v (fitness %.3f)",
+			episode, functionName, system.tuner.Optimizer.bestParameters[functionName], fitness)
+	}
+}
+
+// ParameterTuning returns the tuner state to persist with the model, or nil
+// when tuning is disabled.
+func (system *EnhancedRLSystem) ParameterTuning() *ParameterTuningState {
+	if system.tuner == nil {
+		return nil
+	}
+	state := system.tuner.State()
+	return &state
+}
+
+// RestoreParameterTuning loads previously tuned parameters. It is a no-op when
+// tuning is disabled.
+func (system *EnhancedRLSystem) RestoreParameterTuning(state ParameterTuningState) {
+	if system.tuner != nil {
+		system.tuner.LoadState(state)
+	}
+}
+
+// extractResultMetrics scores the output against the example's ground truth
+// when it answers one of the expected fields, and falls back to the output
+// heuristic otherwise.
+func (system *EnhancedRLSystem) extractResultMetrics(action Action, result ActionResult, example TrainingExample) logging.ResultMetrics {
+	quality := calculateOutputQuality(result.Output)
+	if score, ok := ScoreOutput(action.FunctionName, result.Output, example.Expected); ok {
+		quality = score.Score
+	}
+
 	return logging.ResultMetrics{
 		Success:       result.Success,
-		OutputQuality: calculateOutputQuality(result.Output),
+		OutputQuality: quality,
 		ExecutionTime: result.Duration.Seconds(),
 		MemoryUsed:    result.MemoryUsed,
 		ErrorType:     result.Error,
@@ -212,7 +317,13 @@ func (system *EnhancedRLSystem) updateState(state State, action Action, result A
 	newState := state
 	newState.StepCount++
 	newState.RemainingBudget -= action.Cost
-	newState.ActionsUsed = append(newState.ActionsUsed, action.FunctionName)
+	// Copy rather than extend the previous state's slice and map: the agent
+	// keys its update on the old state after this returns
+	newState.ActionsUsed = append(append([]string(nil), state.ActionsUsed...), action.FunctionName)
+	newState.CurrentResults = make(map[string]interface{}, len(state.CurrentResults)+1)
+	for name, output := range state.CurrentResults {
+		newState.CurrentResults[name] = output
+	}
 	
 	if result.Success {
 		newState.CurrentResults[action.FunctionName] = result.Output
@@ -221,23 +332,32 @@ func (system *EnhancedRLSystem) updateState(state State, action Action, result A
 	return newState
 }
 
+// isTaskComplete ends the episode when the budget or step cap is reached, or
+// when preconditions mask every remaining action.
 func (system *EnhancedRLSystem) isTaskComplete(state State) bool {
-	return state.RemainingBudget <= 0 || len(state.ActionsUsed) >= 10
-}
-
-func (system *EnhancedRLSystem) checkpointModel(episode int) {
-	log.Printf("Checkpointing model at episode %d", episode)
+	return state.RemainingBudget <= 0 || len(state.ActionsUsed) >= 10 ||
+		len(system.actionSpace.Available(state)) == 0
 }
 
 func (system *EnhancedRLSystem) analyzeProgress() interface{} {
-	return map[string]interface{}{
+	progress := map[string]interface{}{
 		"timestamp": time.Now(),
 		"progress":  "ongoing",
 	}
+	if len(system.validationHistory) > 0 {
+		progress["validation"] = system.validationHistory[len(system.validationHistory)-1]
+	}
+	return progress
 }
 
+// SaveFinalModel checkpoints the end of training unless the last periodic
+// checkpoint already covers it, so a finished run can be extended by
+// resuming with more episodes.
 func (system *EnhancedRLSystem) SaveFinalModel() {
-	log.Println("Saving final model")
+	if system.lastCheckpoint == system.episode {
+		return
+	}
+	system.checkpointModel()
 }
 
 func (system *EnhancedRLSystem) SaveInsights(insights interface{}) {
@@ -266,19 +386,10 @@ func calculateTextComplexity(text string) float64 {
 	return math.Min(1.0, float64(len(text))/1000.0)
 }
 
-func calculateEntityDensity(text string) float64 {
-	return 0.1 // Simplified
-}
-
-func detectCodePresence(text string) bool {
-	return false // Simplified
-}
-
-func detectMathPresence(text string) bool {
-	return false // Simplified
-}
-
 func calculateOutputQuality(output interface{}) float64 {
+	if output == nil {
+		return 0.0
+	}
 	return 0.8 // Simplified
 }
 
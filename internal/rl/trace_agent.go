@@ -0,0 +1,149 @@
+package rl
+
+import (
+	"textlib-rl-system/internal/logging"
+)
+
+const (
+	AgentKindQLambda     = "q_lambda"
+	AgentKindSARSALambda = "sarsa_lambda"
+)
+
+// traceCutoff drops eligibility traces once they no longer move Q-values.
+const traceCutoff = 1e-4
+
+func init() {
+	RegisterAgent(AgentKindQLambda, func(config SystemConfig) Agent {
+		return NewTraceAgent(newQLearningAgentFromConfig(config), *withAgentDefaults(config).TraceLambda, false)
+	})
+	RegisterAgent(AgentKindSARSALambda, func(config SystemConfig) Agent {
+		return NewTraceAgent(newQLearningAgentFromConfig(config), *withAgentDefaults(config).TraceLambda, true)
+	})
+}
+
+// TraceAgent implements λ-return learning with replacing eligibility traces.
+// With OnPolicy set it is SARSA(λ); otherwise it is Watkins's Q(λ), which
+// bootstraps from the greedy value and cuts traces after exploratory actions.
+// Traces only live for one episode and are cleared in EndEpisode.
+type TraceAgent struct {
+	*QLearningAgent
+	Lambda   float64
+	OnPolicy bool
+
+	traces map[string]map[string]float64
+	next   pendingAction
+}
+
+func NewTraceAgent(base *QLearningAgent, lambda float64, onPolicy bool) *TraceAgent {
+	return &TraceAgent{
+		QLearningAgent: base,
+		Lambda:         lambda,
+		OnPolicy:       onPolicy,
+		traces:         make(map[string]map[string]float64),
+	}
+}
+
+func (agent *TraceAgent) kind() string {
+	if agent.OnPolicy {
+		return AgentKindSARSALambda
+	}
+	return AgentKindQLambda
+}
+
+func (agent *TraceAgent) SelectActionWithMetrics(state State) (Action, logging.ActionMetrics) {
+	action, isExploration, ok := agent.next.take(agent.getStateKey(state))
+	if !ok {
+		action, isExploration = agent.chooseAction(state)
+	}
+	return action, agent.actionMetrics(state, action, isExploration, agent.GetQValue(state, action))
+}
+
+func (agent *TraceAgent) Update(state State, action Action, reward float64, nextState State, done bool) logging.LearningMetrics {
+	target := reward
+	keepTraces := false
+	if !done {
+		// Both variants commit to the next action now so the trace decision
+		// matches what will actually be executed
+		nextAction, isExploration := agent.chooseAction(nextState)
+		agent.next.set(agent.getStateKey(nextState), nextAction, isExploration)
+
+		if agent.OnPolicy {
+			target += agent.DiscountFactor * agent.GetQValue(nextState, nextAction)
+			keepTraces = true
+		} else {
+			target += agent.DiscountFactor * agent.getMaxQValue(nextState)
+			keepTraces = !isExploration
+		}
+	}
+
+	stateKey := agent.getStateKey(state)
+	actionKey := agent.getActionKey(action)
+	tdError := target - agent.GetQValue(state, action)
+
+	if agent.traces[stateKey] == nil {
+		agent.traces[stateKey] = make(map[string]float64)
+	}
+	agent.traces[stateKey][actionKey] = 1.0
+
+	for traceState, actions := range agent.traces {
+		if agent.QTable[traceState] == nil {
+			agent.QTable[traceState] = make(map[string]float64)
+		}
+		for traceAction, eligibility := range actions {
+			agent.QTable[traceState][traceAction] += agent.LearningRate * tdError * eligibility
+		}
+	}
+
+	if keepTraces {
+		agent.decayTraces()
+	} else {
+		agent.resetTraces()
+	}
+	agent.Exploration.EndStep()
+
+	return agent.learningMetrics()
+}
+
+func (agent *TraceAgent) decayTraces() {
+	decay := agent.DiscountFactor * agent.Lambda
+	for stateKey, actions := range agent.traces {
+		for actionKey, eligibility := range actions {
+			eligibility *= decay
+			if eligibility < traceCutoff {
+				delete(actions, actionKey)
+			} else {
+				actions[actionKey] = eligibility
+			}
+		}
+		if len(actions) == 0 {
+			delete(agent.traces, stateKey)
+		}
+	}
+}
+
+func (agent *TraceAgent) resetTraces() {
+	agent.traces = make(map[string]map[string]float64)
+}
+
+func (agent *TraceAgent) EndEpisode(episode logging.EpisodeMetrics) {
+	agent.resetTraces()
+	agent.next = pendingAction{}
+	agent.QLearningAgent.EndEpisode(episode)
+}
+
+func (agent *TraceAgent) Snapshot() AgentSnapshot {
+	snapshot := agent.snapshotAs(agent.kind())
+	snapshot.Hyperparameters["lambda"] = agent.Lambda
+	return snapshot
+}
+
+func (agent *TraceAgent) Restore(snapshot AgentSnapshot) error {
+	if err := agent.restoreAs(agent.kind(), snapshot); err != nil {
+		return err
+	}
+	if lambda, exists := snapshot.Hyperparameters["lambda"]; exists {
+		agent.Lambda = lambda
+	}
+	agent.resetTraces()
+	return nil
+}
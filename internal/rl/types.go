@@ -1,18 +1,53 @@
 package rl
 
 import (
+	"encoding/json"
 	"time"
 	"textlib-rl-system/internal/logging"
 	"textlib-rl-system/internal/telemetry"
 )
 
+// Agent is a learner that drives action selection in the training loop.
+// EnhancedRLSystem only talks to agents through this interface so new
+// learners can be registered with RegisterAgent without touching the loop.
+type Agent interface {
+	SelectActionWithMetrics(state State) (Action, logging.ActionMetrics)
+	GetQValue(state State, action Action) float64
+	Update(state State, action Action, reward float64, nextState State, done bool) logging.LearningMetrics
+	EndEpisode(episode logging.EpisodeMetrics)
+	Snapshot() AgentSnapshot
+	Restore(snapshot AgentSnapshot) error
+}
+
+// ExperienceAgent is implemented by agents that learn from stored experience
+// beyond their snapshot, such as Dyna-Q's model and the DQN replay buffer.
+// Checkpoints save the experience so a resumed run continues exactly; models
+// leave it out, since inference does not need it.
+type ExperienceAgent interface {
+	Experience() (json.RawMessage, error)
+	// RestoreExperience is called after Restore.
+	RestoreExperience(data json.RawMessage) error
+}
+
+// AgentSnapshot is a serializable copy of an agent's learned state.
+type AgentSnapshot struct {
+	Kind            string                                   `json:"kind"`
+	Hyperparameters map[string]float64                       `json:"hyperparameters"`
+	Tables          map[string]map[string]map[string]float64 `json:"tables,omitempty"`
+	Weights         map[string][]float64                     `json:"weights,omitempty"`
+	Options         map[string]string                        `json:"options,omitempty"`
+}
+
 type QLearningAgent struct {
-	QTable          map[string]map[string]float64
-	LearningRate    float64
-	DiscountFactor  float64
-	ExplorationRate float64
-	MinExploration  float64
-	DecayRate       float64
+	QTable         map[string]map[string]float64
+	LearningRate   float64
+	DiscountFactor float64
+	Exploration    ExplorationStrategy
+	Featurizer     StateFeaturizer
+	ActionSpace    *ActionSpace
+	// StateKeys selects how states are keyed: empty for the featurizer's
+	// readable keys, or StateKeysTextHash for tables migrated from 1.0 models
+	StateKeys      string
 }
 
 type RewardCalculator struct {
@@ -58,22 +93,138 @@ type SystemConfig struct {
 	CheckpointInterval int
 	MetricsPort        int
 	EnableProfiling    bool
+
+	// Checkpoints: every CheckpointInterval episodes the training state is
+	// written to CheckpointDir (empty disables them), keeping the newest
+	// CheckpointKeepLast files (default 5)
+	CheckpointDir      string
+	CheckpointKeepLast int
+
+	// Agent selection and shared learning hyperparameters. Settings for
+	// which zero is meaningful are pointers, so nil means unset and takes the
+	// default while 0 is used as given (Float64 and Int build them); the other
+	// settings treat zero as unset.
+	AgentType       string
+	LearningRate    float64
+	DiscountFactor  *float64 // 0 is myopic
+	ExplorationRate *float64 // 0 is purely greedy
+	MinExploration  *float64
+	DecayRate       float64
+
+	// Multi-step returns: NSteps for n-step agents, TraceLambda for Q(λ)/SARSA(λ)
+	NSteps      int
+	TraceLambda *float64
+
+	// Function approximation: weight decay and learning-rate annealing for
+	// linear_q. LearningRateSchedule is constant, inverse_time or exponential.
+	L2Regularization     *float64
+	LearningRateSchedule string
+	LearningRateDecay    float64
+
+	// Policy gradient (reinforce, actor_critic)
+	PolicyLearningRate float64
+	CriticLearningRate float64
+	EntropyCoefficient *float64
+
+	// Dyna-Q: simulated updates from the learned model per real step
+	PlanningSteps *int
+
+	// Neural Q-network (dqn)
+	HiddenSizes         []int
+	NetworkLearningRate float64
+	ReplayCapacity      int
+	ReplaySampling      string // uniform, proportional or rank
+	PriorityAlpha       *float64
+	PriorityBeta        *float64
+	BatchSize           int
+	TargetSyncInterval  int
+
+	// Values offered per action parameter when the action space is expanded
+	// into parameter variants; unset or 1 keeps the plain actions with default
+	// parameters
+	ParameterLevels int
+
+	// Parameter tuning: every ParameterTuningInterval episodes the GA tunes
+	// each function's parameters on TuningRollouts simulated calls per
+	// candidate. Zero disables tuning; when enabled the agent chooses plain
+	// actions and the tuned parameters are attached when they run.
+	ParameterTuningInterval int
+	TuningGenerations       int
+	TuningPopulation        int
+	TuningRollouts          int
+
+	// Contextual bandit (--mode=bandit): linucb or thompson. BanditAlpha is
+	// the LinUCB confidence width or the Thompson posterior scale.
+	BanditAlgorithm string
+	BanditAlpha     float64
+
+	// Held-out splits: ValidationFraction and TestFraction of the training
+	// data are set aside (0 trains on everything). SplitStrategy is random or
+	// stratified by task type; SplitSeed makes the split reproducible.
+	SplitStrategy      string
+	ValidationFraction float64
+	TestFraction       float64
+	SplitSeed          int64
+
+	// Curriculum: uniform (default), easy_to_hard, competence or self_paced.
+	// CurriculumStart is the share of the difficulty range (or, for
+	// competence, of the examples) available at first; CurriculumEpisodes is
+	// when the schedules reach the full set. Self-paced raises the ceiling by
+	// CurriculumStep of the range once recent per-step reward reaches
+	// CurriculumRewardThreshold. Start and threshold are pointers because 0
+	// is a valid setting for both; nil takes the default.
+	Curriculum                string
+	CurriculumStart           *float64
+	CurriculumEpisodes        int
+	CurriculumRewardThreshold *float64
+	CurriculumStep            float64
+
+	// Seed seeds every random choice in training: exploration, simulated
+	// failures, example sampling, network initialization and the optimizers.
+	// The same seed and configuration replay the same run on the simulator.
+	Seed int64
+
+	Exploration ExplorationConfig
+}
+
+// Float64 returns a pointer to value, for the optional SystemConfig fields.
+func Float64(value float64) *float64 {
+	return &value
+}
+
+// Int returns a pointer to value, for the optional SystemConfig fields.
+func Int(value int) *int {
+	return &value
 }
 
 type EnhancedRLSystem struct {
-	Agent        *QLearningAgent
+	Agent        Agent
 	Logger       *logging.InsightLogger
 	Telemetry    *telemetry.TelemetryClient
 	RewardCalc   *RewardCalculator
 	TrainingData []TrainingExample
 	Config       SystemConfig
+	// ValidationData is evaluated greedily every LoggingInterval episodes
+	ValidationData []TrainingExample
 	
-	availableActions []Action
-	simulator        *ActionSimulator
+	actionSpace       *ActionSpace
+	executor          Executor
+	evaluator         *ActionSimulator // evaluation's own instant simulator
+	tuner             *ParameterTuner
+	validationHistory []EvaluationResult
+	curriculum        *Curriculum
+	episode           int     // next episode to run
+	explorationRate   float64 // as of the last update
+	lastCheckpoint    int     // episode count of the newest checkpoint
 }
 
 type ActionSimulator struct {
 	Functions map[string]SimulatedFunction
+	// Instant skips sleeping out the simulated latency, for rollouts that only
+	// need the outcome; Duration reports the simulated latency either way
+	Instant bool
+	// Rand decides which calls fail; nil uses math/rand
+	Rand *Random
 }
 
 type SimulatedFunction struct {
@@ -104,6 +104,30 @@ func (tc *TelemetryClient) RecordLearningMetrics(metrics logging.LearningMetrics
 		Value:     metrics.LearningProgress,
 		Timestamp: time.Now(),
 	})
+	
+	tc.sendMetric(Metric{
+		Name:      "rl.learning.loss",
+		Value:     metrics.Loss,
+		Timestamp: time.Now(),
+	})
+	
+	tc.sendMetric(Metric{
+		Name:      "rl.learning.td_error",
+		Value:     metrics.TDError,
+		Timestamp: time.Now(),
+	})
+	
+	tc.sendMetric(Metric{
+		Name:      "rl.learning.planning_updates",
+		Value:     float64(metrics.PlanningUpdates),
+		Timestamp: time.Now(),
+	})
+	
+	tc.sendMetric(Metric{
+		Name:      "rl.learning.real_updates",
+		Value:     float64(metrics.RealUpdates),
+		Timestamp: time.Now(),
+	})
 }
 
 func (tc *TelemetryClient) RecordPerformanceMetrics(metrics logging.PerformanceMetrics) {
@@ -0,0 +1,240 @@
+//go:build textlib
+
+// Package textlibexec runs actions against the real textlib library instead
+// of the simulator. Importing it registers the "textlib" executor. It is only
+// built with -tags textlib, so the rest of the module builds without textlib.
+package textlibexec
+
+import (
+	"fmt"
+	"log"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/caiatech/textlib"
+
+	"textlib-rl-system/internal/rl"
+)
+
+const Name = "textlib"
+
+func init() {
+	rl.RegisterExecutor(Name, func(rl.SystemConfig) rl.Executor {
+		executor := NewExecutor()
+		log.Printf("textlib executor: %v have no textlib counterpart and run the simulator's output generators; their durations do not measure textlib",
+			executor.Simulated())
+		return executor
+	})
+}
+
+// Executor calls textlib for every action that has a textlib counterpart and
+// reports the measured wall time and bytes allocated. Actions without one
+// (keywords, sentiment, formatting) run the simulator's output generator
+// directly, without its simulated latency and failures, so they are measured
+// the same way.
+type Executor struct {
+	functions map[string]func(input string, params map[string]interface{}) (interface{}, error)
+	fallback  *rl.ActionSimulator
+}
+
+func NewExecutor() *Executor {
+	return &Executor{
+		functions: map[string]func(string, map[string]interface{}) (interface{}, error){
+			"extract_entities":    extractEntities,
+			"analyze_readability": analyzeReadability,
+			"detect_code":         detectCode,
+			"summarize_text":      summarizeText,
+			"validate_output":     checkInput,
+		},
+		fallback: rl.NewActionSimulator(),
+	}
+}
+
+func (executor *Executor) Name() string {
+	return Name
+}
+
+// Simulated lists the actions that run the simulator's output generator
+// because textlib has nothing for them.
+func (executor *Executor) Simulated() []string {
+	var names []string
+	for name := range executor.fallback.Functions {
+		if _, exists := executor.functions[name]; !exists {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ExecuteAction runs the action and measures it. MemoryUsed is the growth of
+// the process-wide TotalAlloc counter across the call, so concurrent work is
+// attributed to it too; training runs one action at a time.
+func (executor *Executor) ExecuteAction(action rl.Action, input string, params map[string]interface{}) (result rl.ActionResult) {
+	run, exists := executor.functions[action.FunctionName]
+	if !exists {
+		simulated, ok := executor.fallback.Functions[action.FunctionName]
+		if !ok {
+			return rl.ActionResult{Error: fmt.Sprintf("unknown function: %s", action.FunctionName)}
+		}
+		run = simulated.OutputGenerator
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	startTime := time.Now()
+
+	defer func() {
+		result.Duration = time.Since(startTime)
+		runtime.ReadMemStats(&after)
+		result.MemoryUsed = int64(after.TotalAlloc - before.TotalAlloc)
+		if recovered := recover(); recovered != nil {
+			result.Success = false
+			result.Output = nil
+			result.Error = fmt.Sprintf("%s panicked: %v", action.FunctionName, recovered)
+		}
+	}()
+
+	output, err := run(input, params)
+	if err != nil {
+		return rl.ActionResult{Error: err.Error()}
+	}
+	return rl.ActionResult{Success: true, Output: output}
+}
+
+// extractEntities calls ExtractNamedEntities. textlib does not score its
+// entities, so only max_entities applies.
+func extractEntities(input string, params map[string]interface{}) (interface{}, error) {
+	maxEntities := intParam(params, "max_entities", 100)
+
+	entities := []map[string]interface{}{}
+	for _, entity := range textlib.ExtractNamedEntities(input) {
+		if len(entities) >= maxEntities {
+			break
+		}
+		entities = append(entities, map[string]interface{}{
+			"text":  entity.Text,
+			"type":  entity.Type,
+			"start": entity.Position.Start,
+			"end":   entity.Position.End,
+		})
+	}
+
+	return map[string]interface{}{
+		"entities": entities,
+		"count":    len(entities),
+	}, nil
+}
+
+// analyzeReadability calls CalculateFleschReadingEase on at most sample_size
+// words, adding CalculateTextStatistics when include_advanced is set.
+func analyzeReadability(input string, params map[string]interface{}) (interface{}, error) {
+	sampleSize := intParam(params, "sample_size", 1000)
+	includeAdvanced := boolParam(params, "include_advanced", false)
+
+	words := strings.Fields(input)
+	sampled := sampleSize > 0 && len(words) > sampleSize
+	sample := input
+	if sampled {
+		sample = strings.Join(words[:sampleSize], " ")
+	}
+
+	sentences := textlib.SplitIntoSentences(sample)
+	output := map[string]interface{}{
+		"readability_score": textlib.CalculateFleschReadingEase(sample),
+		"total_words":       len(strings.Fields(sample)),
+		"total_sentences":   len(sentences),
+		"sampled":           sampled,
+	}
+	if includeAdvanced {
+		output["advanced"] = textlib.CalculateTextStatistics(sample)
+	}
+	return output, nil
+}
+
+// detectCode treats text with function signatures as code and reports its
+// cyclomatic complexity and the patterns textlib detects.
+func detectCode(input string, params map[string]interface{}) (interface{}, error) {
+	signatures := textlib.ExtractFunctionSignatures(input)
+
+	output := map[string]interface{}{
+		"has_code":   len(signatures) > 0,
+		"signatures": len(signatures),
+		"patterns":   textlib.DetectPatterns(input),
+	}
+	if len(signatures) > 0 {
+		output["complexity"] = textlib.CalculateCyclomaticComplexity(input)
+	}
+	return output, nil
+}
+
+// summarizeText keeps the first and last sentence textlib finds, skipping
+// sentences shorter than min_sentence_length characters. textlib has its own
+// sentence rules, so delimiter_style does not apply.
+func summarizeText(input string, params map[string]interface{}) (interface{}, error) {
+	minLength := intParam(params, "min_sentence_length", 10)
+
+	sentences := []string{}
+	for _, sentence := range textlib.SplitIntoSentences(input) {
+		if trimmed := strings.TrimSpace(sentence); len(trimmed) >= minLength {
+			sentences = append(sentences, trimmed)
+		}
+	}
+	if len(sentences) <= 2 {
+		return map[string]interface{}{
+			"summary":           input,
+			"compression_ratio": 1.0,
+			"sentences":         len(sentences),
+		}, nil
+	}
+
+	summary := sentences[0] + " " + sentences[len(sentences)-1]
+	return map[string]interface{}{
+		"summary":           summary,
+		"original_length":   len(input),
+		"summary_length":    len(summary),
+		"compression_ratio": float64(len(summary)) / float64(len(input)),
+		"sentences":         len(sentences),
+	}, nil
+}
+
+// checkInput runs validate_output. Executors only see the input text, not what
+// earlier actions produced, so like the simulator's version it is a sanity
+// check of the input: textlib must find words in it and it must not be too
+// long to analyze.
+func checkInput(input string, params map[string]interface{}) (interface{}, error) {
+	issues := []string{}
+	stats := textlib.CalculateTextStatistics(input)
+	if stats == nil || stats.WordCount == 0 {
+		issues = append(issues, "no_words")
+	}
+	if len(input) > 10000 {
+		issues = append(issues, "input_too_long")
+	}
+
+	return map[string]interface{}{
+		"is_valid": len(issues) == 0,
+		"issues":   issues,
+	}, nil
+}
+
+// JSON-decoded numbers arrive as float64, tuned ones as int or float64.
+
+func intParam(params map[string]interface{}, name string, def int) int {
+	switch value := params[name].(type) {
+	case int:
+		return value
+	case float64:
+		return int(value)
+	}
+	return def
+}
+
+func boolParam(params map[string]interface{}, name string, def bool) bool {
+	if value, ok := params[name].(bool); ok {
+		return value
+	}
+	return def
+}
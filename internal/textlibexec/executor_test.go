@@ -0,0 +1,69 @@
+//go:build textlib
+
+package textlibexec
+
+import (
+	"reflect"
+	"testing"
+
+	"textlib-rl-system/internal/rl"
+)
+
+const sampleText = "Alice Johnson visited Microsoft in Seattle on Monday. The meeting went well. Everyone agreed on the next steps."
+
+func TestExecutor_Registered(t *testing.T) {
+	executor, err := rl.NewExecutor(Name, rl.SystemConfig{})
+	if err != nil {
+		t.Fatalf("NewExecutor returned error: %v", err)
+	}
+	if executor.Name() != Name {
+		t.Errorf("Expected the textlib executor, got %s", executor.Name())
+	}
+}
+
+func TestExecutor_RunsEveryDefaultAction(t *testing.T) {
+	executor := NewExecutor()
+	for _, action := range rl.DefaultActionSpace().Actions {
+		result := executor.ExecuteAction(action, sampleText, nil)
+		if !result.Success {
+			t.Errorf("Expected %s to succeed, got %q", action.FunctionName, result.Error)
+			continue
+		}
+		if _, ok := result.Output.(map[string]interface{}); !ok {
+			t.Errorf("Expected a map output for %s, got %T", action.FunctionName, result.Output)
+		}
+		if result.Duration <= 0 {
+			t.Errorf("Expected a measured duration for %s", action.FunctionName)
+		}
+	}
+}
+
+func TestExecutor_AppliesParameters(t *testing.T) {
+	executor := NewExecutor()
+
+	result := executor.ExecuteAction(rl.Action{FunctionName: "extract_entities"}, sampleText, map[string]interface{}{"max_entities": 0})
+	if count := result.Output.(map[string]interface{})["count"]; count != 0 {
+		t.Errorf("Expected max_entities to cap the result, got %v", count)
+	}
+
+	result = executor.ExecuteAction(rl.Action{FunctionName: "analyze_readability"}, sampleText,
+		map[string]interface{}{"sample_size": 3.0, "include_advanced": true})
+	output := result.Output.(map[string]interface{})
+	if output["sampled"] != true || output["total_words"] != 3 || output["advanced"] == nil {
+		t.Errorf("Expected a sampled analysis with statistics, got %v", output)
+	}
+}
+
+func TestExecutor_ListsSimulatedActions(t *testing.T) {
+	want := []string{"extract_keywords", "format_text", "sentiment_analysis"}
+	if got := NewExecutor().Simulated(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v to run on the simulator, got %v", want, got)
+	}
+}
+
+func TestExecutor_UnknownFunction(t *testing.T) {
+	result := NewExecutor().ExecuteAction(rl.Action{FunctionName: "missing"}, sampleText, nil)
+	if result.Success || result.Error == "" {
+		t.Errorf("Expected an error for an unknown function, got %+v", result)
+	}
+}